@@ -23,12 +23,16 @@ const (
 )
 
 type Cache struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	PackageCount  int32                  `protobuf:"varint,1,opt,name=package_count,json=packageCount,proto3" json:"package_count,omitempty"`
-	Rules         []*parse.Rule          `protobuf:"bytes,2,rep,name=rules,proto3" json:"rules,omitempty"`
-	Key           string                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	PackageCount   int32                  `protobuf:"varint,1,opt,name=package_count,json=packageCount,proto3" json:"package_count,omitempty"`
+	Rules          []*parse.Rule          `protobuf:"bytes,2,rep,name=rules,proto3" json:"rules,omitempty"`
+	Key            string                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Files          []*ParsedFile          `protobuf:"bytes,4,rep,name=files,proto3" json:"files,omitempty"`
+	SchemaVersion  int32                  `protobuf:"varint,5,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	ParserVersion  string                 `protobuf:"bytes,6,opt,name=parser_version,json=parserVersion,proto3" json:"parser_version,omitempty"`
+	RuleVisitTimes map[string]int64       `protobuf:"bytes,7,rep,name=rule_visit_times,json=ruleVisitTimes,proto3" json:"rule_visit_times,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *Cache) Reset() {
@@ -82,6 +86,110 @@ func (x *Cache) GetKey() string {
 	return ""
 }
 
+func (x *Cache) GetFiles() []*ParsedFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *Cache) GetSchemaVersion() int32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *Cache) GetParserVersion() string {
+	if x != nil {
+		return x.ParserVersion
+	}
+	return ""
+}
+
+func (x *Cache) GetRuleVisitTimes() map[string]int64 {
+	if x != nil {
+		return x.RuleVisitTimes
+	}
+	return nil
+}
+
+type ParsedFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sha256        string                 `protobuf:"bytes,1,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	ParserVersion string                 `protobuf:"bytes,2,opt,name=parser_version,json=parserVersion,proto3" json:"parser_version,omitempty"`
+	File          *parse.File            `protobuf:"bytes,3,opt,name=file,proto3" json:"file,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	MtimeNanos    int64                  `protobuf:"varint,5,opt,name=mtime_nanos,json=mtimeNanos,proto3" json:"mtime_nanos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParsedFile) Reset() {
+	*x = ParsedFile{}
+	mi := &file_build_stack_gazelle_scala_cache_cache_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParsedFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParsedFile) ProtoMessage() {}
+
+func (x *ParsedFile) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_cache_cache_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParsedFile.ProtoReflect.Descriptor instead.
+func (*ParsedFile) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_cache_cache_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ParsedFile) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *ParsedFile) GetParserVersion() string {
+	if x != nil {
+		return x.ParserVersion
+	}
+	return ""
+}
+
+func (x *ParsedFile) GetFile() *parse.File {
+	if x != nil {
+		return x.File
+	}
+	return nil
+}
+
+func (x *ParsedFile) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ParsedFile) GetMtimeNanos() int64 {
+	if x != nil {
+		return x.MtimeNanos
+	}
+	return 0
+}
+
 type ResolvedImports struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Imports       map[string]string      `protobuf:"bytes,1,rep,name=imports,proto3" json:"imports,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
@@ -91,7 +199,7 @@ type ResolvedImports struct {
 
 func (x *ResolvedImports) Reset() {
 	*x = ResolvedImports{}
-	mi := &file_build_stack_gazelle_scala_cache_cache_proto_msgTypes[1]
+	mi := &file_build_stack_gazelle_scala_cache_cache_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -103,7 +211,7 @@ func (x *ResolvedImports) String() string {
 func (*ResolvedImports) ProtoMessage() {}
 
 func (x *ResolvedImports) ProtoReflect() protoreflect.Message {
-	mi := &file_build_stack_gazelle_scala_cache_cache_proto_msgTypes[1]
+	mi := &file_build_stack_gazelle_scala_cache_cache_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -116,7 +224,7 @@ func (x *ResolvedImports) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ResolvedImports.ProtoReflect.Descriptor instead.
 func (*ResolvedImports) Descriptor() ([]byte, []int) {
-	return file_build_stack_gazelle_scala_cache_cache_proto_rawDescGZIP(), []int{1}
+	return file_build_stack_gazelle_scala_cache_cache_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *ResolvedImports) GetImports() map[string]string {
@@ -130,11 +238,26 @@ var File_build_stack_gazelle_scala_cache_cache_proto protoreflect.FileDescriptor
 
 const file_build_stack_gazelle_scala_cache_cache_proto_rawDesc = "" +
 	"\n" +
-	"+build/stack/gazelle/scala/cache/cache.proto\x12\x1fbuild.stack.gazelle.scala.cache\x1a*build/stack/gazelle/scala/parse/rule.proto\"{\n" +
+	"+build/stack/gazelle/scala/cache/cache.proto\x12\x1fbuild.stack.gazelle.scala.cache\x1a*build/stack/gazelle/scala/parse/file.proto\x1a*build/stack/gazelle/scala/parse/rule.proto\"\xb5\x03\n" +
 	"\x05Cache\x12#\n" +
 	"\rpackage_count\x18\x01 \x01(\x05R\fpackageCount\x12;\n" +
 	"\x05rules\x18\x02 \x03(\v2%.build.stack.gazelle.scala.parse.RuleR\x05rules\x12\x10\n" +
-	"\x03key\x18\x03 \x01(\tR\x03key\"\xa6\x01\n" +
+	"\x03key\x18\x03 \x01(\tR\x03key\x12A\n" +
+	"\x05files\x18\x04 \x03(\v2+.build.stack.gazelle.scala.cache.ParsedFileR\x05files\x12%\n" +
+	"\x0eschema_version\x18\x05 \x01(\x05R\rschemaVersion\x12%\n" +
+	"\x0eparser_version\x18\x06 \x01(\tR\rparserVersion\x12d\n" +
+	"\x10rule_visit_times\x18\a \x03(\v2:.build.stack.gazelle.scala.cache.Cache.RuleVisitTimesEntryR\x0eruleVisitTimes\x1aA\n" +
+	"\x13RuleVisitTimesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\xbb\x01\n" +
+	"\n" +
+	"ParsedFile\x12\x16\n" +
+	"\x06sha256\x18\x01 \x01(\tR\x06sha256\x12%\n" +
+	"\x0eparser_version\x18\x02 \x01(\tR\rparserVersion\x129\n" +
+	"\x04file\x18\x03 \x01(\v2%.build.stack.gazelle.scala.parse.FileR\x04file\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12\x1f\n" +
+	"\vmtime_nanos\x18\x05 \x01(\x03R\n" +
+	"mtimeNanos\"\xa6\x01\n" +
 	"\x0fResolvedImports\x12W\n" +
 	"\aimports\x18\x01 \x03(\v2=.build.stack.gazelle.scala.cache.ResolvedImports.ImportsEntryR\aimports\x1a:\n" +
 	"\fImportsEntry\x12\x10\n" +
@@ -154,21 +277,27 @@ func file_build_stack_gazelle_scala_cache_cache_proto_rawDescGZIP() []byte {
 	return file_build_stack_gazelle_scala_cache_cache_proto_rawDescData
 }
 
-var file_build_stack_gazelle_scala_cache_cache_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_build_stack_gazelle_scala_cache_cache_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_build_stack_gazelle_scala_cache_cache_proto_goTypes = []any{
 	(*Cache)(nil),           // 0: build.stack.gazelle.scala.cache.Cache
-	(*ResolvedImports)(nil), // 1: build.stack.gazelle.scala.cache.ResolvedImports
-	nil,                     // 2: build.stack.gazelle.scala.cache.ResolvedImports.ImportsEntry
-	(*parse.Rule)(nil),      // 3: build.stack.gazelle.scala.parse.Rule
+	(*ParsedFile)(nil),      // 1: build.stack.gazelle.scala.cache.ParsedFile
+	(*ResolvedImports)(nil), // 2: build.stack.gazelle.scala.cache.ResolvedImports
+	nil,                     // 3: build.stack.gazelle.scala.cache.Cache.RuleVisitTimesEntry
+	nil,                     // 4: build.stack.gazelle.scala.cache.ResolvedImports.ImportsEntry
+	(*parse.Rule)(nil),      // 5: build.stack.gazelle.scala.parse.Rule
+	(*parse.File)(nil),      // 6: build.stack.gazelle.scala.parse.File
 }
 var file_build_stack_gazelle_scala_cache_cache_proto_depIdxs = []int32{
-	3, // 0: build.stack.gazelle.scala.cache.Cache.rules:type_name -> build.stack.gazelle.scala.parse.Rule
-	2, // 1: build.stack.gazelle.scala.cache.ResolvedImports.imports:type_name -> build.stack.gazelle.scala.cache.ResolvedImports.ImportsEntry
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	5, // 0: build.stack.gazelle.scala.cache.Cache.rules:type_name -> build.stack.gazelle.scala.parse.Rule
+	1, // 1: build.stack.gazelle.scala.cache.Cache.files:type_name -> build.stack.gazelle.scala.cache.ParsedFile
+	3, // 2: build.stack.gazelle.scala.cache.Cache.rule_visit_times:type_name -> build.stack.gazelle.scala.cache.Cache.RuleVisitTimesEntry
+	6, // 3: build.stack.gazelle.scala.cache.ParsedFile.file:type_name -> build.stack.gazelle.scala.parse.File
+	4, // 4: build.stack.gazelle.scala.cache.ResolvedImports.imports:type_name -> build.stack.gazelle.scala.cache.ResolvedImports.ImportsEntry
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_build_stack_gazelle_scala_cache_cache_proto_init() }
@@ -182,7 +311,7 @@ func file_build_stack_gazelle_scala_cache_cache_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_build_stack_gazelle_scala_cache_cache_proto_rawDesc), len(file_build_stack_gazelle_scala_cache_cache_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
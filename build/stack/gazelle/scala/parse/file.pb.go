@@ -66,22 +66,33 @@ func (x *FileSet) GetFiles() []*File {
 }
 
 type File struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Filename        string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
-	SemanticImports []string               `protobuf:"bytes,2,rep,name=semantic_imports,json=semanticImports,proto3" json:"semantic_imports,omitempty"`
-	Imports         []string               `protobuf:"bytes,3,rep,name=imports,proto3" json:"imports,omitempty"`
-	Packages        []string               `protobuf:"bytes,4,rep,name=packages,proto3" json:"packages,omitempty"`
-	Classes         []string               `protobuf:"bytes,5,rep,name=classes,proto3" json:"classes,omitempty"`
-	Objects         []string               `protobuf:"bytes,6,rep,name=objects,proto3" json:"objects,omitempty"`
-	Traits          []string               `protobuf:"bytes,7,rep,name=traits,proto3" json:"traits,omitempty"`
-	Types           []string               `protobuf:"bytes,8,rep,name=types,proto3" json:"types,omitempty"`
-	Vals            []string               `protobuf:"bytes,9,rep,name=vals,proto3" json:"vals,omitempty"`
-	Names           []string               `protobuf:"bytes,10,rep,name=names,proto3" json:"names,omitempty"`
-	Extends         map[string]*ClassList  `protobuf:"bytes,11,rep,name=extends,proto3" json:"extends,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Error           string                 `protobuf:"bytes,13,opt,name=error,proto3" json:"error,omitempty"`
-	Tree            string                 `protobuf:"bytes,14,opt,name=tree,proto3" json:"tree,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Filename            string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	SemanticImports     []string               `protobuf:"bytes,2,rep,name=semantic_imports,json=semanticImports,proto3" json:"semantic_imports,omitempty"`
+	Imports             []string               `protobuf:"bytes,3,rep,name=imports,proto3" json:"imports,omitempty"`
+	Packages            []string               `protobuf:"bytes,4,rep,name=packages,proto3" json:"packages,omitempty"`
+	Classes             []string               `protobuf:"bytes,5,rep,name=classes,proto3" json:"classes,omitempty"`
+	Objects             []string               `protobuf:"bytes,6,rep,name=objects,proto3" json:"objects,omitempty"`
+	Traits              []string               `protobuf:"bytes,7,rep,name=traits,proto3" json:"traits,omitempty"`
+	Types               []string               `protobuf:"bytes,8,rep,name=types,proto3" json:"types,omitempty"`
+	Vals                []string               `protobuf:"bytes,9,rep,name=vals,proto3" json:"vals,omitempty"`
+	Names               []string               `protobuf:"bytes,10,rep,name=names,proto3" json:"names,omitempty"`
+	Extends             map[string]*ClassList  `protobuf:"bytes,11,rep,name=extends,proto3" json:"extends,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Error               string                 `protobuf:"bytes,13,opt,name=error,proto3" json:"error,omitempty"`
+	Tree                string                 `protobuf:"bytes,14,opt,name=tree,proto3" json:"tree,omitempty"`
+	MainObjects         []string               `protobuf:"bytes,15,rep,name=main_objects,json=mainObjects,proto3" json:"main_objects,omitempty"`
+	SemanticExports     []string               `protobuf:"bytes,16,rep,name=semantic_exports,json=semanticExports,proto3" json:"semantic_exports,omitempty"`
+	Enums               []string               `protobuf:"bytes,17,rep,name=enums,proto3" json:"enums,omitempty"`
+	Givens              []string               `protobuf:"bytes,18,rep,name=givens,proto3" json:"givens,omitempty"`
+	Defs                []string               `protobuf:"bytes,19,rep,name=defs,proto3" json:"defs,omitempty"`
+	Exports             []string               `protobuf:"bytes,20,rep,name=exports,proto3" json:"exports,omitempty"`
+	ImportPositions     map[string]*Position   `protobuf:"bytes,21,rep,name=import_positions,json=importPositions,proto3" json:"import_positions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	DefinitionPositions map[string]*Position   `protobuf:"bytes,22,rep,name=definition_positions,json=definitionPositions,proto3" json:"definition_positions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	NamePositions       map[string]*Position   `protobuf:"bytes,23,rep,name=name_positions,json=namePositions,proto3" json:"name_positions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ImportSelectors     []*ImportSelector      `protobuf:"bytes,24,rep,name=import_selectors,json=importSelectors,proto3" json:"import_selectors,omitempty"`
+	Degraded            bool                   `protobuf:"varint,25,opt,name=degraded,proto3" json:"degraded,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
 func (x *File) Reset() {
@@ -205,6 +216,211 @@ func (x *File) GetTree() string {
 	return ""
 }
 
+func (x *File) GetMainObjects() []string {
+	if x != nil {
+		return x.MainObjects
+	}
+	return nil
+}
+
+func (x *File) GetSemanticExports() []string {
+	if x != nil {
+		return x.SemanticExports
+	}
+	return nil
+}
+
+func (x *File) GetEnums() []string {
+	if x != nil {
+		return x.Enums
+	}
+	return nil
+}
+
+func (x *File) GetGivens() []string {
+	if x != nil {
+		return x.Givens
+	}
+	return nil
+}
+
+func (x *File) GetDefs() []string {
+	if x != nil {
+		return x.Defs
+	}
+	return nil
+}
+
+func (x *File) GetExports() []string {
+	if x != nil {
+		return x.Exports
+	}
+	return nil
+}
+
+func (x *File) GetImportPositions() map[string]*Position {
+	if x != nil {
+		return x.ImportPositions
+	}
+	return nil
+}
+
+func (x *File) GetDefinitionPositions() map[string]*Position {
+	if x != nil {
+		return x.DefinitionPositions
+	}
+	return nil
+}
+
+func (x *File) GetNamePositions() map[string]*Position {
+	if x != nil {
+		return x.NamePositions
+	}
+	return nil
+}
+
+func (x *File) GetImportSelectors() []*ImportSelector {
+	if x != nil {
+		return x.ImportSelectors
+	}
+	return nil
+}
+
+func (x *File) GetDegraded() bool {
+	if x != nil {
+		return x.Degraded
+	}
+	return false
+}
+
+type ImportSelector struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Imp           string                 `protobuf:"bytes,1,opt,name=imp,proto3" json:"imp,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Alias         string                 `protobuf:"bytes,3,opt,name=alias,proto3" json:"alias,omitempty"`
+	Hidden        bool                   `protobuf:"varint,4,opt,name=hidden,proto3" json:"hidden,omitempty"`
+	Nested        bool                   `protobuf:"varint,5,opt,name=nested,proto3" json:"nested,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportSelector) Reset() {
+	*x = ImportSelector{}
+	mi := &file_build_stack_gazelle_scala_parse_file_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportSelector) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportSelector) ProtoMessage() {}
+
+func (x *ImportSelector) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_parse_file_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportSelector.ProtoReflect.Descriptor instead.
+func (*ImportSelector) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_parse_file_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ImportSelector) GetImp() string {
+	if x != nil {
+		return x.Imp
+	}
+	return ""
+}
+
+func (x *ImportSelector) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ImportSelector) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *ImportSelector) GetHidden() bool {
+	if x != nil {
+		return x.Hidden
+	}
+	return false
+}
+
+func (x *ImportSelector) GetNested() bool {
+	if x != nil {
+		return x.Nested
+	}
+	return false
+}
+
+type Position struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Line          int32                  `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"`
+	Column        int32                  `protobuf:"varint,2,opt,name=column,proto3" json:"column,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Position) Reset() {
+	*x = Position{}
+	mi := &file_build_stack_gazelle_scala_parse_file_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Position) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Position) ProtoMessage() {}
+
+func (x *Position) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_parse_file_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Position.ProtoReflect.Descriptor instead.
+func (*Position) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_parse_file_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Position) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *Position) GetColumn() int32 {
+	if x != nil {
+		return x.Column
+	}
+	return 0
+}
+
 type ClassList struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Classes       []string               `protobuf:"bytes,1,rep,name=classes,proto3" json:"classes,omitempty"`
@@ -214,7 +430,7 @@ type ClassList struct {
 
 func (x *ClassList) Reset() {
 	*x = ClassList{}
-	mi := &file_build_stack_gazelle_scala_parse_file_proto_msgTypes[2]
+	mi := &file_build_stack_gazelle_scala_parse_file_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -226,7 +442,7 @@ func (x *ClassList) String() string {
 func (*ClassList) ProtoMessage() {}
 
 func (x *ClassList) ProtoReflect() protoreflect.Message {
-	mi := &file_build_stack_gazelle_scala_parse_file_proto_msgTypes[2]
+	mi := &file_build_stack_gazelle_scala_parse_file_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -239,7 +455,7 @@ func (x *ClassList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ClassList.ProtoReflect.Descriptor instead.
 func (*ClassList) Descriptor() ([]byte, []int) {
-	return file_build_stack_gazelle_scala_parse_file_proto_rawDescGZIP(), []int{2}
+	return file_build_stack_gazelle_scala_parse_file_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ClassList) GetClasses() []string {
@@ -255,7 +471,7 @@ const file_build_stack_gazelle_scala_parse_file_proto_rawDesc = "" +
 	"\n" +
 	"*build/stack/gazelle/scala/parse/file.proto\x12\x1fbuild.stack.gazelle.scala.parse\"F\n" +
 	"\aFileSet\x12;\n" +
-	"\x05files\x18\x01 \x03(\v2%.build.stack.gazelle.scala.parse.FileR\x05files\"\xef\x03\n" +
+	"\x05files\x18\x01 \x03(\v2%.build.stack.gazelle.scala.parse.FileR\x05files\"\x9b\v\n" +
 	"\x04File\x12\x1a\n" +
 	"\bfilename\x18\x01 \x01(\tR\bfilename\x12)\n" +
 	"\x10semantic_imports\x18\x02 \x03(\tR\x0fsemanticImports\x12\x18\n" +
@@ -270,10 +486,39 @@ const file_build_stack_gazelle_scala_parse_file_proto_rawDesc = "" +
 	" \x03(\tR\x05names\x12L\n" +
 	"\aextends\x18\v \x03(\v22.build.stack.gazelle.scala.parse.File.ExtendsEntryR\aextends\x12\x14\n" +
 	"\x05error\x18\r \x01(\tR\x05error\x12\x12\n" +
-	"\x04tree\x18\x0e \x01(\tR\x04tree\x1af\n" +
+	"\x04tree\x18\x0e \x01(\tR\x04tree\x12!\n" +
+	"\fmain_objects\x18\x0f \x03(\tR\vmainObjects\x12)\n" +
+	"\x10semantic_exports\x18\x10 \x03(\tR\x0fsemanticExports\x12\x14\n" +
+	"\x05enums\x18\x11 \x03(\tR\x05enums\x12\x16\n" +
+	"\x06givens\x18\x12 \x03(\tR\x06givens\x12\x12\n" +
+	"\x04defs\x18\x13 \x03(\tR\x04defs\x12\x18\n" +
+	"\aexports\x18\x14 \x03(\tR\aexports\x12e\n" +
+	"\x10import_positions\x18\x15 \x03(\v2:.build.stack.gazelle.scala.parse.File.ImportPositionsEntryR\x0fimportPositions\x12q\n" +
+	"\x14definition_positions\x18\x16 \x03(\v2>.build.stack.gazelle.scala.parse.File.DefinitionPositionsEntryR\x13definitionPositions\x12_\n" +
+	"\x0ename_positions\x18\x17 \x03(\v28.build.stack.gazelle.scala.parse.File.NamePositionsEntryR\rnamePositions\x12Z\n" +
+	"\x10import_selectors\x18\x18 \x03(\v2/.build.stack.gazelle.scala.parse.ImportSelectorR\x0fimportSelectors\x12\x1a\n" +
+	"\bdegraded\x18\x19 \x01(\bR\bdegraded\x1af\n" +
 	"\fExtendsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12@\n" +
-	"\x05value\x18\x02 \x01(\v2*.build.stack.gazelle.scala.parse.ClassListR\x05value:\x028\x01\"%\n" +
+	"\x05value\x18\x02 \x01(\v2*.build.stack.gazelle.scala.parse.ClassListR\x05value:\x028\x01\x1am\n" +
+	"\x14ImportPositionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12?\n" +
+	"\x05value\x18\x02 \x01(\v2).build.stack.gazelle.scala.parse.PositionR\x05value:\x028\x01\x1aq\n" +
+	"\x18DefinitionPositionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12?\n" +
+	"\x05value\x18\x02 \x01(\v2).build.stack.gazelle.scala.parse.PositionR\x05value:\x028\x01\x1ak\n" +
+	"\x12NamePositionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12?\n" +
+	"\x05value\x18\x02 \x01(\v2).build.stack.gazelle.scala.parse.PositionR\x05value:\x028\x01\"|\n" +
+	"\x0eImportSelector\x12\x10\n" +
+	"\x03imp\x18\x01 \x01(\tR\x03imp\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05alias\x18\x03 \x01(\tR\x05alias\x12\x16\n" +
+	"\x06hidden\x18\x04 \x01(\bR\x06hidden\x12\x16\n" +
+	"\x06nested\x18\x05 \x01(\bR\x06nested\"6\n" +
+	"\bPosition\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\x05R\x04line\x12\x16\n" +
+	"\x06column\x18\x02 \x01(\x05R\x06column\"%\n" +
 	"\tClassList\x12\x18\n" +
 	"\aclasses\x18\x01 \x03(\tR\aclassesBj\n" +
 	"\x1fbuild.stack.gazelle.scala.parseP\x01ZEgithub.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse;parseb\x06proto3"
@@ -290,22 +535,34 @@ func file_build_stack_gazelle_scala_parse_file_proto_rawDescGZIP() []byte {
 	return file_build_stack_gazelle_scala_parse_file_proto_rawDescData
 }
 
-var file_build_stack_gazelle_scala_parse_file_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_build_stack_gazelle_scala_parse_file_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_build_stack_gazelle_scala_parse_file_proto_goTypes = []any{
-	(*FileSet)(nil),   // 0: build.stack.gazelle.scala.parse.FileSet
-	(*File)(nil),      // 1: build.stack.gazelle.scala.parse.File
-	(*ClassList)(nil), // 2: build.stack.gazelle.scala.parse.ClassList
-	nil,               // 3: build.stack.gazelle.scala.parse.File.ExtendsEntry
+	(*FileSet)(nil),        // 0: build.stack.gazelle.scala.parse.FileSet
+	(*File)(nil),           // 1: build.stack.gazelle.scala.parse.File
+	(*ImportSelector)(nil), // 2: build.stack.gazelle.scala.parse.ImportSelector
+	(*Position)(nil),       // 3: build.stack.gazelle.scala.parse.Position
+	(*ClassList)(nil),      // 4: build.stack.gazelle.scala.parse.ClassList
+	nil,                    // 5: build.stack.gazelle.scala.parse.File.ExtendsEntry
+	nil,                    // 6: build.stack.gazelle.scala.parse.File.ImportPositionsEntry
+	nil,                    // 7: build.stack.gazelle.scala.parse.File.DefinitionPositionsEntry
+	nil,                    // 8: build.stack.gazelle.scala.parse.File.NamePositionsEntry
 }
 var file_build_stack_gazelle_scala_parse_file_proto_depIdxs = []int32{
-	1, // 0: build.stack.gazelle.scala.parse.FileSet.files:type_name -> build.stack.gazelle.scala.parse.File
-	3, // 1: build.stack.gazelle.scala.parse.File.extends:type_name -> build.stack.gazelle.scala.parse.File.ExtendsEntry
-	2, // 2: build.stack.gazelle.scala.parse.File.ExtendsEntry.value:type_name -> build.stack.gazelle.scala.parse.ClassList
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	1,  // 0: build.stack.gazelle.scala.parse.FileSet.files:type_name -> build.stack.gazelle.scala.parse.File
+	5,  // 1: build.stack.gazelle.scala.parse.File.extends:type_name -> build.stack.gazelle.scala.parse.File.ExtendsEntry
+	6,  // 2: build.stack.gazelle.scala.parse.File.import_positions:type_name -> build.stack.gazelle.scala.parse.File.ImportPositionsEntry
+	7,  // 3: build.stack.gazelle.scala.parse.File.definition_positions:type_name -> build.stack.gazelle.scala.parse.File.DefinitionPositionsEntry
+	8,  // 4: build.stack.gazelle.scala.parse.File.name_positions:type_name -> build.stack.gazelle.scala.parse.File.NamePositionsEntry
+	2,  // 5: build.stack.gazelle.scala.parse.File.import_selectors:type_name -> build.stack.gazelle.scala.parse.ImportSelector
+	4,  // 6: build.stack.gazelle.scala.parse.File.ExtendsEntry.value:type_name -> build.stack.gazelle.scala.parse.ClassList
+	3,  // 7: build.stack.gazelle.scala.parse.File.ImportPositionsEntry.value:type_name -> build.stack.gazelle.scala.parse.Position
+	3,  // 8: build.stack.gazelle.scala.parse.File.DefinitionPositionsEntry.value:type_name -> build.stack.gazelle.scala.parse.Position
+	3,  // 9: build.stack.gazelle.scala.parse.File.NamePositionsEntry.value:type_name -> build.stack.gazelle.scala.parse.Position
+	10, // [10:10] is the sub-list for method output_type
+	10, // [10:10] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_build_stack_gazelle_scala_parse_file_proto_init() }
@@ -319,7 +576,7 @@ func file_build_stack_gazelle_scala_parse_file_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_build_stack_gazelle_scala_parse_file_proto_rawDesc), len(file_build_stack_gazelle_scala_parse_file_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -40,6 +40,9 @@ const (
 	ImportType_PROTO_MESSAGE       ImportType = 14
 	ImportType_PROTO_SERVICE       ImportType = 15
 	ImportType_PROTO_PACKAGE       ImportType = 16
+	ImportType_ENUM                ImportType = 17
+	ImportType_GIVEN               ImportType = 18
+	ImportType_DEF                 ImportType = 19
 )
 
 // Enum value maps for ImportType.
@@ -61,6 +64,9 @@ var (
 		14: "PROTO_MESSAGE",
 		15: "PROTO_SERVICE",
 		16: "PROTO_PACKAGE",
+		17: "ENUM",
+		18: "GIVEN",
+		19: "DEF",
 	}
 	ImportType_value = map[string]int32{
 		"IMPORT_TYPE_UNKNOWN": 0,
@@ -79,6 +85,9 @@ var (
 		"PROTO_MESSAGE":       14,
 		"PROTO_SERVICE":       15,
 		"PROTO_PACKAGE":       16,
+		"ENUM":                17,
+		"GIVEN":               18,
+		"DEF":                 19,
 	}
 )
 
@@ -120,6 +129,8 @@ const (
 	ImportKind_RESOLVED_NAME       ImportKind = 5
 	ImportKind_TRANSITIVE          ImportKind = 6
 	ImportKind_SEMANTIC            ImportKind = 7
+	ImportKind_RUNTIME             ImportKind = 8
+	ImportKind_EXPORT              ImportKind = 9
 )
 
 // Enum value maps for ImportKind.
@@ -133,6 +144,8 @@ var (
 		5: "RESOLVED_NAME",
 		6: "TRANSITIVE",
 		7: "SEMANTIC",
+		8: "RUNTIME",
+		9: "EXPORT",
 	}
 	ImportKind_value = map[string]int32{
 		"IMPORT_KIND_UNKNOWN": 0,
@@ -143,6 +156,8 @@ var (
 		"RESOLVED_NAME":       5,
 		"TRANSITIVE":          6,
 		"SEMANTIC":            7,
+		"RUNTIME":             8,
+		"EXPORT":              9,
 	}
 )
 
@@ -177,7 +192,7 @@ var File_build_stack_gazelle_scala_parse_import_proto protoreflect.FileDescripto
 
 const file_build_stack_gazelle_scala_parse_import_proto_rawDesc = "" +
 	"\n" +
-	",build/stack/gazelle/scala/parse/import.proto\x12\x1fbuild.stack.gazelle.scala.parse*\x86\x02\n" +
+	",build/stack/gazelle/scala/parse/import.proto\x12\x1fbuild.stack.gazelle.scala.parse*\xa4\x02\n" +
 	"\n" +
 	"ImportType\x12\x17\n" +
 	"\x13IMPORT_TYPE_UNKNOWN\x10\x00\x12\v\n" +
@@ -198,7 +213,10 @@ const file_build_stack_gazelle_scala_parse_import_proto_rawDesc = "" +
 	"\x10PROTO_ENUM_FIELD\x10\r\x12\x11\n" +
 	"\rPROTO_MESSAGE\x10\x0e\x12\x11\n" +
 	"\rPROTO_SERVICE\x10\x0f\x12\x11\n" +
-	"\rPROTO_PACKAGE\x10\x10*\x8d\x01\n" +
+	"\rPROTO_PACKAGE\x10\x10\x12\b\n" +
+	"\x04ENUM\x10\x11\x12\t\n" +
+	"\x05GIVEN\x10\x12\x12\a\n" +
+	"\x03DEF\x10\x13*\xa6\x01\n" +
 	"\n" +
 	"ImportKind\x12\x17\n" +
 	"\x13IMPORT_KIND_UNKNOWN\x10\x00\x12\n" +
@@ -211,7 +229,10 @@ const file_build_stack_gazelle_scala_parse_import_proto_rawDesc = "" +
 	"\rRESOLVED_NAME\x10\x05\x12\x0e\n" +
 	"\n" +
 	"TRANSITIVE\x10\x06\x12\f\n" +
-	"\bSEMANTIC\x10\aBj\n" +
+	"\bSEMANTIC\x10\a\x12\v\n" +
+	"\aRUNTIME\x10\b\x12\n" +
+	"\n" +
+	"\x06EXPORT\x10\tBj\n" +
 	"\x1fbuild.stack.gazelle.scala.parseP\x01ZEgithub.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse;parseb\x06proto3"
 
 var (
@@ -25,6 +25,8 @@ type ParseRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Filenames     []string               `protobuf:"bytes,1,rep,name=filenames,proto3" json:"filenames,omitempty"`
 	WantParseTree bool                   `protobuf:"varint,2,opt,name=want_parse_tree,json=wantParseTree,proto3" json:"want_parse_tree,omitempty"`
+	Dialect       string                 `protobuf:"bytes,3,opt,name=dialect,proto3" json:"dialect,omitempty"`
+	WantPositions bool                   `protobuf:"varint,4,opt,name=want_positions,json=wantPositions,proto3" json:"want_positions,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -73,6 +75,20 @@ func (x *ParseRequest) GetWantParseTree() bool {
 	return false
 }
 
+func (x *ParseRequest) GetDialect() string {
+	if x != nil {
+		return x.Dialect
+	}
+	return ""
+}
+
+func (x *ParseRequest) GetWantPositions() bool {
+	if x != nil {
+		return x.WantPositions
+	}
+	return false
+}
+
 type ParseResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Files         []*File                `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
@@ -137,10 +153,12 @@ var File_build_stack_gazelle_scala_parse_parser_proto protoreflect.FileDescripto
 
 const file_build_stack_gazelle_scala_parse_parser_proto_rawDesc = "" +
 	"\n" +
-	",build/stack/gazelle/scala/parse/parser.proto\x12\x1fbuild.stack.gazelle.scala.parse\x1a*build/stack/gazelle/scala/parse/file.proto\"T\n" +
+	",build/stack/gazelle/scala/parse/parser.proto\x12\x1fbuild.stack.gazelle.scala.parse\x1a*build/stack/gazelle/scala/parse/file.proto\"\x95\x01\n" +
 	"\fParseRequest\x12\x1c\n" +
 	"\tfilenames\x18\x01 \x03(\tR\tfilenames\x12&\n" +
-	"\x0fwant_parse_tree\x18\x02 \x01(\bR\rwantParseTree\"\x89\x01\n" +
+	"\x0fwant_parse_tree\x18\x02 \x01(\bR\rwantParseTree\x12\x18\n" +
+	"\adialect\x18\x03 \x01(\tR\adialect\x12%\n" +
+	"\x0ewant_positions\x18\x04 \x01(\bR\rwantPositions\"\x89\x01\n" +
 	"\rParseResponse\x12;\n" +
 	"\x05files\x18\x01 \x03(\v2%.build.stack.gazelle.scala.parse.FileR\x05files\x12\x14\n" +
 	"\x05error\x18\x02 \x01(\tR\x05error\x12%\n" +
@@ -0,0 +1,677 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v6.32.1
+// source: build/stack/gazelle/scala/provider/provider.proto
+
+package provider
+
+import (
+	parse "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Request struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Request:
+	//
+	//	*Request_Initialize
+	//	*Request_CanProvide
+	//	*Request_Shutdown
+	Request       isRequest_Request `protobuf_oneof:"request"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Request) Reset() {
+	*x = Request{}
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Request) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request) ProtoMessage() {}
+
+func (x *Request) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request.ProtoReflect.Descriptor instead.
+func (*Request) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_provider_provider_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Request) GetRequest() isRequest_Request {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *Request) GetInitialize() *InitializeRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Initialize); ok {
+			return x.Initialize
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetCanProvide() *CanProvideRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_CanProvide); ok {
+			return x.CanProvide
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetShutdown() *ShutdownRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Shutdown); ok {
+			return x.Shutdown
+		}
+	}
+	return nil
+}
+
+type isRequest_Request interface {
+	isRequest_Request()
+}
+
+type Request_Initialize struct {
+	Initialize *InitializeRequest `protobuf:"bytes,1,opt,name=initialize,proto3,oneof"`
+}
+
+type Request_CanProvide struct {
+	CanProvide *CanProvideRequest `protobuf:"bytes,2,opt,name=can_provide,json=canProvide,proto3,oneof"`
+}
+
+type Request_Shutdown struct {
+	Shutdown *ShutdownRequest `protobuf:"bytes,3,opt,name=shutdown,proto3,oneof"`
+}
+
+func (*Request_Initialize) isRequest_Request() {}
+
+func (*Request_CanProvide) isRequest_Request() {}
+
+func (*Request_Shutdown) isRequest_Request() {}
+
+type Response struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Response:
+	//
+	//	*Response_Symbol
+	//	*Response_Initialize
+	//	*Response_CanProvide
+	//	*Response_Shutdown
+	Response      isResponse_Response `protobuf_oneof:"response"`
+	Error         string              `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Response) Reset() {
+	*x = Response{}
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Response) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Response) ProtoMessage() {}
+
+func (x *Response) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Response.ProtoReflect.Descriptor instead.
+func (*Response) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_provider_provider_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Response) GetResponse() isResponse_Response {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *Response) GetSymbol() *Symbol {
+	if x != nil {
+		if x, ok := x.Response.(*Response_Symbol); ok {
+			return x.Symbol
+		}
+	}
+	return nil
+}
+
+func (x *Response) GetInitialize() *InitializeResponse {
+	if x != nil {
+		if x, ok := x.Response.(*Response_Initialize); ok {
+			return x.Initialize
+		}
+	}
+	return nil
+}
+
+func (x *Response) GetCanProvide() *CanProvideResponse {
+	if x != nil {
+		if x, ok := x.Response.(*Response_CanProvide); ok {
+			return x.CanProvide
+		}
+	}
+	return nil
+}
+
+func (x *Response) GetShutdown() *ShutdownResponse {
+	if x != nil {
+		if x, ok := x.Response.(*Response_Shutdown); ok {
+			return x.Shutdown
+		}
+	}
+	return nil
+}
+
+func (x *Response) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type isResponse_Response interface {
+	isResponse_Response()
+}
+
+type Response_Symbol struct {
+	Symbol *Symbol `protobuf:"bytes,1,opt,name=symbol,proto3,oneof"`
+}
+
+type Response_Initialize struct {
+	Initialize *InitializeResponse `protobuf:"bytes,2,opt,name=initialize,proto3,oneof"`
+}
+
+type Response_CanProvide struct {
+	CanProvide *CanProvideResponse `protobuf:"bytes,3,opt,name=can_provide,json=canProvide,proto3,oneof"`
+}
+
+type Response_Shutdown struct {
+	Shutdown *ShutdownResponse `protobuf:"bytes,4,opt,name=shutdown,proto3,oneof"`
+}
+
+func (*Response_Symbol) isResponse_Response() {}
+
+func (*Response_Initialize) isResponse_Response() {}
+
+func (*Response_CanProvide) isResponse_Response() {}
+
+func (*Response_Shutdown) isResponse_Response() {}
+
+type InitializeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	RepoRoot      string                 `protobuf:"bytes,2,opt,name=repo_root,json=repoRoot,proto3" json:"repo_root,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InitializeRequest) Reset() {
+	*x = InitializeRequest{}
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InitializeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InitializeRequest) ProtoMessage() {}
+
+func (x *InitializeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InitializeRequest.ProtoReflect.Descriptor instead.
+func (*InitializeRequest) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_provider_provider_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *InitializeRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *InitializeRequest) GetRepoRoot() string {
+	if x != nil {
+		return x.RepoRoot
+	}
+	return ""
+}
+
+type Symbol struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          parse.ImportType       `protobuf:"varint,1,opt,name=type,proto3,enum=build.stack.gazelle.scala.parse.ImportType" json:"type,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Label         string                 `protobuf:"bytes,3,opt,name=label,proto3" json:"label,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Symbol) Reset() {
+	*x = Symbol{}
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Symbol) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Symbol) ProtoMessage() {}
+
+func (x *Symbol) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Symbol.ProtoReflect.Descriptor instead.
+func (*Symbol) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_provider_provider_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Symbol) GetType() parse.ImportType {
+	if x != nil {
+		return x.Type
+	}
+	return parse.ImportType(0)
+}
+
+func (x *Symbol) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Symbol) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+type InitializeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InitializeResponse) Reset() {
+	*x = InitializeResponse{}
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InitializeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InitializeResponse) ProtoMessage() {}
+
+func (x *InitializeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InitializeResponse.ProtoReflect.Descriptor instead.
+func (*InitializeResponse) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_provider_provider_proto_rawDescGZIP(), []int{4}
+}
+
+type CanProvideRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	From          string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CanProvideRequest) Reset() {
+	*x = CanProvideRequest{}
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CanProvideRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CanProvideRequest) ProtoMessage() {}
+
+func (x *CanProvideRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CanProvideRequest.ProtoReflect.Descriptor instead.
+func (*CanProvideRequest) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_provider_provider_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CanProvideRequest) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *CanProvideRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+type CanProvideResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CanProvide    bool                   `protobuf:"varint,1,opt,name=can_provide,json=canProvide,proto3" json:"can_provide,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CanProvideResponse) Reset() {
+	*x = CanProvideResponse{}
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CanProvideResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CanProvideResponse) ProtoMessage() {}
+
+func (x *CanProvideResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CanProvideResponse.ProtoReflect.Descriptor instead.
+func (*CanProvideResponse) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_provider_provider_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CanProvideResponse) GetCanProvide() bool {
+	if x != nil {
+		return x.CanProvide
+	}
+	return false
+}
+
+type ShutdownRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShutdownRequest) Reset() {
+	*x = ShutdownRequest{}
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShutdownRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShutdownRequest) ProtoMessage() {}
+
+func (x *ShutdownRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShutdownRequest.ProtoReflect.Descriptor instead.
+func (*ShutdownRequest) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_provider_provider_proto_rawDescGZIP(), []int{7}
+}
+
+type ShutdownResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShutdownResponse) Reset() {
+	*x = ShutdownResponse{}
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShutdownResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShutdownResponse) ProtoMessage() {}
+
+func (x *ShutdownResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShutdownResponse.ProtoReflect.Descriptor instead.
+func (*ShutdownResponse) Descriptor() ([]byte, []int) {
+	return file_build_stack_gazelle_scala_provider_provider_proto_rawDescGZIP(), []int{8}
+}
+
+var File_build_stack_gazelle_scala_provider_provider_proto protoreflect.FileDescriptor
+
+const file_build_stack_gazelle_scala_provider_provider_proto_rawDesc = "" +
+	"\n" +
+	"1build/stack/gazelle/scala/provider/provider.proto\x12\"build.stack.gazelle.scala.provider\x1a,build/stack/gazelle/scala/parse/import.proto\"\x9a\x02\n" +
+	"\aRequest\x12W\n" +
+	"\n" +
+	"initialize\x18\x01 \x01(\v25.build.stack.gazelle.scala.provider.InitializeRequestH\x00R\n" +
+	"initialize\x12X\n" +
+	"\vcan_provide\x18\x02 \x01(\v25.build.stack.gazelle.scala.provider.CanProvideRequestH\x00R\n" +
+	"canProvide\x12Q\n" +
+	"\bshutdown\x18\x03 \x01(\v23.build.stack.gazelle.scala.provider.ShutdownRequestH\x00R\bshutdownB\t\n" +
+	"\arequest\"\xfb\x02\n" +
+	"\bResponse\x12D\n" +
+	"\x06symbol\x18\x01 \x01(\v2*.build.stack.gazelle.scala.provider.SymbolH\x00R\x06symbol\x12X\n" +
+	"\n" +
+	"initialize\x18\x02 \x01(\v26.build.stack.gazelle.scala.provider.InitializeResponseH\x00R\n" +
+	"initialize\x12Y\n" +
+	"\vcan_provide\x18\x03 \x01(\v26.build.stack.gazelle.scala.provider.CanProvideResponseH\x00R\n" +
+	"canProvide\x12R\n" +
+	"\bshutdown\x18\x04 \x01(\v24.build.stack.gazelle.scala.provider.ShutdownResponseH\x00R\bshutdown\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05errorB\n" +
+	"\n" +
+	"\bresponse\"D\n" +
+	"\x11InitializeRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\trepo_root\x18\x02 \x01(\tR\brepoRoot\"s\n" +
+	"\x06Symbol\x12?\n" +
+	"\x04type\x18\x01 \x01(\x0e2+.build.stack.gazelle.scala.parse.ImportTypeR\x04type\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05label\x18\x03 \x01(\tR\x05label\"\x14\n" +
+	"\x12InitializeResponse\"=\n" +
+	"\x11CanProvideRequest\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\tR\x05label\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\"5\n" +
+	"\x12CanProvideResponse\x12\x1f\n" +
+	"\vcan_provide\x18\x01 \x01(\bR\n" +
+	"canProvide\"\x11\n" +
+	"\x0fShutdownRequest\"\x12\n" +
+	"\x10ShutdownResponseBs\n" +
+	"\"build.stack.gazelle.scala.providerP\x01ZKgithub.com/stackb/scala-gazelle/build/stack/gazelle/scala/provider;providerb\x06proto3"
+
+var (
+	file_build_stack_gazelle_scala_provider_provider_proto_rawDescOnce sync.Once
+	file_build_stack_gazelle_scala_provider_provider_proto_rawDescData []byte
+)
+
+func file_build_stack_gazelle_scala_provider_provider_proto_rawDescGZIP() []byte {
+	file_build_stack_gazelle_scala_provider_provider_proto_rawDescOnce.Do(func() {
+		file_build_stack_gazelle_scala_provider_provider_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_build_stack_gazelle_scala_provider_provider_proto_rawDesc), len(file_build_stack_gazelle_scala_provider_provider_proto_rawDesc)))
+	})
+	return file_build_stack_gazelle_scala_provider_provider_proto_rawDescData
+}
+
+var file_build_stack_gazelle_scala_provider_provider_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_build_stack_gazelle_scala_provider_provider_proto_goTypes = []any{
+	(*Request)(nil),            // 0: build.stack.gazelle.scala.provider.Request
+	(*Response)(nil),           // 1: build.stack.gazelle.scala.provider.Response
+	(*InitializeRequest)(nil),  // 2: build.stack.gazelle.scala.provider.InitializeRequest
+	(*Symbol)(nil),             // 3: build.stack.gazelle.scala.provider.Symbol
+	(*InitializeResponse)(nil), // 4: build.stack.gazelle.scala.provider.InitializeResponse
+	(*CanProvideRequest)(nil),  // 5: build.stack.gazelle.scala.provider.CanProvideRequest
+	(*CanProvideResponse)(nil), // 6: build.stack.gazelle.scala.provider.CanProvideResponse
+	(*ShutdownRequest)(nil),    // 7: build.stack.gazelle.scala.provider.ShutdownRequest
+	(*ShutdownResponse)(nil),   // 8: build.stack.gazelle.scala.provider.ShutdownResponse
+	(parse.ImportType)(0),      // 9: build.stack.gazelle.scala.parse.ImportType
+}
+var file_build_stack_gazelle_scala_provider_provider_proto_depIdxs = []int32{
+	2, // 0: build.stack.gazelle.scala.provider.Request.initialize:type_name -> build.stack.gazelle.scala.provider.InitializeRequest
+	5, // 1: build.stack.gazelle.scala.provider.Request.can_provide:type_name -> build.stack.gazelle.scala.provider.CanProvideRequest
+	7, // 2: build.stack.gazelle.scala.provider.Request.shutdown:type_name -> build.stack.gazelle.scala.provider.ShutdownRequest
+	3, // 3: build.stack.gazelle.scala.provider.Response.symbol:type_name -> build.stack.gazelle.scala.provider.Symbol
+	4, // 4: build.stack.gazelle.scala.provider.Response.initialize:type_name -> build.stack.gazelle.scala.provider.InitializeResponse
+	6, // 5: build.stack.gazelle.scala.provider.Response.can_provide:type_name -> build.stack.gazelle.scala.provider.CanProvideResponse
+	8, // 6: build.stack.gazelle.scala.provider.Response.shutdown:type_name -> build.stack.gazelle.scala.provider.ShutdownResponse
+	9, // 7: build.stack.gazelle.scala.provider.Symbol.type:type_name -> build.stack.gazelle.scala.parse.ImportType
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_build_stack_gazelle_scala_provider_provider_proto_init() }
+func file_build_stack_gazelle_scala_provider_provider_proto_init() {
+	if File_build_stack_gazelle_scala_provider_provider_proto != nil {
+		return
+	}
+	file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[0].OneofWrappers = []any{
+		(*Request_Initialize)(nil),
+		(*Request_CanProvide)(nil),
+		(*Request_Shutdown)(nil),
+	}
+	file_build_stack_gazelle_scala_provider_provider_proto_msgTypes[1].OneofWrappers = []any{
+		(*Response_Symbol)(nil),
+		(*Response_Initialize)(nil),
+		(*Response_CanProvide)(nil),
+		(*Response_Shutdown)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_build_stack_gazelle_scala_provider_provider_proto_rawDesc), len(file_build_stack_gazelle_scala_provider_provider_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_build_stack_gazelle_scala_provider_provider_proto_goTypes,
+		DependencyIndexes: file_build_stack_gazelle_scala_provider_provider_proto_depIdxs,
+		MessageInfos:      file_build_stack_gazelle_scala_provider_provider_proto_msgTypes,
+	}.Build()
+	File_build_stack_gazelle_scala_provider_provider_proto = out.File
+	file_build_stack_gazelle_scala_provider_provider_proto_goTypes = nil
+	file_build_stack_gazelle_scala_provider_provider_proto_depIdxs = nil
+}
@@ -0,0 +1,232 @@
+// cachetool is a utility for scala-gazelle cache files (see
+// -scala_gazelle_cache_file).  The format (.json, .pb or .pbtext) of each file
+// is determined by its extension.
+//
+//	cachetool inspect [-rules] FILE
+//
+// prints a summary of the cache,
+//
+//	cachetool validate FILE
+//
+// checks the internal consistency of the cache,
+//
+//	cachetool prune [-root DIR] [-output_file OUT] FILE
+//
+// removes entries for files that no longer exist, and
+//
+//	cachetool convert IN OUT
+//
+// converts the cache IN to OUT (e.g. .pb to .json).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stackb/scala-gazelle/pkg/bazel"
+	"github.com/stackb/scala-gazelle/pkg/collections"
+	"github.com/stackb/scala-gazelle/pkg/protobuf"
+	"github.com/stackb/scala-gazelle/pkg/scalacache"
+
+	scpb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/cache"
+)
+
+const usage = `usage: cachetool inspect [-rules] FILE
+       cachetool validate FILE
+       cachetool prune [-root DIR] [-output_file OUT] FILE
+       cachetool convert IN OUT`
+
+func main() {
+	log.SetPrefix("cachetool: ")
+	log.SetOutput(os.Stderr)
+	log.SetFlags(0) // don't print timestamps
+
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string) error {
+	args, err := collections.ReadArgsParamsFile(args)
+	if err != nil {
+		return fmt.Errorf("failed to read params file: %v", err)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+
+	switch args[0] {
+	case "inspect":
+		return inspect(args[1:])
+	case "validate":
+		return validate(args[1:])
+	case "prune":
+		return prune(args[1:])
+	case "convert":
+		return convert(args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+// inspect implements the 'inspect' command.
+func inspect(args []string) error {
+	var listRules bool
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	fs.BoolVar(&listRules, "rules", false, "also list the rules and their number of files")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cachetool inspect [flags] FILE")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	filename, err := oneFile(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	var cache scpb.Cache
+	if err := protobuf.ReadFile(filename, &cache); err != nil {
+		return err
+	}
+
+	var ruleFiles int
+	for _, rule := range cache.Rules {
+		ruleFiles += len(rule.Files)
+	}
+
+	fmt.Printf("file:           %s\n", filename)
+	fmt.Printf("key:            %q\n", cache.Key)
+	fmt.Printf("schema version: %d (current %d)\n", cache.SchemaVersion, scalacache.SchemaVersion)
+	fmt.Printf("parser version: %q\n", cache.ParserVersion)
+	fmt.Printf("package count:  %d\n", cache.PackageCount)
+	fmt.Printf("rules:          %d (%d files)\n", len(cache.Rules), ruleFiles)
+	fmt.Printf("files:          %d\n", len(cache.Files))
+	for _, version := range scalacache.ParserVersions(&cache) {
+		fmt.Printf("  %s\n", version)
+	}
+	if listRules {
+		for _, rule := range cache.Rules {
+			fmt.Printf("%s %s (%d files)\n", rule.Kind, rule.Label, len(rule.Files))
+		}
+	}
+	return nil
+}
+
+// validate implements the 'validate' command.
+func validate(args []string) error {
+	filename, err := oneFile(args)
+	if err != nil {
+		return err
+	}
+
+	var cache scpb.Cache
+	if err := protobuf.ReadFile(filename, &cache); err != nil {
+		return err
+	}
+
+	errs := scalacache.Validate(&cache)
+	for _, err := range errs {
+		log.Printf("%s: %v", filename, err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d problem(s) found", len(errs))
+	}
+	fmt.Printf("%s: ok\n", filename)
+	return nil
+}
+
+// prune implements the 'prune' command.
+func prune(args []string) error {
+	var (
+		root       string
+		outputFile string
+	)
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	fs.StringVar(&root, "root", bazel.GetBuildWorkspaceDirectory(), "the workspace root directory that cached filenames are relative to")
+	fs.StringVar(&outputFile, "output_file", "", "optional file to write the pruned cache to (default: overwrite the input file)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cachetool prune [flags] FILE")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	filename, err := oneFile(fs.Args())
+	if err != nil {
+		return err
+	}
+	if outputFile == "" {
+		outputFile = filename
+	} else {
+		outputFile = workingDirFile(outputFile)
+	}
+
+	var cache scpb.Cache
+	if err := protobuf.ReadFile(filename, &cache); err != nil {
+		return err
+	}
+
+	rules, files := scalacache.Prune(&cache, root)
+
+	if err := protobuf.WriteFile(outputFile, &cache); err != nil {
+		return err
+	}
+	fmt.Printf("%s: pruned %d rule(s) and %d file(s); %d rule(s) and %d file(s) remain\n",
+		outputFile, rules, files, len(cache.Rules), len(cache.Files))
+	return nil
+}
+
+// convert implements the 'convert' command.
+func convert(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: cachetool convert IN OUT")
+	}
+	in := workingDirFile(args[0])
+	out := workingDirFile(args[1])
+	if in == out {
+		return fmt.Errorf("input and output files must be different")
+	}
+	for _, filename := range []string{in, out} {
+		switch ext := filepath.Ext(filename); ext {
+		case ".json", ".pb", ".pbtext":
+		default:
+			return fmt.Errorf("%s: unknown extension %q (want .json, .pb or .pbtext)", filename, ext)
+		}
+	}
+
+	var cache scpb.Cache
+	if err := protobuf.ReadFile(in, &cache); err != nil {
+		return err
+	}
+	if err := protobuf.WriteFile(out, &cache); err != nil {
+		return err
+	}
+	fmt.Printf("%s -> %s\n", in, out)
+	return nil
+}
+
+// oneFile returns the single filename of the given args.
+func oneFile(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected a single cache file, got [%s]", strings.Join(args, " "))
+	}
+	return workingDirFile(args[0]), nil
+}
+
+// workingDirFile resolves a relative filename against the directory the tool
+// was run from, which is not the current directory under 'bazel run'.
+func workingDirFile(filename string) string {
+	if filepath.IsAbs(filename) {
+		return filename
+	}
+	if bwd, ok := os.LookupEnv("BUILD_WORKING_DIRECTORY"); ok {
+		return filepath.Join(bwd, filename)
+	}
+	return filename
+}
@@ -0,0 +1,287 @@
+// scalaparse exposes the scala parser of the gazelle extension as a
+// standalone tool.  It has two modes:
+//
+//	scalaparse parse [flags] FILE...
+//
+// parses the given files and prints a build.stack.gazelle.scala.parse.FileSet
+// as JSON or proto, and
+//
+//	scalaparse serve [flags]
+//
+// runs a long-lived grpc server implementing the
+// build.stack.gazelle.scala.parse.Parser service.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/stackb/scala-gazelle/pkg/collections"
+	"github.com/stackb/scala-gazelle/pkg/parser"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+const usage = `usage: scalaparse parse [flags] FILE...
+       scalaparse serve [flags]`
+
+func main() {
+	log.SetPrefix("scalaparse: ")
+	log.SetOutput(os.Stderr)
+	log.SetFlags(0) // don't print timestamps
+
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string) error {
+	args, err := collections.ReadArgsParamsFile(args)
+	if err != nil {
+		return fmt.Errorf("failed to read params file: %v", err)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+
+	switch args[0] {
+	case "parse":
+		return parse(args[1:])
+	case "serve":
+		return serve(args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+// parse implements the 'parse' command.
+func parse(args []string) error {
+	var (
+		format        string
+		outputFile    string
+		dialect       string
+		wantParseTree bool
+		wantPositions bool
+		timeout       time.Duration
+	)
+	fs := flag.NewFlagSet("parse", flag.ExitOnError)
+	fs.StringVar(&format, "format", "json", "output format (json|proto)")
+	fs.StringVar(&outputFile, "output_file", "", "optional file to write the FileSet to (default stdout)")
+	fs.StringVar(&dialect, "dialect", "", "scalameta dialect (e.g. Scala213 or Scala3); detected if empty")
+	fs.BoolVar(&wantParseTree, "want_parse_tree", false, "include the raw parse tree of each file")
+	fs.BoolVar(&wantPositions, "want_positions", false, "include source positions of imports, definitions and names")
+	fs.DurationVar(&timeout, "timeout", 60*time.Second, "parse timeout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: scalaparse parse [flags] FILE...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(fs.Args()) == 0 {
+		return fmt.Errorf("source files list must not be empty")
+	}
+
+	var marshal func(proto.Message) ([]byte, error)
+	switch format {
+	case "json":
+		marshal = func(m proto.Message) ([]byte, error) {
+			data, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(m)
+			return append(data, '\n'), err
+		}
+	case "proto":
+		marshal = proto.Marshal
+	default:
+		return fmt.Errorf("unknown format %q (want json or proto)", format)
+	}
+
+	p := parser.NewScalametaParser(parser.WithHttpClientTimeout(timeout))
+	if err := p.Start(); err != nil {
+		return fmt.Errorf("starting parser: %w", err)
+	}
+	defer p.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	response, err := parseFiles(ctx, p, &sppb.ParseRequest{
+		Filenames:     fs.Args(),
+		Dialect:       dialect,
+		WantParseTree: wantParseTree,
+		WantPositions: wantPositions,
+	})
+	if err != nil {
+		return err
+	}
+	if response.Error != "" {
+		return fmt.Errorf("parse error: %s", response.Error)
+	}
+
+	data, err := marshal(&sppb.FileSet{Files: response.Files})
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if outputFile != "" {
+		err = os.WriteFile(outputFile, data, 0644)
+	} else {
+		_, err = os.Stdout.Write(data)
+	}
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	var failed int
+	for _, file := range response.Files {
+		if file.Error != "" {
+			log.Printf("%s: %s", file.Filename, file.Error)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) failed to parse", failed)
+	}
+	return nil
+}
+
+// serve implements the 'serve' command.
+func serve(args []string) error {
+	var (
+		listen    string
+		poolSize  int
+		transport string
+		encoding  string
+	)
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.StringVar(&listen, "listen", "localhost:8040", "host:port to listen on")
+	fs.IntVar(&poolSize, "pool_size", 1, "number of parser processes")
+	fs.StringVar(&transport, "transport", parser.TransportHTTP, "transport used to communicate with the parser processes (http|grpc)")
+	fs.StringVar(&encoding, "encoding", parser.JSONCodecName, "message encoding of the grpc service (json|proto)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: scalaparse serve [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var serverOptions []grpc.ServerOption
+	switch encoding {
+	case parser.JSONCodecName:
+		serverOptions = append(serverOptions, grpc.ForceServerCodec(parser.NewJSONCodec()))
+	case "proto":
+	default:
+		return fmt.Errorf("unknown encoding %q (want %q or %q)", encoding, parser.JSONCodecName, "proto")
+	}
+
+	logger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).Level(zerolog.InfoLevel).With().Timestamp().Logger()
+	pool := parser.NewParserPool(func(id int) parser.PoolWorker {
+		return parser.NewScalametaParser(
+			parser.WithLogger(logger.With().Int("worker", id).Logger()),
+			parser.WithTransport(transport),
+		)
+	}, parser.WithPoolSize(poolSize), parser.WithPoolLogger(logger))
+	if err := pool.Start(); err != nil {
+		return fmt.Errorf("starting parser: %w", err)
+	}
+	defer pool.Stop()
+
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	server := grpc.NewServer(serverOptions...)
+	sppb.RegisterParserServer(server, &parserServer{parser: pool})
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Println("shutting down...")
+		server.GracefulStop()
+	}()
+
+	log.Printf("serving Parser on %s (%d parser(s))", lis.Addr(), pool.Size())
+	if err := server.Serve(lis); err != nil {
+		return fmt.Errorf("serve: %w", err)
+	}
+	for _, stats := range pool.Stats() {
+		log.Printf("parser %s", stats)
+	}
+	return nil
+}
+
+// parserServer implements the Parser service.
+type parserServer struct {
+	sppb.UnimplementedParserServer
+	parser *parser.ParserPool
+}
+
+// Parse implements the Parser service.
+func (s *parserServer) Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	return parseFiles(ctx, s.parser, in)
+}
+
+// fileParser is implemented by ScalametaParser and ParserPool.
+type fileParser interface {
+	Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error)
+}
+
+// parseFiles parses the files in the given request.  The parser process runs
+// in a temporary directory, so relative filenames are made absolute (against
+// the working directory, see workingDir) and reset to their original form in
+// the response.
+func parseFiles(ctx context.Context, p fileParser, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	cwd, err := workingDir()
+	if err != nil {
+		return nil, err
+	}
+
+	request := proto.Clone(in).(*sppb.ParseRequest)
+	filenames := make(map[string]string)
+	for i, filename := range in.Filenames {
+		abs := filename
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(cwd, filename)
+		}
+		request.Filenames[i] = abs
+		filenames[abs] = filename
+	}
+
+	response, err := p.Parse(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range response.Files {
+		if filename, ok := filenames[file.Filename]; ok {
+			file.Filename = filename
+		}
+	}
+	return response, nil
+}
+
+// workingDir returns the directory the tool was run from, which is not the
+// current directory under 'bazel run'.
+func workingDir() (string, error) {
+	if bwd, ok := os.LookupEnv("BUILD_WORKING_DIRECTORY"); ok {
+		return bwd, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting os cwd: %w", err)
+	}
+	return cwd, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+// recordingParser records the filenames it is asked to parse and reports each
+// file as defining the class 'a.<Basename>'.
+type recordingParser struct {
+	filenames []string
+}
+
+func (p *recordingParser) Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	response := &sppb.ParseResponse{}
+	for _, filename := range in.Filenames {
+		p.filenames = append(p.filenames, filename)
+		response.Files = append(response.Files, &sppb.File{
+			Filename: filename,
+			Classes:  []string{"a." + filepath.Base(filename)},
+		})
+	}
+	return response, nil
+}
+
+func TestParseFiles(t *testing.T) {
+	for name, tc := range map[string]struct {
+		bwd        string
+		filenames  []string
+		wantParsed []string
+		want       []string
+	}{
+		"relative filenames are resolved against BUILD_WORKING_DIRECTORY": {
+			bwd:        "/home/user/src",
+			filenames:  []string{"a/A.scala", "B.scala"},
+			wantParsed: []string{"/home/user/src/a/A.scala", "/home/user/src/B.scala"},
+			want:       []string{"a/A.scala", "B.scala"},
+		},
+		"absolute filenames are unchanged": {
+			bwd:        "/home/user/src",
+			filenames:  []string{"/tmp/A.scala"},
+			wantParsed: []string{"/tmp/A.scala"},
+			want:       []string{"/tmp/A.scala"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("BUILD_WORKING_DIRECTORY", tc.bwd)
+
+			p := &recordingParser{}
+			response, err := parseFiles(context.Background(), p, &sppb.ParseRequest{
+				Filenames: tc.filenames,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.wantParsed, p.filenames); diff != "" {
+				t.Errorf("parsed filenames (-want +got):\n%s", diff)
+			}
+			var got []string
+			for _, file := range response.Files {
+				got = append(got, file.Filename)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("response filenames (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -129,5 +129,6 @@ func newFile(doc *spb.TextDocument) *sppb.File {
 	return &sppb.File{
 		Filename:        doc.Uri,
 		SemanticImports: semanticdb.SemanticImports(doc),
+		SemanticExports: semanticdb.SemanticExports(doc),
 	}
 }
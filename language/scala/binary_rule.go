@@ -0,0 +1,124 @@
+package scala
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/rule"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+	"github.com/stackb/scala-gazelle/pkg/scalarule"
+)
+
+// entrypointBaseClasses is the list of types that, when extended by a
+// top-level object, make that object an application entrypoint.
+var entrypointBaseClasses = []string{
+	"App",
+	"IOApp",
+	"IOApp.Simple",
+	"ZIOApp",
+	"ZIOAppDefault",
+}
+
+// generateBinaryRules creates a binary rule for each application entrypoint
+// found in the library rules of the package.  The rule has 'main_class' set;
+// its 'deps' are populated during resolution of the main class.  Entrypoints
+// that are already named in the 'main_class' of an existing rule are skipped,
+// as those rules are resolved (and updated) as usual.
+func (s *scalaPackage) generateBinaryRules(configuredRules []*scalarule.Config, existing []scalarule.RuleProvider) []scalarule.RuleProvider {
+	_, _, binaryConfig := generatedRuleConfigs(configuredRules)
+	if binaryConfig == nil {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	mainClasses := make(map[string]bool)
+	if s.args.File != nil {
+		for _, r := range s.args.File.Rules {
+			names[r.Name()] = true
+			if mainClass := r.AttrString("main_class"); mainClass != "" {
+				mainClasses[mainClass] = true
+			}
+		}
+	}
+
+	rules := make([]scalarule.RuleProvider, 0)
+	for _, provider := range existing {
+		if r := provider.Rule(); r != nil {
+			names[r.Name()] = true
+			if mainClass := r.AttrString("main_class"); mainClass != "" {
+				mainClasses[mainClass] = true
+			}
+		}
+	}
+
+	for _, provider := range existing {
+		lib, ok := provider.(*existingScalaRule)
+		if !ok || !lib.isLibrary {
+			continue
+		}
+		for _, file := range lib.scalaRule.Files() {
+			for _, mainClass := range entrypoints(file) {
+				if mainClasses[mainClass] {
+					continue
+				}
+				mainClasses[mainClass] = true
+
+				name := importBasename(mainClass)
+				if names[name] {
+					log.Printf("%s: not generating binary rule for %s: name %q is already taken", s.cfg.Rel(), mainClass, name)
+					continue
+				}
+				names[name] = true
+
+				r := rule.NewRule(binaryConfig.Provider.Name(), name)
+				r.SetAttr("main_class", mainClass)
+				if provided := s.resolveGeneratedRule(binaryConfig, r); provided != nil {
+					rules = append(rules, provided)
+				}
+			}
+		}
+	}
+
+	return rules
+}
+
+// entrypoints returns the sorted list of top-level objects in the file that
+// are application entrypoints.
+func entrypoints(file *sppb.File) []string {
+	seen := make(map[string]bool)
+	for _, name := range file.MainObjects {
+		seen[name] = true
+	}
+	for _, token := range extendsKeysSorted(file.Extends) {
+		parts := strings.SplitN(token, " ", 2)
+		if len(parts) != 2 || parts[0] != "object" {
+			continue
+		}
+		for _, base := range file.Extends[token].Classes {
+			if isEntrypointBaseClass(base) {
+				seen[parts[1]] = true
+				break
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isEntrypointBaseClass tests whether the given (possibly qualified) type name
+// is one of the entrypointBaseClasses.
+func isEntrypointBaseClass(name string) bool {
+	for _, base := range entrypointBaseClasses {
+		if name == base || strings.HasSuffix(name, "."+base) {
+			return true
+		}
+	}
+	return false
+}
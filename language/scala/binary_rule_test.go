@@ -0,0 +1,65 @@
+package scala
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+func TestEntrypoints(t *testing.T) {
+	for name, tc := range map[string]struct {
+		file *sppb.File
+		want []string
+	}{
+		"degenerate": {
+			file: &sppb.File{},
+			want: []string{},
+		},
+		"main method": {
+			file: &sppb.File{
+				Objects:     []string{"com.foo.Main", "com.foo.Util"},
+				MainObjects: []string{"com.foo.Main"},
+			},
+			want: []string{"com.foo.Main"},
+		},
+		"extends entrypoint base classes": {
+			file: &sppb.File{
+				Objects: []string{"com.foo.A", "com.foo.B", "com.foo.C", "com.foo.D"},
+				Extends: map[string]*sppb.ClassList{
+					"object com.foo.A": {Classes: []string{"App"}},
+					"object com.foo.B": {Classes: []string{"cats.effect.IOApp.Simple"}},
+					"object com.foo.C": {Classes: []string{"zio.ZIOAppDefault"}},
+					"object com.foo.D": {Classes: []string{"com.foo.MyApp"}},
+				},
+			},
+			want: []string{"com.foo.A", "com.foo.B", "com.foo.C"},
+		},
+		"classes are not entrypoints": {
+			file: &sppb.File{
+				Classes: []string{"com.foo.A"},
+				Extends: map[string]*sppb.ClassList{
+					"class com.foo.A": {Classes: []string{"App"}},
+				},
+			},
+			want: []string{},
+		},
+		"deduplicated": {
+			file: &sppb.File{
+				MainObjects: []string{"com.foo.A"},
+				Extends: map[string]*sppb.ClassList{
+					"object com.foo.A": {Classes: []string{"scala.App"}},
+				},
+			},
+			want: []string{"com.foo.A"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := entrypoints(tc.file)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -2,12 +2,16 @@ package scala
 
 import (
 	"log"
+	"os"
 	"time"
 
 	"github.com/bazelbuild/bazel-gazelle/label"
 	scpb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/cache"
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
 	"github.com/stackb/scala-gazelle/pkg/parser"
 	"github.com/stackb/scala-gazelle/pkg/protobuf"
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+	"github.com/stackb/scala-gazelle/pkg/scalacache"
 )
 
 const debugCache = false
@@ -27,8 +31,23 @@ func (sl *scalaLang) readScalaRuleCacheFile() error {
 		return nil
 	}
 
+	for _, msg := range scalacache.Migrate(&sl.cache, sl.sourceProvider.ParserVersion()) {
+		log.Printf("scala-gazelle cache %s: %s", sl.cacheFileFlagValue, msg)
+	}
+
 	parser.SortRules(sl.cache.Rules)
 
+	// rules of caches written before visit times were recorded are assumed to
+	// have been visited when the cache file was written.
+	var modTime int64
+	if info, err := os.Stat(sl.cacheFileFlagValue); err == nil {
+		modTime = info.ModTime().Unix()
+		// the stats of files modified since the cache was written are not
+		// trusted.
+		sl.parser.SetCacheWriteTime(info.ModTime())
+	}
+	visitTimes := make(map[string]int64, len(sl.cache.Rules))
+
 	for _, rule := range sl.cache.Rules {
 		from, err := label.Parse(rule.Label)
 		if err != nil {
@@ -37,11 +56,26 @@ func (sl *scalaLang) readScalaRuleCacheFile() error {
 		if err := sl.parser.LoadScalaRule(from, rule); err != nil {
 			return err
 		}
+		// until the rule is visited, its symbols are provided from the cache.
+		if err := sl.sourceProvider.LoadCachedScalaRule(from, rule); err != nil {
+			return err
+		}
+		// visit times are keyed by the canonical form of the label.
+		if visitTime, ok := sl.cache.RuleVisitTimes[rule.Label]; ok {
+			visitTimes[from.String()] = visitTime
+		} else {
+			visitTimes[from.String()] = modTime
+		}
+	}
+	sl.cache.RuleVisitTimes = visitTimes
+
+	for _, file := range sl.cache.Files {
+		sl.parser.LoadParsedFile(file)
 	}
 
 	if debugCache {
 		t2 := time.Since(t1).Round(1 * time.Millisecond)
-		log.Printf("Read cache %s (%d rules) %v", sl.cacheFileFlagValue, len(sl.cache.Rules), t2)
+		log.Printf("Read cache %s (%d rules, %d files) %v", sl.cacheFileFlagValue, len(sl.cache.Rules), len(sl.cache.Files), t2)
 	}
 
 	return nil
@@ -50,11 +84,68 @@ func (sl *scalaLang) readScalaRuleCacheFile() error {
 func (sl *scalaLang) writeScalaRuleCacheFile() error {
 	sl.cache.PackageCount = int32(len(sl.packages))
 	sl.cache.Rules = sl.parser.ScalaRules()
+	sl.cache.Files = sl.parser.ParsedFiles(sl.repoRoot)
 	sl.cache.Key = sl.cacheKeyFlagValue
+	sl.cache.SchemaVersion = scalacache.SchemaVersion
+	sl.cache.ParserVersion = sl.sourceProvider.ParserVersion()
+	sl.cache.RuleVisitTimes = sl.ruleVisitTimes(sl.cache.Rules, time.Now())
 
 	if debugCache {
-		log.Printf("Wrote scala-gazelle cache %s (%d rules)", sl.cacheFileFlagValue, len(sl.cache.Rules))
+		log.Printf("Wrote scala-gazelle cache %s (%d rules, %d files)", sl.cacheFileFlagValue, len(sl.cache.Rules), len(sl.cache.Files))
 	}
 
 	return protobuf.WriteFile(sl.cacheFileFlagValue, &sl.cache)
 }
+
+// ruleVisitTimes returns the visit times of the given rules.  Rules visited
+// during this run are stamped with the given time, others retain their
+// previous visit time.
+func (sl *scalaLang) ruleVisitTimes(rules []*sppb.Rule, now time.Time) map[string]int64 {
+	visitTimes := make(map[string]int64, len(rules))
+	for _, rule := range rules {
+		from, err := label.Parse(rule.Label)
+		if err != nil {
+			continue
+		}
+		key := from.String()
+		if sl.parser.Visited(from) {
+			visitTimes[key] = now.Unix()
+		} else if visitTime, ok := sl.cache.RuleVisitTimes[key]; ok {
+			visitTimes[key] = visitTime
+		}
+	}
+	return visitTimes
+}
+
+// pruneCachedRules forgets the cached rules in packages that were visited
+// during this run.  The rules that were visited provided their symbols again;
+// those that were not (e.g. the rule was removed) are stale.  The remaining
+// cached rules, in packages that were not visited, continue to provide their
+// symbols such that a run on a subset of packages resolves like a full run.
+func (sl *scalaLang) pruneCachedRules() {
+	visited := func(from label.Label) bool {
+		return sl.visitedPackages[from.Pkg]
+	}
+
+	for _, rule := range sl.parser.ScalaRules() {
+		from, err := label.Parse(rule.Label)
+		if err != nil {
+			continue
+		}
+		if visited(from) && !sl.parser.Visited(from) {
+			sl.parser.RemoveScalaRule(from)
+		}
+	}
+
+	sl.sourceProvider.PruneCachedRules(visited)
+
+	// the global scope is replaced by a scala scope after this.
+	if scope, ok := sl.globalScope.(*resolver.TrieScope); ok {
+		removed := scope.Prune(func(sym *resolver.Symbol) bool {
+			return sym.Cached && visited(sym.Label)
+		})
+		if debugCache {
+			log.Printf("pruned %d cached symbol(s) of visited packages", removed)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package scala
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/bazelbuild/bazel-gazelle/testtools"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+	"github.com/stackb/scala-gazelle/pkg/testutil"
+)
+
+func TestPruneCachedRules(t *testing.T) {
+	now := time.Now()
+	lastWeek := now.Add(-8*24*time.Hour - time.Minute).Unix()
+
+	tmpDir, _, cleanup := testutil.MustPrepareTestFiles(t, []testtools.FileSpec{
+		{
+			Path: "cache.json",
+			Content: `{
+  "schema_version": 1,
+  "parser_version": "lexer-1",
+  "rules": [
+    {"label": "//a:a", "kind": "scala_library", "sha256": "1", "files": [{"filename": "a/A.scala", "classes": ["a.A", "shared.S"]}]},
+    {"label": "//b:b", "kind": "scala_library", "sha256": "2", "files": [{"filename": "b/B.scala", "classes": ["b.B", "shared.S"]}]},
+    {"label": "//c:c", "kind": "scala_library", "sha256": "3", "files": [{"filename": "c/C.scala", "classes": ["c.C"]}]}
+  ],
+  "rule_visit_times": {"//a:a": "` + fmt.Sprint(lastWeek) + `", "//b:b": "` + fmt.Sprint(lastWeek) + `"}
+}`,
+		},
+	})
+	defer cleanup()
+
+	os.Setenv("TEST_TMPDIR", tmpDir)
+	lang := NewLanguage().(*scalaLang)
+	fs := flag.NewFlagSet(scalaLangName, flag.ExitOnError)
+	c := &config.Config{
+		WorkDir: tmpDir,
+		Exts:    make(map[string]interface{}),
+	}
+	lang.RegisterFlags(fs, "", c)
+	if err := fs.Parse([]string{
+		"-scala_symbol_provider=source",
+		"-scala_parser_backend=lexer",
+		"-scala_gazelle_cache_file=${TEST_TMPDIR}/cache.json",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := lang.CheckFlags(fs, c); err != nil {
+		t.Fatal(err)
+	}
+
+	// the symbols of all cached rules are known during the generate phase
+	for _, name := range []string{"a.A", "b.B", "c.C"} {
+		sym, ok := lang.GetSymbol(name)
+		if !ok {
+			t.Fatalf("expected cached symbol %s", name)
+		}
+		if !sym.Cached {
+			t.Errorf("expected symbol %s to be marked as cached", name)
+		}
+	}
+	if sym, _ := lang.GetSymbol("shared.S"); len(sym.Conflicts) != 1 {
+		t.Errorf("expected shared.S to be conflicted, got %v", sym.Conflicts)
+	}
+
+	// package 'a' was visited, but the rule was removed
+	lang.visitedPackages["a"] = true
+	lang.pruneCachedRules()
+
+	if _, ok := lang.GetSymbol("a.A"); ok {
+		t.Errorf("expected symbol a.A of visited package to be pruned")
+	}
+	sym, ok := lang.GetSymbol("shared.S")
+	if !ok {
+		t.Fatal("expected symbol shared.S")
+	}
+	if diff := cmp.Diff(label.Label{Pkg: "b", Name: "b"}, sym.Label); diff != "" {
+		t.Errorf("shared.S label (-want +got):\n%s", diff)
+	}
+	if len(sym.Conflicts) != 0 {
+		t.Errorf("expected shared.S to be unconflicted, got %v", sym.Conflicts)
+	}
+
+	var rules []string
+	for _, rule := range lang.parser.ScalaRules() {
+		rules = append(rules, rule.Label)
+	}
+	if diff := cmp.Diff([]string{"//b:b", "//c:c"}, rules); diff != "" {
+		t.Errorf("rules (-want +got):\n%s", diff)
+	}
+
+	noRuleIndex := func(from label.Label) (*rule.Rule, bool) {
+		return nil, false
+	}
+	if !lang.sourceProvider.CanProvide(&resolver.ImportLabel{Label: label.Label{Pkg: "b", Name: "b"}}, nil, noRuleIndex, label.NoLabel) {
+		t.Errorf("expected cached rule //b:b to be provided")
+	}
+	if lang.sourceProvider.CanProvide(&resolver.ImportLabel{Label: label.Label{Pkg: "a", Name: "a"}}, nil, noRuleIndex, label.NoLabel) {
+		t.Errorf("expected pruned rule //a:a not to be provided")
+	}
+
+	if diff := cmp.Diff(map[string]int64{"//b": lastWeek, "//c": lang.cache.RuleVisitTimes["//c"]}, lang.ruleVisitTimes(lang.parser.ScalaRules(), now)); diff != "" {
+		t.Errorf("visit times (-want +got):\n%s", diff)
+	}
+
+	// //c:c has no visit time: it is assumed to be as old as the cache file,
+	// which was just written.
+	if diff := cmp.Diff([]string{"//b (8d)"}, lang.staleCachedRules(now)); diff != "" {
+		t.Errorf("stale (-want +got):\n%s", diff)
+	}
+}
@@ -11,6 +11,9 @@ import (
 // Configure implements part of the language.Language interface
 func (sl *scalaLang) Configure(c *config.Config, rel string, f *rule.File) {
 	sc := scalaconfig.GetOrCreate(sl.logger, sl, c, rel)
+	if rel == "" {
+		sc.SetParseErrorPolicy(sl.parseErrorPolicy)
+	}
 	if f != nil {
 		if err := sc.ParseDirectives(f.Directives); err != nil {
 			log.Fatalf("parsing directives in package %q: %v", rel, err)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/stackb/scala-gazelle/pkg/procutil"
 )
@@ -50,5 +51,60 @@ func (sl *scalaLang) reportCoverage(printf func(format string, v ...any)) {
 
 	if procutil.LookupBoolEnv(SCALA_GAZELLE_SHOW_COVERAGE, true) {
 		printf("scala-gazelle coverage is %0.1f%% (%d/%d) %s", percent, managed, total, totals)
+		if sl.sourceProvider != nil {
+			for _, stats := range sl.sourceProvider.ParserStats() {
+				printf("scala-gazelle parser %s", stats)
+			}
+			if degraded := sl.sourceProvider.DegradedFiles(); len(degraded) > 0 {
+				printf("scala-gazelle parsed %d file(s) with the degraded lexer parser:", len(degraded))
+				for _, filename := range degraded {
+					printf("  %s", filename)
+				}
+			}
+			if stale := sl.staleCachedRules(time.Now()); len(stale) > 0 {
+				printf("scala-gazelle resolved symbols from %d cached rule(s) in packages that were not visited for more than %s (run gazelle on these packages to refresh the cache):", len(stale), formatAge(sl.cacheStaleFlagValue))
+				for _, line := range stale {
+					printf("  %s", line)
+				}
+			}
+		}
+	}
+}
+
+// staleCachedRules returns a list of the cached rules in packages not visited
+// during this run that were last visited longer ago than the
+// -scala_gazelle_cache_stale_threshold, with their age.
+func (sl *scalaLang) staleCachedRules(now time.Time) []string {
+	if sl.cacheStaleFlagValue <= 0 || sl.sourceProvider == nil {
+		return nil
+	}
+	var stale []string
+	for _, from := range sl.sourceProvider.CachedRules() {
+		visitTime, ok := sl.cache.RuleVisitTimes[from.String()]
+		if !ok {
+			continue
+		}
+		if age := now.Sub(time.Unix(visitTime, 0)); age > sl.cacheStaleFlagValue {
+			stale = append(stale, fmt.Sprintf("%s (%s)", from, formatAge(age)))
+		}
+	}
+	return stale
+}
+
+// formatAge formats the given duration in days and hours, or minutes if less
+// than an hour.
+func formatAge(d time.Duration) string {
+	if d < time.Hour {
+		return d.Round(time.Minute).String()
+	}
+	days := int(d / (24 * time.Hour))
+	hours := int(d%(24*time.Hour)) / int(time.Hour)
+	switch {
+	case days == 0:
+		return fmt.Sprintf("%dh", hours)
+	case hours == 0:
+		return fmt.Sprintf("%dd", days)
+	default:
+		return fmt.Sprintf("%dd%dh", days, hours)
 	}
 }
@@ -1 +1,29 @@
 package scala
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormatAge(t *testing.T) {
+	for name, tc := range map[string]struct {
+		age  time.Duration
+		want string
+	}{
+		"minutes": {age: 5*time.Minute + 10*time.Second, want: "5m0s"},
+		"hours":   {age: 5*time.Hour + 10*time.Minute, want: "5h"},
+		"days":    {age: 48 * time.Hour, want: "2d"},
+		"days and hours": {
+			age:  7*24*time.Hour + 3*time.Hour,
+			want: "7d3h",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, formatAge(tc.age)); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -14,7 +14,9 @@ import (
 
 	"github.com/stackb/scala-gazelle/pkg/bazel"
 	"github.com/stackb/scala-gazelle/pkg/collections"
+	"github.com/stackb/scala-gazelle/pkg/glob"
 	"github.com/stackb/scala-gazelle/pkg/protobuf"
+	"github.com/stackb/scala-gazelle/pkg/resolver"
 	"github.com/stackb/scala-gazelle/pkg/scalaconfig"
 	"github.com/stackb/scala-gazelle/pkg/scalarule"
 
@@ -23,7 +25,7 @@ import (
 
 func init() {
 	mustRegister := func(load, kind string, isBinary, isLibrary, isTest bool) {
-		fqn := load + "%" + kind
+		fqn := existingScalaRuleName(load, kind)
 		if err := scalarule.
 			GlobalProviderRegistry().
 			RegisterProvider(fqn, &existingScalaRuleProvider{load, kind, isBinary, isLibrary, isTest}); err != nil {
@@ -37,10 +39,21 @@ func init() {
 	mustRegister("@io_bazel_rules_scala//scala:scala.bzl", "scala_test", false, false, true)
 }
 
+// existingScalaRuleName returns the name under which the provider of the given
+// load and kind is registered.  Native rules (having no load) are identified by
+// their kind alone, as that is what fullyQualifiedLoadName returns for them.
+func existingScalaRuleName(load, kind string) string {
+	if load == "" {
+		return kind
+	}
+	return load + "%" + kind
+}
+
 // existingScalaRuleProvider implements RuleResolver for scala-like rules that
-// are already in the build file.  It does not create any new rules.  This rule
-// implementation is used to parse files named in 'srcs' and update 'deps' (and
-// optionally, exports).
+// are already in the build file.  It does not create any new rules itself
+// (when build file generation is enabled, the package creates them and passes
+// them to ResolveRule).  This rule implementation is used to parse files named
+// in 'srcs' and update 'deps' (and optionally, exports).
 type existingScalaRuleProvider struct {
 	load, name string
 	isBinary   bool
@@ -131,6 +144,11 @@ func (s *existingScalaRule) Resolve(rctx *scalarule.ResolveContext, importsRaw i
 	}
 
 	sc := scalaconfig.Get(rctx.Config)
+	if sc.ParseErrorPolicy() == scalaconfig.ParseErrorPolicyKeepExisting && len(parseErrorFiles(scalaRule.Files())) > 0 {
+		// the imports are incomplete: leave the rule as-is
+		return
+	}
+
 	imports := scalaRule.ResolveImports(rctx)
 	sc.Imports(imports, rctx.Rule, "deps", rctx.From)
 
@@ -150,6 +168,34 @@ func (s *existingScalaRule) Resolve(rctx *scalarule.ResolveContext, importsRaw i
 		exports := scalaRule.ResolveExports(rctx)
 		sc.Exports(exports, rctx.Rule, "exports", rctx.From)
 	}
+
+	if sc.ShouldManageRuntimeDeps() {
+		runtimeImports := scalaRule.ResolveRuntimeImports(rctx, s.runtimeImports(sc, rctx.Rule, rctx.From))
+		sc.RuntimeDeps(runtimeImports, rctx.Rule, "runtime_deps", rctx.From)
+	}
+}
+
+// runtimeImports collects the (unresolved) runtime imports of the rule from
+// the files named in 'srcs' and 'resources'.
+func (s *existingScalaRule) runtimeImports(sc *scalaconfig.Config, r *rule.Rule, from label.Label) resolver.ImportMap {
+	args := s.pkg.GenerateArgs()
+
+	var filenames []string
+	for _, attrName := range []string{"srcs", "resources"} {
+		srcs, err := glob.CollectFilenames(args.File, args.Dir, r.Attr(attrName))
+		if err != nil {
+			log.Printf("%v: unable to collect %s: %v", from, attrName, err)
+			continue
+		}
+		filenames = append(filenames, srcs...)
+	}
+
+	imports, err := sc.RuntimeImports(r, args.Dir, filenames)
+	if err != nil {
+		log.Printf("%v: unable to collect runtime imports: %v", from, err)
+		return resolver.NewImportMap()
+	}
+	return imports
 }
 
 // sweepTransitiveDeps iterates through deps marked "UNKNOWN" and removes them
@@ -1 +1,92 @@
 package scala
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/merger"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/stackb/scala-gazelle/pkg/scalarule"
+)
+
+func TestExistingJavaLibraryRule(t *testing.T) {
+	for name, tc := range map[string]struct {
+		// rules are the -existing_scala_library_rule flag values.  When nil,
+		// the global provider registry is used as-is.
+		rules        []string
+		content      string
+		want         string
+		wantLoadInfo *rule.LoadInfo
+	}{
+		"native is left unchanged by default": {
+			content: `java_library(name = "lib")
+`,
+			want: `java_library(name = "lib")
+`,
+		},
+		"rules_java": {
+			rules: []string{"@rules_java//java:defs.bzl%java_library"},
+			content: `load("@rules_java//java:defs.bzl", "java_library")
+
+java_library(name = "lib")
+`,
+			want: `load("@rules_java//java:defs.bzl", "java_library")
+
+java_library(name = "lib")
+`,
+			wantLoadInfo: &rule.LoadInfo{
+				Name:    "@rules_java//java:defs.bzl",
+				Symbols: []string{"java_library"},
+			},
+		},
+		"native": {
+			rules: []string{"%java_library"},
+			content: `java_library(name = "lib")
+`,
+			want: `java_library(name = "lib")
+`,
+			wantLoadInfo: &rule.LoadInfo{
+				Symbols: []string{"java_library"},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			lang := NewLanguage().(*scalaLang)
+			if tc.rules != nil {
+				lang.ruleProviderRegistry = scalarule.NewProviderRegistryMap() // don't use global one
+				if err := lang.setupExistingScalaLibraryRules(tc.rules); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			f, err := rule.LoadData("BUILD.bazel", "", []byte(tc.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fqn := fullyQualifiedLoadName(f.Loads, f.Rules[0].Kind())
+			provider, ok := lang.ruleProviderRegistry.LookupProvider(fqn)
+			if tc.wantLoadInfo == nil {
+				if ok {
+					t.Errorf("rule provider unexpectedly registered: %q", fqn)
+				}
+				if _, ok := lang.Kinds()[f.Rules[0].Kind()]; ok {
+					t.Errorf("kind unexpectedly known: %q", f.Rules[0].Kind())
+				}
+			} else {
+				if !ok {
+					t.Fatalf("rule provider not registered: %q", fqn)
+				}
+				if diff := cmp.Diff(*tc.wantLoadInfo, provider.LoadInfo()); diff != "" {
+					t.Errorf("loadInfo (-want +got):\n%s", diff)
+				}
+			}
+
+			merger.FixLoads(f, lang.Loads())
+			if diff := cmp.Diff(tc.want, string(f.Format())); diff != "" {
+				t.Errorf("build file (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -10,17 +10,24 @@ import (
 	"path/filepath"
 	"runtime/pprof"
 	"strings"
+	"time"
 
 	"github.com/bazelbuild/bazel-gazelle/config"
 
 	"github.com/stackb/scala-gazelle/pkg/collections"
+	"github.com/stackb/scala-gazelle/pkg/provider"
 	"github.com/stackb/scala-gazelle/pkg/resolver"
+	"github.com/stackb/scala-gazelle/pkg/scalaconfig"
+	"github.com/stackb/scala-gazelle/pkg/starlarkresolver"
 )
 
 const (
 	scalaSymbolProviderFlagName          = "scala_symbol_provider"
 	scalaConflictResolverFlagName        = "scala_conflict_resolver"
 	scalaDepsCleanerFlagName             = "scala_deps_cleaner"
+	scalaRuntimeDepsResolverFlagName     = "scala_runtime_deps_resolver"
+	scalaStarlarkScriptFlagName          = "scala_starlark_script"
+	scalaExecSymbolProviderFlagName      = "scala_exec_symbol_provider"
 	existingScalaBinaryRuleFlagName      = "existing_scala_binary_rule"
 	existingScalaLibraryRuleFlagName     = "existing_scala_library_rule"
 	existingScalaTestRuleFlagName        = "existing_scala_test_rule"
@@ -30,9 +37,12 @@ const (
 	scalaGazelleDebugProcessFileFlagName = "scala_gazelle_debug_process"
 	scalaGazelleCacheKeyFlagName         = "scala_gazelle_cache_key"
 	scalaGazellePrintCacheKeyFlagName    = "scala_gazelle_print_cache_key"
+	scalaGazelleCacheVerifyFlagName      = "scala_gazelle_cache_verify"
+	scalaGazelleCacheStaleFlagName       = "scala_gazelle_cache_stale_threshold"
 	cpuprofileFileFlagName               = "cpuprofile_file"
 	memprofileFileFlagName               = "memprofile_file"
 	logFileFlagName                      = "log_file"
+	scalaParseErrorPolicyFlagName        = "scala_parse_error_policy"
 )
 
 // RegisterFlags implements part of the language.Language interface
@@ -45,17 +55,24 @@ func (sl *scalaLang) RegisterFlags(flags *flag.FlagSet, cmd string, c *config.Co
 	flags.StringVar(&sl.cacheFileFlagValue, scalaGazelleCacheFileFlagName, "", "optional path a cache file (.json or .pb)")
 	flags.StringVar(&sl.importsFileFlagValue, scalaGazelleImportsFileFlagName, "", "optional path to an imports file where resolved imports should be written (.json or .pb)")
 	flags.StringVar(&sl.cacheKeyFlagValue, scalaGazelleCacheKeyFlagName, "", "optional string that can be used to bust the cache file")
+	flags.BoolVar(&sl.cacheVerifyFlagValue, scalaGazelleCacheVerifyFlagName, false, "if true, hash every source file to validate the cache, even if its size and mtime are unchanged")
+	flags.DurationVar(&sl.cacheStaleFlagValue, scalaGazelleCacheStaleFlagName, 7*24*time.Hour, "the coverage report warns about cached rules in packages not visited during the run that were last visited longer ago than this (0 to disable)")
 	flags.StringVar(&sl.cpuprofileFlagValue, cpuprofileFileFlagName, "", "optional path a cpuprofile file (.prof)")
 	flags.StringVar(&sl.memprofileFlagValue, memprofileFileFlagName, "", "optional path a memory profile file (.prof)")
+	flags.StringVar(&sl.parseErrorPolicyFlagValue, scalaParseErrorPolicyFlagName, "keep_existing", "default policy for rules having files that fail to parse (fail|keep_existing|warn); can be overridden with the scala_parse_error_policy directive")
 	flags.Var(&sl.symbolProviderNamesFlagValue, scalaSymbolProviderFlagName, "name of a symbol provider implementation to enable")
 	flags.Var(&sl.conflictResolverNamesFlagValue, scalaConflictResolverFlagName, "name of a conflict resolver implementation to enable")
 	flags.Var(&sl.depsCleanerNamesFlagValue, scalaDepsCleanerFlagName, "name of a deps cleaner implementation to enable")
+	flags.Var(&sl.runtimeDepsResolverNamesFlagValue, scalaRuntimeDepsResolverFlagName, "name of a runtime deps resolver implementation to enable")
+	flags.Var(&sl.execSymbolProvidersFlagValue, scalaExecSymbolProviderFlagName, "NAME[:FORMAT]=COMMAND [ARG...] spec of an external symbol provider command; enable it with -scala_symbol_provider=NAME")
+	flags.Var(&sl.starlarkScriptsFlagValue, scalaStarlarkScriptFlagName, "path to a .star file that registers conflict resolvers and deps cleaners (relative to the repository root)")
 	flags.Var(&sl.existingScalaBinaryRulesFlagValue, existingScalaBinaryRuleFlagName, "LOAD%NAME mapping for a custom existing scala binary rule implementation (e.g. '@io_bazel_rules_scala//scala:scala.bzl%scalabinary'")
 	flags.Var(&sl.existingScalaLibraryRulesFlagValue, existingScalaLibraryRuleFlagName, "LOAD%NAME mapping for a custom existing scala library rule implementation (e.g. '@io_bazel_rules_scala//scala:scala.bzl%scala_library'")
 	flags.Var(&sl.existingScalaTestRulesFlagValue, existingScalaTestRuleFlagName, "LOAD%NAME mapping for a custom existing scala test rule implementation (e.g. '@io_bazel_rules_scala//scala:scala.bzl%scala_test'")
 
 	sl.registerSymbolProviders(flags, cmd, c)
 	sl.registerConflictResolvers(flags, cmd, c)
+	sl.registerRuntimeDepsResolvers(flags, cmd, c)
 }
 
 func (sl *scalaLang) registerSymbolProviders(flags *flag.FlagSet, cmd string, c *config.Config) {
@@ -73,6 +90,12 @@ func (sl *scalaLang) registerConflictResolvers(flags *flag.FlagSet, cmd string,
 	}
 }
 
+func (sl *scalaLang) registerRuntimeDepsResolvers(flags *flag.FlagSet, cmd string, c *config.Config) {
+	for _, rr := range resolver.GlobalRuntimeDepsResolvers() {
+		rr.RegisterFlags(flags, cmd, c)
+	}
+}
+
 // CheckFlags implements part of the language.Language interface
 func (sl *scalaLang) CheckFlags(flags *flag.FlagSet, c *config.Config) error {
 	if sl.debugProcessFlagValue {
@@ -81,15 +104,24 @@ func (sl *scalaLang) CheckFlags(flags *flag.FlagSet, c *config.Config) error {
 
 	sl.symbolResolver = newUniverseResolver(sl, sl.globalPackages)
 
+	if err := sl.setupExecSymbolProviders(sl.execSymbolProvidersFlagValue); err != nil {
+		return err
+	}
 	if err := sl.setupSymbolProviders(flags, c, sl.symbolProviderNamesFlagValue); err != nil {
 		return err
 	}
+	if err := sl.setupStarlarkScripts(c.RepoRoot, sl.starlarkScriptsFlagValue); err != nil {
+		return err
+	}
 	if err := sl.setupConflictResolvers(flags, c, sl.conflictResolverNamesFlagValue); err != nil {
 		return err
 	}
 	if err := sl.setupDepsCleaners(flags, c, sl.depsCleanerNamesFlagValue); err != nil {
 		return err
 	}
+	if err := sl.setupRuntimeDepsResolvers(flags, c, sl.runtimeDepsResolverNamesFlagValue); err != nil {
+		return err
+	}
 	if err := sl.setupExistingScalaBinaryRules(sl.existingScalaBinaryRulesFlagValue); err != nil {
 		return err
 	}
@@ -99,7 +131,10 @@ func (sl *scalaLang) CheckFlags(flags *flag.FlagSet, c *config.Config) error {
 	if err := sl.setupExistingScalaTestRules(sl.existingScalaTestRulesFlagValue); err != nil {
 		return err
 	}
-	if err := sl.setupCache(); err != nil {
+	if err := sl.setupCache(c.RepoRoot); err != nil {
+		return err
+	}
+	if err := sl.setupParseErrorPolicy(sl.parseErrorPolicyFlagValue); err != nil {
 		return err
 	}
 	if err := sl.setupCpuProfiling(c.WorkDir); err != nil {
@@ -114,6 +149,30 @@ func (sl *scalaLang) CheckFlags(flags *flag.FlagSet, c *config.Config) error {
 	return nil
 }
 
+func (sl *scalaLang) setupParseErrorPolicy(value string) error {
+	policy, err := scalaconfig.ParseParseErrorPolicy(value)
+	if err != nil {
+		return fmt.Errorf("-%s: %w", scalaParseErrorPolicyFlagName, err)
+	}
+	sl.parseErrorPolicy = policy
+	return nil
+}
+
+func (sl *scalaLang) setupExecSymbolProviders(specs []string) error {
+	sl.logger.Debug().Msgf("setting up %d exec symbol providers", len(specs))
+
+	for _, spec := range specs {
+		p, err := provider.ParseExecProviderSpec(spec)
+		if err != nil {
+			return fmt.Errorf("-%s: %w", scalaExecSymbolProviderFlagName, err)
+		}
+		if err := sl.AddSymbolProvider(p); err != nil {
+			return fmt.Errorf("-%s: %w", scalaExecSymbolProviderFlagName, err)
+		}
+	}
+	return nil
+}
+
 func (sl *scalaLang) setupSymbolProviders(flags *flag.FlagSet, c *config.Config, names []string) error {
 	sl.logger.Debug().Msgf("setting up %d symbol providers", len(names))
 
@@ -130,6 +189,25 @@ func (sl *scalaLang) setupSymbolProviders(flags *flag.FlagSet, c *config.Config,
 	return nil
 }
 
+func (sl *scalaLang) setupStarlarkScripts(repoRoot string, filenames []string) error {
+	sl.logger.Debug().Msgf("setting up %d starlark scripts", len(filenames))
+
+	for _, filename := range filenames {
+		filename = os.ExpandEnv(filename)
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(repoRoot, filename)
+		}
+		script, err := starlarkresolver.LoadFile(filename)
+		if err != nil {
+			return fmt.Errorf("-%s: %w", scalaStarlarkScriptFlagName, err)
+		}
+		if err := script.Register(resolver.GlobalConflictResolverRegistry(), resolver.GlobalDepsCleanerRegistry()); err != nil {
+			return fmt.Errorf("-%s: %w", scalaStarlarkScriptFlagName, err)
+		}
+	}
+	return nil
+}
+
 func (sl *scalaLang) setupConflictResolvers(flags *flag.FlagSet, c *config.Config, names []string) error {
 	sl.logger.Debug().Msgf("setting up %d conflict resolvers", len(names))
 
@@ -162,6 +240,22 @@ func (sl *scalaLang) setupDepsCleaners(flags *flag.FlagSet, c *config.Config, na
 	return nil
 }
 
+func (sl *scalaLang) setupRuntimeDepsResolvers(flags *flag.FlagSet, c *config.Config, names []string) error {
+	sl.logger.Debug().Msgf("setting up %d runtime deps resolvers", len(names))
+
+	for _, name := range names {
+		rr, ok := resolver.GlobalRuntimeDepsResolverRegistry().GetRuntimeDepsResolver(name)
+		if !ok {
+			return fmt.Errorf("-%s not found: %q", scalaRuntimeDepsResolverFlagName, name)
+		}
+		if err := rr.CheckFlags(flags, c); err != nil {
+			return err
+		}
+		sl.runtimeDepsResolvers[name] = rr
+	}
+	return nil
+}
+
 func (sl *scalaLang) setupExistingScalaBinaryRules(rules []string) error {
 	sl.logger.Debug().Msgf("setting up %d existing scala binary rules", len(rules))
 
@@ -170,7 +264,7 @@ func (sl *scalaLang) setupExistingScalaBinaryRules(rules []string) error {
 		if len(parts) != 2 {
 			return fmt.Errorf("invalid -existing_scala_binary_rule flag value: wanted '%%' separated string, got %q", fqn)
 		}
-		if err := sl.setupExistingScalaBinaryRule(fqn, parts[0], parts[1]); err != nil {
+		if err := sl.setupExistingScalaBinaryRule(existingScalaRuleName(parts[0], parts[1]), parts[0], parts[1]); err != nil {
 			return err
 		}
 	}
@@ -185,7 +279,7 @@ func (sl *scalaLang) setupExistingScalaLibraryRules(rules []string) error {
 		if len(parts) != 2 {
 			return fmt.Errorf("invalid -existing_scala_library_rule flag value: wanted '%%' separated string, got %q", fqn)
 		}
-		if err := sl.setupExistingScalaLibraryRule(fqn, parts[0], parts[1]); err != nil {
+		if err := sl.setupExistingScalaLibraryRule(existingScalaRuleName(parts[0], parts[1]), parts[0], parts[1]); err != nil {
 			return err
 		}
 	}
@@ -200,7 +294,7 @@ func (sl *scalaLang) setupExistingScalaTestRules(rules []string) error {
 		if len(parts) != 2 {
 			return fmt.Errorf("invalid -existing_scala_test_rule flag value: wanted '%%' separated string, got %q", fqn)
 		}
-		if err := sl.setupExistingScalaTestRule(fqn, parts[0], parts[1]); err != nil {
+		if err := sl.setupExistingScalaTestRule(existingScalaRuleName(parts[0], parts[1]), parts[0], parts[1]); err != nil {
 			return err
 		}
 	}
@@ -240,7 +334,10 @@ func (sl *scalaLang) setupExistingScalaTestRule(fqn, load, kind string) error {
 	return sl.ruleProviderRegistry.RegisterProvider(fqn, provider)
 }
 
-func (sl *scalaLang) setupCache() error {
+func (sl *scalaLang) setupCache(repoRoot string) error {
+	sl.repoRoot = repoRoot
+	sl.parser.SetParserVersion(sl.sourceProvider.ParserVersion())
+	sl.parser.SetVerifyFiles(sl.cacheVerifyFlagValue)
 	if sl.cacheFileFlagValue != "" {
 		sl.cacheFileFlagValue = os.ExpandEnv(sl.cacheFileFlagValue)
 		if err := sl.readScalaRuleCacheFile(); err != nil {
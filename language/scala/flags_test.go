@@ -6,12 +6,14 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bazelbuild/bazel-gazelle/config"
 	"github.com/bazelbuild/bazel-gazelle/rule"
 	"github.com/bazelbuild/bazel-gazelle/testtools"
 	"github.com/google/go-cmp/cmp"
 
+	"github.com/stackb/scala-gazelle/pkg/scalacache"
 	"github.com/stackb/scala-gazelle/pkg/scalarule"
 	"github.com/stackb/scala-gazelle/pkg/testutil"
 )
@@ -48,6 +50,28 @@ func TestCacheFlags(t *testing.T) {
 				}
 			},
 		},
+		"scala_gazelle_cache_file__migrated": {
+			files: []testtools.FileSpec{
+				{
+					Path:    "./cache.json",
+					Content: `{"package_count": 100, "rules": [{"label": "//a:a"}]}`,
+				},
+			},
+			args: []string{
+				"-scala_gazelle_cache_file=${TEST_TMPDIR}/cache.json",
+			},
+			check: func(t *testing.T, tmpDir string, lang *scalaLang) {
+				if diff := cmp.Diff(scalacache.SchemaVersion, lang.cache.SchemaVersion); diff != "" {
+					t.Errorf("SchemaVersion (-want got):\n%s", diff)
+				}
+				if diff := cmp.Diff(int32(100), lang.cache.PackageCount); diff != "" {
+					t.Errorf("PackageCount (-want got):\n%s", diff)
+				}
+				if len(lang.cache.Rules) != 0 {
+					t.Errorf("expected rules to be evicted, got %d", len(lang.cache.Rules))
+				}
+			},
+		},
 		"scala_gazelle_print_cache_key_on": {
 			args: []string{
 				"-scala_gazelle_cache_key=12345",
@@ -72,6 +96,26 @@ func TestCacheFlags(t *testing.T) {
 				}
 			},
 		},
+		"scala_gazelle_cache_verify": {
+			args: []string{
+				"-scala_gazelle_cache_verify",
+			},
+			check: func(t *testing.T, tmpDir string, lang *scalaLang) {
+				if diff := cmp.Diff(true, lang.cacheVerifyFlagValue); diff != "" {
+					t.Errorf("cacheVerifyFlagValue (-want got):\n%s", diff)
+				}
+			},
+		},
+		"scala_gazelle_cache_stale_threshold": {
+			args: []string{
+				"-scala_gazelle_cache_stale_threshold=24h",
+			},
+			check: func(t *testing.T, tmpDir string, lang *scalaLang) {
+				if diff := cmp.Diff(24*time.Hour, lang.cacheStaleFlagValue); diff != "" {
+					t.Errorf("cacheStaleFlagValue (-want got):\n%s", diff)
+				}
+			},
+		},
 		"scala_gazelle_cache_key__valid": {
 			files: []testtools.FileSpec{
 				{
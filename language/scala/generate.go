@@ -17,6 +17,7 @@ func (sl *scalaLang) GenerateRules(args language.GenerateArgs) (result language.
 	now := time.Now()
 
 	sl.logger.Debug().Msgf("visiting directory %s", args.Rel)
+	sl.visitedPackages[args.Rel] = true
 
 	sc := scalaconfig.Get(args.Config)
 	if args.File == nil && !sc.GenerateBuildFiles() {
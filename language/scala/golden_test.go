@@ -14,6 +14,7 @@ func TestScala(t *testing.T) {
 	}
 	goldentest.FromDir("language/scala",
 		goldentest.WithOnlyTests(
+			"generate_binaries",
 			"java_provider",
 			"maven_provider",
 			"override_provider",
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/bazelbuild/bazel-gazelle/label"
 	"github.com/bazelbuild/bazel-gazelle/language"
@@ -38,6 +39,12 @@ type scalaLang struct {
 	cacheFileFlagValue string
 	// cacheKeyFlagValue is the main cache key, if enabled
 	cacheKeyFlagValue string
+	// cacheVerifyFlagValue disables the size/mtime fast path of cache
+	// validation
+	cacheVerifyFlagValue bool
+	// cacheStaleFlagValue is the age of cached rules in unvisited packages
+	// that are reported as possibly stale
+	cacheStaleFlagValue time.Duration
 	// importsFileFlagValue is the name of a file to dump resolved import map to, if enabled
 	importsFileFlagValue string
 	// symbolProviderNamesFlagValue is a repeatable list of resolver to enable
@@ -48,6 +55,15 @@ type scalaLang struct {
 	// depsCleanerNamesFlagValue is a repeatable list of deps cleaners
 	// to enable
 	depsCleanerNamesFlagValue collections.StringSlice
+	// runtimeDepsResolverNamesFlagValue is a repeatable list of runtime deps
+	// resolvers to enable
+	runtimeDepsResolverNamesFlagValue collections.StringSlice
+	// execSymbolProvidersFlagValue is a repeatable list of exec symbol
+	// provider specs
+	execSymbolProvidersFlagValue collections.StringSlice
+	// starlarkScriptsFlagValue is a repeatable list of .star files that
+	// register conflict resolvers and deps cleaners
+	starlarkScriptsFlagValue collections.StringSlice
 	// existingScalaLibraryRulesFlagValue is the value of the
 	// existing_scala_binary_rule repeatable flag
 	existingScalaBinaryRulesFlagValue collections.StringSlice
@@ -60,8 +76,18 @@ type scalaLang struct {
 	cpuprofileFlagValue                string
 	existingScalaRuleCoverageFlagValue bool
 	memprofileFlagValue                string
+	parseErrorPolicyFlagValue          string
+	// parseErrorPolicy is the parsed value of the parse error policy flag,
+	// applied to the root config.
+	parseErrorPolicy scalaconfig.ParseErrorPolicy
 	// cache is the loaded cache, if configured
 	cache scpb.Cache
+	// visitedPackages is the set of packages visited during this run, whether
+	// or not they have a BUILD file.
+	visitedPackages map[string]bool
+	// repoRoot is the root directory of the repository.  Cached files that no
+	// longer exist there are pruned.
+	repoRoot string
 	// ruleProviderRegistry is the rule registry implementation.  This holds the
 	// rules configured via gazelle directives by the user.
 	ruleProviderRegistry scalarule.ProviderRegistry
@@ -84,6 +110,9 @@ type scalaLang struct {
 	conflictResolvers map[string]resolver.ConflictResolver
 	// depsCleaners is a map of all known deps cleaner implementations
 	depsCleaners map[string]resolver.DepsCleaner
+	// runtimeDepsResolvers is a map of all known runtime deps resolver
+	// implementations
+	runtimeDepsResolvers map[string]resolver.RuntimeDepsResolver
 	// globalScope includes all known symbols in the universe (minus package
 	// symbols)
 	globalScope resolver.Scope
@@ -118,7 +147,9 @@ func NewLanguage() language.Language {
 		knownRules:           make(map[label.Label]*rule.Rule),
 		conflictResolvers:    make(map[string]resolver.ConflictResolver),
 		depsCleaners:         make(map[string]resolver.DepsCleaner),
+		runtimeDepsResolvers: make(map[string]resolver.RuntimeDepsResolver),
 		packages:             make(map[string]*scalaPackage),
+		visitedPackages:      make(map[string]bool),
 		progress:             mobyprogress.NewProgressOutput(mobyprogress.NewOut(os.Stderr)),
 		ruleProviderRegistry: scalarule.GlobalProviderRegistry(),
 		logFile:              logFile,
@@ -23,8 +23,17 @@ func ExampleLanguage_KnownDirectives() {
 	// scala_debug
 	// scala_log_level
 	// scala_deps_cleaner
+	// scala_runtime_deps_resolver
 	// scala_keep_unmanaged_deps
 	// scala_fix_wildcard_imports
 	// scala_generate_build_files
+	// scala_generate_binaries
+	// scala_granularity
+	// scala_file_rule_name
 	// scala_rule
+	// scala_test_file_patterns
+	// scala_export_signatures
+	// scala_parse_error_policy
+	// scala_wildcard_imports
+	// scala_dialect
 }
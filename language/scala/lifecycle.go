@@ -1,7 +1,13 @@
 package scala
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
 
 	"github.com/stackb/scala-gazelle/pkg/resolver"
 )
@@ -17,6 +23,12 @@ func (sl *scalaLang) onResolve() {
 		}
 	}
 
+	if err := sl.checkParseErrors(); err != nil {
+		log.Fatal(err)
+	}
+
+	sl.pruneCachedRules()
+
 	// assign final readonly scala-specific scope
 	if scalaScope, err := resolver.NewScalaScope(sl.globalScope); err != nil {
 		sl.logger.Printf("warning: setting up global resolver scope: %v", err)
@@ -31,6 +43,28 @@ func (sl *scalaLang) onResolve() {
 	}
 }
 
+// checkParseErrors returns an error that lists all files that failed to parse
+// in rules having the 'fail' parse error policy.
+func (sl *scalaLang) checkParseErrors() error {
+	var failed []*sppb.File
+	for _, pkg := range sl.packages {
+		failed = append(failed, pkg.ParseErrors()...)
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[i].Filename < failed[j].Filename
+	})
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "%d file(s) failed to parse (scala_parse_error_policy is fail):", len(failed))
+	for _, file := range failed {
+		fmt.Fprintf(&report, "\n  %s: %s", file.Filename, file.Error)
+	}
+	return errors.New(report.String())
+}
+
 // onEnd is called when the last rule has been resolved.
 func (sl *scalaLang) onEnd() {
 	sl.phaseTransition("end")
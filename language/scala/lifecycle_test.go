@@ -0,0 +1,47 @@
+package scala
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+func TestCheckParseErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		packages map[string]*scalaPackage
+		wantErr  string
+	}{
+		"degenerate": {},
+		"no errors": {
+			packages: map[string]*scalaPackage{
+				"a": {},
+			},
+		},
+		"errors are sorted by filename": {
+			packages: map[string]*scalaPackage{
+				"b": {parseErrors: []*sppb.File{
+					{Filename: "b/B.scala", Error: "expected class or object definition"},
+				}},
+				"a": {parseErrors: []*sppb.File{
+					{Filename: "a/A.scala", Error: "illegal start of simple expression"},
+				}},
+			},
+			wantErr: `2 file(s) failed to parse (scala_parse_error_policy is fail):
+  a/A.scala: illegal start of simple expression
+  b/B.scala: expected class or object definition`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sl := &scalaLang{packages: tc.packages}
+			var gotErr string
+			if err := sl.checkParseErrors(); err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Errorf("error (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
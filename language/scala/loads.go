@@ -18,6 +18,10 @@ func (sl *scalaLang) Loads() []rule.LoadInfo {
 			log.Fatalf("unknown rule provider: %q", name)
 		}
 		load := provider.LoadInfo()
+		if load.Name == "" {
+			// native rule, nothing to load
+			continue
+		}
 		symbolsByLoadName[load.Name] = append(symbolsByLoadName[load.Name], load.Symbols...)
 	}
 
@@ -0,0 +1,24 @@
+package scala
+
+import (
+	"fmt"
+
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+)
+
+// GetRuntimeDepsResolver implements part of the
+// resolver.RuntimeDepsResolverRegistry interface.
+func (sl *scalaLang) GetRuntimeDepsResolver(name string) (resolver.RuntimeDepsResolver, bool) {
+	r, ok := sl.runtimeDepsResolvers[name]
+	return r, ok
+}
+
+// PutRuntimeDepsResolver implements part of the
+// resolver.RuntimeDepsResolverRegistry interface.
+func (sl *scalaLang) PutRuntimeDepsResolver(name string, r resolver.RuntimeDepsResolver) error {
+	if _, ok := sl.runtimeDepsResolvers[name]; ok {
+		return fmt.Errorf("duplicate runtime deps resolver: %s", name)
+	}
+	sl.runtimeDepsResolvers[name] = r
+	return nil
+}
@@ -2,9 +2,12 @@ package scala
 
 import (
 	"fmt"
+	"io/fs"
 	"log"
+	"os"
+	"path"
 	"path/filepath"
-	"strings"
+	"sort"
 
 	"github.com/bazelbuild/bazel-gazelle/config"
 	"github.com/bazelbuild/bazel-gazelle/label"
@@ -53,6 +56,18 @@ type scalaPackage struct {
 	ruleCoverage *packageRuleCoverage
 	// files is a list of scala files that are within this package.
 	files []*sppb.File
+	// parseErrors is a list of files that failed to parse in rules having the
+	// 'fail' parse error policy.
+	parseErrors []*sppb.File
+	// parsed holds the results of rules that were parsed ahead of their
+	// resolution (see parseRules).
+	parsed map[parsedRuleKey]*parser.RuleResult
+}
+
+// parsedRuleKey is the key of a rule parsed ahead of its resolution.
+type parsedRuleKey struct {
+	rule     *rule.Rule
+	attrName string
 }
 
 // newScalaPackage constructs a Package given a list of scala files.
@@ -61,13 +76,13 @@ func newScalaPackage(
 	args language.GenerateArgs,
 	cfg *scalaconfig.Config,
 	providerRegistry scalarule.ProviderRegistry,
-	parser parser.Parser,
+	scalaParser parser.Parser,
 	universe resolver.Universe) *scalaPackage {
 
 	s := &scalaPackage{
 		logger:           logger,
 		args:             args,
-		parser:           parser,
+		parser:           scalaParser,
 		universe:         universe,
 		providerRegistry: providerRegistry,
 		cfg:              cfg,
@@ -137,6 +152,7 @@ func (s *scalaPackage) generateRules(enabled bool) []scalarule.RuleProvider {
 	rules := make([]scalarule.RuleProvider, 0)
 
 	existingRulesByFQN := make(map[string][]*rule.Rule)
+	var hasManagedRules bool
 	if s.args.File != nil {
 		s.logger.Debug().Msgf("checking for pre-existing rules in %s...", s.args.File.Path)
 
@@ -145,6 +161,7 @@ func (s *scalaPackage) generateRules(enabled bool) []scalarule.RuleProvider {
 			existingRulesByFQN[fqn] = append(existingRulesByFQN[fqn], r)
 			if provider, ok := s.providerRegistry.LookupProvider(fqn); ok {
 				s.logger.Debug().Msgf("found: rule provider for %s is %T", fqn, provider.Name())
+				hasManagedRules = true
 
 				// TOOD(pcj): consider adding .ContributesToCoverage or some
 				// other way of tracking which rules contribute to coverage
@@ -160,6 +177,24 @@ func (s *scalaPackage) generateRules(enabled bool) []scalarule.RuleProvider {
 
 	configuredRules := s.cfg.ConfiguredRules()
 
+	for _, rc := range configuredRules {
+		if !rc.Enabled || rc.Provider != nil {
+			continue
+		}
+		provider, ok := s.providerRegistry.LookupProvider(rc.Implementation)
+		if !ok {
+			log.Fatalf(
+				"rule not registered: %q (available: %v)",
+				rc.Implementation,
+				s.providerRegistry.ProviderNames(),
+			)
+		}
+		s.logger.Debug().Msgf("rule %s provider is %T", rc.Name, provider)
+		rc.Provider = provider
+	}
+
+	s.parseRules("srcs", s.existingRulesToParse(configuredRules, existingRulesByFQN))
+
 	for _, rc := range configuredRules {
 		if !rc.Enabled {
 			s.logger.Debug().Msgf("%s configuration not enabled, skipping rule generation", rc.Name)
@@ -167,19 +202,6 @@ func (s *scalaPackage) generateRules(enabled bool) []scalarule.RuleProvider {
 			continue
 		}
 
-		if rc.Provider == nil {
-			provider, ok := s.providerRegistry.LookupProvider(rc.Implementation)
-			if !ok {
-				log.Fatalf(
-					"rule not registered: %q (available: %v)",
-					rc.Implementation,
-					s.providerRegistry.ProviderNames(),
-				)
-			}
-			s.logger.Debug().Msgf("rule %s provider is %T", rc.Name, provider)
-			rc.Provider = provider
-		}
-
 		providedRule := rc.Provider.ProvideRule(rc, s)
 		if providedRule != nil {
 			s.logger.Debug().Msgf("new provided rule: %s%%s", providedRule.Name(), providedRule.Kind())
@@ -189,6 +211,15 @@ func (s *scalaPackage) generateRules(enabled bool) []scalarule.RuleProvider {
 		existing := existingRulesByFQN[rc.Implementation]
 		if len(existing) > 0 {
 			for _, r := range existing {
+				if s.isStaleFileRule(rc, r) {
+					// delete the rule from the existing file directly rather
+					// than reporting it as empty: the kinds have no
+					// NonEmptyAttrs, which would change how gazelle merges
+					// them outside of 'file' granularity mode.
+					s.logger.Debug().Msgf("deleting stale per-file rule %s %s", r.Kind(), r.Name())
+					r.Delete()
+					continue
+				}
 				resolvedRule := s.resolveRule(rc, r)
 				if resolvedRule != nil {
 					s.logger.Debug().Msgf("new resolved rule: %s %s", resolvedRule.Name(), resolvedRule.Kind())
@@ -208,9 +239,312 @@ func (s *scalaPackage) generateRules(enabled bool) []scalarule.RuleProvider {
 		delete(existingRulesByFQN, rc.Implementation)
 	}
 
+	switch s.cfg.Granularity() {
+	case scalaconfig.GranularityFile:
+		rules = append(rules, s.generateFileRules(configuredRules, rules)...)
+	default:
+		if !hasManagedRules && s.cfg.GenerateBuildFiles() && s.isGranularityRoot() {
+			rules = append(rules, s.generateNewRules(configuredRules)...)
+		}
+	}
+
+	if s.cfg.GenerateBinaries() {
+		rules = append(rules, s.generateBinaryRules(configuredRules, rules)...)
+	}
+
+	return rules
+}
+
+// existingRulesToParse returns the existing rules that are resolved by an
+// existingScalaRuleProvider, in the order they are resolved by generateRules.
+func (s *scalaPackage) existingRulesToParse(configuredRules []*scalarule.Config, existingRulesByFQN map[string][]*rule.Rule) []*rule.Rule {
+	var rules []*rule.Rule
+	seen := make(map[string]bool)
+	for _, rc := range configuredRules {
+		if !rc.Enabled || seen[rc.Implementation] {
+			continue
+		}
+		seen[rc.Implementation] = true
+		if _, ok := rc.Provider.(*existingScalaRuleProvider); !ok {
+			continue
+		}
+		for _, r := range existingRulesByFQN[rc.Implementation] {
+			if !s.isStaleFileRule(rc, r) {
+				rules = append(rules, r)
+			}
+		}
+	}
+	return rules
+}
+
+// generateNewRules creates rules for a package that has .scala files but no
+// existing managed rules.  Main sources are assigned to the first enabled
+// library rule configuration and test sources (as determined by the
+// scala_test_file_patterns directive) to the first enabled test rule
+// configuration.  The new rules are resolved in the same manner as existing
+// ones.
+func (s *scalaPackage) generateNewRules(configuredRules []*scalarule.Config) []scalarule.RuleProvider {
+	srcs, testSrcs := s.partitionSrcs(s.scalaSrcs())
+	if len(srcs) == 0 && len(testSrcs) == 0 {
+		return nil
+	}
+
+	libraryConfig, testConfig, _ := generatedRuleConfigs(configuredRules)
+	name := s.defaultRuleName()
+
+	return s.generateRuleSet([]*generatedRule{
+		{libraryConfig, name, srcs},
+		{testConfig, name + "_test", testSrcs},
+	})
+}
+
+// generateFileRules creates one rule per .scala file in the package that is
+// not already named in the srcs of one of the given rules.  Rule names are
+// determined by the scala_file_rule_name directive.
+func (s *scalaPackage) generateFileRules(configuredRules []*scalarule.Config, existing []scalarule.RuleProvider) []scalarule.RuleProvider {
+	dir := filepath.Join(s.repoRootDir(), s.args.Rel)
+
+	covered := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, provider := range existing {
+		r := provider.Rule()
+		if r == nil {
+			continue
+		}
+		names[r.Name()] = true
+		srcs, err := glob.CollectFilenames(s.args.File, dir, r.Attr("srcs"))
+		if err != nil {
+			continue
+		}
+		for _, src := range srcs {
+			covered[src] = true
+		}
+	}
+	if s.args.File != nil {
+		for _, r := range s.args.File.Rules {
+			names[r.Name()] = true
+		}
+	}
+
+	libraryConfig, testConfig, _ := generatedRuleConfigs(configuredRules)
+	srcs, testSrcs := s.partitionSrcs(s.scalaSrcs())
+	generated := make([]*generatedRule, 0, len(srcs)+len(testSrcs))
+
+	generate := func(rc *scalarule.Config, src string) {
+		if covered[src] {
+			return
+		}
+		name := s.cfg.FileRuleName(src)
+		if names[name] {
+			log.Printf("%s: not generating rule for %s: name %q is already taken", s.cfg.Rel(), src, name)
+			return
+		}
+		names[name] = true
+		generated = append(generated, &generatedRule{rc, name, []string{src}})
+	}
+
+	for _, src := range srcs {
+		generate(libraryConfig, src)
+	}
+	for _, src := range testSrcs {
+		generate(testConfig, src)
+	}
+
+	return s.generateRuleSet(generated)
+}
+
+// generatedRule describes a new rule of the kind of the given rule
+// configuration.
+type generatedRule struct {
+	rc   *scalarule.Config
+	name string
+	srcs []string
+}
+
+// generateRuleSet creates the given rules and resolves them.  Rules whose
+// configuration is nil or that have no srcs are skipped.  The rules are parsed
+// together ahead of their resolution, such that they are parsed concurrently.
+func (s *scalaPackage) generateRuleSet(generated []*generatedRule) []scalarule.RuleProvider {
+	var configs []*scalarule.Config
+	var newRules []*rule.Rule
+	for _, g := range generated {
+		if g.rc == nil || len(g.srcs) == 0 {
+			continue
+		}
+		r := rule.NewRule(g.rc.Provider.Name(), g.name)
+		r.SetAttr("srcs", g.srcs)
+		configs = append(configs, g.rc)
+		newRules = append(newRules, r)
+	}
+
+	s.parseRules("srcs", newRules)
+
+	rules := make([]scalarule.RuleProvider, 0, len(newRules))
+	for i, r := range newRules {
+		if provided := s.resolveGeneratedRule(configs[i], r); provided != nil {
+			rules = append(rules, provided)
+		}
+	}
 	return rules
 }
 
+// resolveGeneratedRule resolves a newly created rule and records it in the
+// rule coverage.
+func (s *scalaPackage) resolveGeneratedRule(rc *scalarule.Config, r *rule.Rule) scalarule.RuleProvider {
+	provided := s.resolveRule(rc, r)
+	if provided == nil {
+		return nil
+	}
+	s.logger.Debug().Msgf("new generated rule: %s %s", provided.Kind(), provided.Name())
+
+	s.ruleCoverage.total += 1
+	s.ruleCoverage.managed += 1
+	s.ruleCoverage.kinds[r.Kind()] += 1
+
+	return provided
+}
+
+// generatedRuleConfigs returns the first enabled library, test and binary rule
+// configurations that are implemented by an existingScalaRuleProvider.  Any
+// may be nil.
+func generatedRuleConfigs(configuredRules []*scalarule.Config) (library, test, binary *scalarule.Config) {
+	for _, rc := range configuredRules {
+		if !rc.Enabled {
+			continue
+		}
+		provider, ok := rc.Provider.(*existingScalaRuleProvider)
+		if !ok {
+			continue
+		}
+		if provider.isLibrary && library == nil {
+			library = rc
+		}
+		if provider.isTest && test == nil {
+			test = rc
+		}
+		if provider.isBinary && binary == nil {
+			binary = rc
+		}
+	}
+	return
+}
+
+// isGranularityRoot returns whether rules should be generated in this
+// directory.  In 'package' granularity mode, subdirectories that do not have a
+// BUILD file belong to the enclosing package.
+func (s *scalaPackage) isGranularityRoot() bool {
+	if s.cfg.Granularity() != scalaconfig.GranularityPackage {
+		return true
+	}
+	return s.args.File != nil || s.args.Rel == s.cfg.GranularityRel()
+}
+
+// isStaleFileRule returns whether the given existing rule is a per-file rule
+// (in 'file' granularity mode) whose source file no longer exists.
+func (s *scalaPackage) isStaleFileRule(rc *scalarule.Config, r *rule.Rule) bool {
+	if s.cfg.Granularity() != scalaconfig.GranularityFile {
+		return false
+	}
+	if _, ok := rc.Provider.(*existingScalaRuleProvider); !ok {
+		return false
+	}
+	srcs := r.AttrStrings("srcs")
+	if len(srcs) != 1 || r.ShouldKeep() {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(s.repoRootDir(), s.args.Rel, srcs[0])); err == nil {
+		return false
+	}
+	return true
+}
+
+// scalaSrcs returns the list of .scala files for generated rules, relative to
+// the package directory.  In 'package' granularity mode this includes files in
+// subdirectories that do not have a BUILD file.
+func (s *scalaPackage) scalaSrcs() []string {
+	var srcs []string
+	for _, filename := range s.args.RegularFiles {
+		if filepath.Ext(filename) == ".scala" {
+			srcs = append(srcs, filename)
+		}
+	}
+	if s.cfg.Granularity() != scalaconfig.GranularityPackage {
+		return srcs
+	}
+
+	dir := filepath.Join(s.repoRootDir(), s.args.Rel)
+	c := s.cfg.Config()
+	for _, subdir := range s.args.Subdirs {
+		root := filepath.Join(dir, subdir)
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if isBuildPackageDir(c, path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(path) == ".scala" {
+				if rel, err := filepath.Rel(dir, path); err == nil {
+					srcs = append(srcs, filepath.ToSlash(rel))
+				}
+			}
+			return nil
+		})
+	}
+	return srcs
+}
+
+// partitionSrcs splits the given list of filenames into sorted lists of main
+// and test .scala sources.
+func (s *scalaPackage) partitionSrcs(filenames []string) (srcs, testSrcs []string) {
+	for _, filename := range filenames {
+		if filepath.Ext(filename) != ".scala" {
+			continue
+		}
+		if s.cfg.IsTestFile(filename) {
+			testSrcs = append(testSrcs, filename)
+		} else {
+			srcs = append(srcs, filename)
+		}
+	}
+	sort.Strings(srcs)
+	sort.Strings(testSrcs)
+	return
+}
+
+// isParseableSrc returns whether the given source file can be parsed for
+// symbols.  Java sources are parsed in addition to scala sources so that mixed
+// rules (and java_library rules) provide the java types they define.
+func isParseableSrc(filename string) bool {
+	switch filepath.Ext(filename) {
+	case ".scala", ".java":
+		return true
+	}
+	return false
+}
+
+// defaultRuleName returns the name of a generated rule for the package, which
+// is the base name of the package directory (or the repository root directory).
+func (s *scalaPackage) defaultRuleName() string {
+	if s.args.Rel == "" {
+		return filepath.Base(s.repoRootDir())
+	}
+	return path.Base(s.args.Rel)
+}
+
+// isBuildPackageDir returns whether the given directory contains a BUILD file.
+func isBuildPackageDir(c *config.Config, dir string) bool {
+	for _, name := range c.ValidBuildFileNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *scalaPackage) resolveRule(rc *scalarule.Config, r *rule.Rule) scalarule.RuleProvider {
 	if rr, ok := rc.Provider.(scalarule.RuleResolver); ok {
 		s.logger.Debug().Msgf("resolving rule %s with implementation %T", rc.Name, rc.Provider)
@@ -233,41 +567,93 @@ func (s *scalaPackage) GeneratedRules() (rules []*rule.Rule) {
 	return
 }
 
-// ParseRule implements part of the scalarule.Package interface.
-func (s *scalaPackage) ParseRule(r *rule.Rule, attrName string) (scalaRule scalarule.Rule, err error) {
+// ruleRequest returns the request to parse the .scala and .java files of the
+// named attribute of the given rule.  If there are no such files, the request
+// is returned along with ErrRuleHasNoSrcs.
+func (s *scalaPackage) ruleRequest(r *rule.Rule, attrName string) (*parser.RuleRequest, error) {
 	dir := filepath.Join(s.repoRootDir(), s.args.Rel)
 
-	// collect and filter .scala files from the `srcs` attribute.
+	// collect and filter .scala and .java files from the `srcs` attribute.
 	srcs, err := glob.CollectFilenames(s.args.File, dir, r.Attr(attrName))
 	if err != nil {
 		return nil, err
 	}
 	scalaSrcs := make([]string, 0, len(srcs))
 	for _, src := range srcs {
-		if !strings.HasSuffix(src, ".scala") {
+		if !isParseableSrc(src) {
 			continue
 		}
 		scalaSrcs = append(scalaSrcs, src)
 	}
+
+	req := &parser.RuleRequest{
+		Kind:    r.Kind(),
+		From:    s.cfg.MaybeRewrite(r.Kind(), label.Label{Pkg: s.args.Rel, Name: r.Name()}),
+		Dialect: s.cfg.Dialect(),
+		Dir:     dir,
+		Srcs:    scalaSrcs,
+	}
 	if len(scalaSrcs) == 0 {
-		err = ErrRuleHasNoSrcs
+		return req, ErrRuleHasNoSrcs
+	}
+	return req, nil
+}
+
+// parseRules parses the named attribute of the given rules ahead of their
+// resolution, such that the rules are parsed concurrently (if the parser
+// supports it).  ParseRule then uses the results.
+func (s *scalaPackage) parseRules(attrName string, rules []*rule.Rule) {
+	if len(rules) < 2 {
+		return
+	}
+	var reqs []*parser.RuleRequest
+	var keys []parsedRuleKey
+	for _, r := range rules {
+		req, err := s.ruleRequest(r, attrName)
+		if err != nil {
+			// ParseRule reports the error
+			continue
+		}
+		reqs = append(reqs, req)
+		keys = append(keys, parsedRuleKey{r, attrName})
+	}
+	if s.parsed == nil {
+		s.parsed = make(map[parsedRuleKey]*parser.RuleResult)
+	}
+	for i, result := range parser.ParseScalaRules(s.parser, reqs) {
+		s.parsed[keys[i]] = result
+	}
+}
+
+// ParseRule implements part of the scalarule.Package interface.
+func (s *scalaPackage) ParseRule(r *rule.Rule, attrName string) (scalaRule scalarule.Rule, err error) {
+	req, err := s.ruleRequest(r, attrName)
+	if err != nil && err != ErrRuleHasNoSrcs {
+		return nil, err
 	}
 
 	logger := s.logger.With().Str("kind", r.Kind()).Str("name", r.Name()).Logger()
-	logger.Debug().Msgf("%d scala files collected from %s", len(scalaSrcs), attrName)
+	logger.Debug().Msgf("%d source files collected from %s", len(req.Srcs), attrName)
 
-	from := s.cfg.MaybeRewrite(r.Kind(), label.Label{Pkg: s.args.Rel, Name: r.Name()})
+	from := req.From
 
 	rule := &sppb.Rule{
 		Label: from.String(),
 		Kind:  r.Kind(),
 	}
-	if len(scalaSrcs) > 0 {
-		rule, err = s.parser.ParseScalaRule(r.Kind(), from, dir, scalaSrcs...)
+	if len(req.Srcs) > 0 {
+		key := parsedRuleKey{r, attrName}
+		if result, ok := s.parsed[key]; ok {
+			delete(s.parsed, key)
+			rule, err = result.Rule, result.Err
+		} else {
+			rule, err = s.parser.ParseScalaRule(req.Kind, from, req.Dialect, req.Dir, req.Srcs...)
+		}
 		if err != nil {
 			logger.Warn().Err(err).Msg("parse error")
 			return nil, err
 		}
+		s.applyParseErrorPolicy(from, rule)
 	}
 
 	ctx := &scalaRuleContext{
@@ -282,6 +668,50 @@ func (s *scalaPackage) ParseRule(r *rule.Rule, attrName string) (scalaRule scala
 	return
 }
 
+// applyParseErrorPolicy handles the files of the given rule that failed to
+// parse, including degraded files (those recovered by the lexer fallback
+// still have the error of the parser backend).  Rules are resolved (or not)
+// according to the policy in existingScalaRule.Resolve.
+func (s *scalaPackage) applyParseErrorPolicy(from label.Label, rule *sppb.Rule) {
+	failed := parseErrorFiles(rule.Files)
+	if len(failed) == 0 {
+		return
+	}
+	policy := s.cfg.ParseErrorPolicy()
+	for _, file := range failed {
+		switch policy {
+		case scalaconfig.ParseErrorPolicyWarn:
+			if file.Degraded {
+				log.Printf("WARNING: %s: parse error: %s (deps of %s are resolved using the imports found by the lexer parser)", file.Filename, file.Error, from)
+				continue
+			}
+			log.Printf("WARNING: %s: parse error: %s (deps of %s are resolved from the remaining files)", file.Filename, file.Error, from)
+		case scalaconfig.ParseErrorPolicyKeepExisting:
+			log.Printf("WARNING: %s: parse error: %s (keeping the existing deps of %s)", file.Filename, file.Error, from)
+		}
+	}
+	if policy == scalaconfig.ParseErrorPolicyFail {
+		s.parseErrors = append(s.parseErrors, failed...)
+	}
+}
+
+// ParseErrors returns the files that failed to parse in rules having the
+// 'fail' parse error policy.
+func (s *scalaPackage) ParseErrors() []*sppb.File {
+	return s.parseErrors
+}
+
+// parseErrorFiles returns the subset of files that failed to parse.  Degraded
+// files the parser backend failed on are included.
+func parseErrorFiles(files []*sppb.File) (failed []*sppb.File) {
+	for _, file := range files {
+		if file.Error != "" {
+			failed = append(failed, file)
+		}
+	}
+	return
+}
+
 // repoRootDir return the root directory of the repo.
 func (s *scalaPackage) repoRootDir() string {
 	return s.cfg.Config().RepoRoot
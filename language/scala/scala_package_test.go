@@ -1,18 +1,27 @@
 package scala
 
 import (
+	"flag"
+	"io"
 	"os"
 	"testing"
 
 	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/language"
 	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/bazelbuild/bazel-gazelle/testtools"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/mock"
 
 	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+	"github.com/stackb/scala-gazelle/pkg/parser"
+	"github.com/stackb/scala-gazelle/pkg/provider"
 	"github.com/stackb/scala-gazelle/pkg/resolver/mocks"
 	"github.com/stackb/scala-gazelle/pkg/scalaconfig"
+	"github.com/stackb/scala-gazelle/pkg/scalarule"
 )
 
 func TestScalaPackageParseRule(t *testing.T) {
@@ -64,3 +73,402 @@ func TestScalaPackageParseRule(t *testing.T) {
 		})
 	}
 }
+
+func TestScalaPackagePartitionSrcs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives   []rule.Directive
+		filenames    []string
+		wantSrcs     []string
+		wantTestSrcs []string
+	}{
+		"degenerate": {},
+		"non-scala files are ignored": {
+			filenames: []string{"BUILD.bazel", "Foo.java", "README.md"},
+		},
+		"default test patterns": {
+			filenames:    []string{"FooSpec.scala", "Foo.scala", "BarTest.scala", "Bar.scala", "BazSuite.scala"},
+			wantSrcs:     []string{"Bar.scala", "Foo.scala"},
+			wantTestSrcs: []string{"BarTest.scala", "BazSuite.scala", "FooSpec.scala"},
+		},
+		"custom test patterns": {
+			directives: []rule.Directive{
+				{Key: "scala_test_file_patterns", Value: "*IT.scala"},
+			},
+			filenames:    []string{"FooIT.scala", "FooTest.scala"},
+			wantSrcs:     []string{"FooTest.scala"},
+			wantTestSrcs: []string{"FooIT.scala"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "", tc.directives...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pkg := scalaPackage{cfg: cfg}
+
+			gotSrcs, gotTestSrcs := pkg.partitionSrcs(tc.filenames)
+
+			if diff := cmp.Diff(tc.wantSrcs, gotSrcs); diff != "" {
+				t.Errorf("srcs (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantTestSrcs, gotTestSrcs); diff != "" {
+				t.Errorf("test srcs (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScalaPackageScalaSrcs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives []rule.Directive
+		files      []testtools.FileSpec
+		rel        string
+		regular    []string
+		subdirs    []string
+		want       []string
+	}{
+		"directory": {
+			files: []testtools.FileSpec{
+				{Path: "src/A.scala"},
+				{Path: "src/sub/B.scala"},
+			},
+			rel:     "src",
+			regular: []string{"A.scala", "README.md"},
+			subdirs: []string{"sub"},
+			want:    []string{"A.scala"},
+		},
+		"package": {
+			directives: []rule.Directive{
+				{Key: "scala_granularity", Value: "package"},
+			},
+			files: []testtools.FileSpec{
+				{Path: "src/A.scala"},
+				{Path: "src/sub/B.scala"},
+				{Path: "src/sub/deeper/C.scala"},
+				{Path: "src/other/BUILD.bazel"},
+				{Path: "src/other/D.scala"},
+			},
+			rel:     "src",
+			regular: []string{"A.scala"},
+			subdirs: []string{"other", "sub"},
+			want:    []string{"A.scala", "sub/B.scala", "sub/deeper/C.scala"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, cleanup := testtools.CreateFiles(t, tc.files)
+			defer cleanup()
+
+			cfg, err := NewTestScalaConfig(t, mocks.NewUniverse(t), tc.rel, tc.directives...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg.Config().RepoRoot = dir
+
+			pkg := scalaPackage{
+				cfg: cfg,
+				args: language.GenerateArgs{
+					Rel:          tc.rel,
+					RegularFiles: tc.regular,
+					Subdirs:      tc.subdirs,
+				},
+			}
+
+			got := pkg.scalaSrcs()
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScalaPackageIsStaleFileRule(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives []rule.Directive
+		srcs       []string
+		want       bool
+	}{
+		"directory granularity": {
+			srcs: []string{"Missing.scala"},
+			want: false,
+		},
+		"file exists": {
+			directives: []rule.Directive{
+				{Key: "scala_granularity", Value: "file"},
+			},
+			srcs: []string{"A.scala"},
+			want: false,
+		},
+		"file missing": {
+			directives: []rule.Directive{
+				{Key: "scala_granularity", Value: "file"},
+			},
+			srcs: []string{"Missing.scala"},
+			want: true,
+		},
+		"multiple srcs": {
+			directives: []rule.Directive{
+				{Key: "scala_granularity", Value: "file"},
+			},
+			srcs: []string{"A.scala", "Missing.scala"},
+			want: false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, cleanup := testtools.CreateFiles(t, []testtools.FileSpec{
+				{Path: "src/A.scala"},
+			})
+			defer cleanup()
+
+			cfg, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "src", tc.directives...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg.Config().RepoRoot = dir
+
+			pkg := scalaPackage{
+				cfg:  cfg,
+				args: language.GenerateArgs{Rel: "src"},
+			}
+			rc := &scalarule.Config{
+				Provider: &existingScalaRuleProvider{name: "scala_library", isLibrary: true},
+			}
+			r := rule.NewRule("scala_library", "A_scala")
+			r.SetAttr("srcs", tc.srcs)
+
+			got := pkg.isStaleFileRule(rc, r)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScalaPackageApplyParseErrorPolicy(t *testing.T) {
+	files := []*sppb.File{
+		{Filename: "src/A.scala"},
+		{Filename: "src/Bad.scala", Error: "syntax error"},
+	}
+
+	for name, tc := range map[string]struct {
+		directives []rule.Directive
+		want       []*sppb.File
+	}{
+		"keep_existing": {},
+		"warn": {
+			directives: []rule.Directive{
+				{Key: "scala_parse_error_policy", Value: "warn"},
+			},
+		},
+		"fail": {
+			directives: []rule.Directive{
+				{Key: "scala_parse_error_policy", Value: "fail"},
+			},
+			want: []*sppb.File{
+				{Filename: "src/Bad.scala", Error: "syntax error"},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "src", tc.directives...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pkg := scalaPackage{cfg: cfg}
+
+			pkg.applyParseErrorPolicy(label.Label{Pkg: "src", Name: "lib"}, &sppb.Rule{Files: files})
+
+			if diff := cmp.Diff(tc.want, pkg.ParseErrors(), cmpopts.IgnoreUnexported(sppb.File{})); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestScalaPackageParseErrorPolicyWithFallback asserts that the parse error
+// policy applies to files recovered by the lexer fallback.
+func TestScalaPackageParseErrorPolicyWithFallback(t *testing.T) {
+	for name, tc := range map[string]struct {
+		args           []string
+		directives     []rule.Directive
+		wantDegraded   bool
+		wantParseError []string
+	}{
+		"fallback and fail": {
+			args: []string{"-scala_parser_fallback=true"},
+			directives: []rule.Directive{
+				{Key: "scala_parse_error_policy", Value: "fail"},
+			},
+			wantDegraded:   true,
+			wantParseError: []string{"src/A.scala"},
+		},
+		"fallback and warn": {
+			args: []string{"-scala_parser_fallback=true"},
+			directives: []rule.Directive{
+				{Key: "scala_parse_error_policy", Value: "warn"},
+			},
+			wantDegraded: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, cleanup := testtools.CreateFiles(t, []testtools.FileSpec{
+				{Path: "src/A.scala", Content: "package com.foo\nimport com.bar.Baz\nclass A\n"},
+			})
+			defer cleanup()
+
+			// the parser backend cannot be started, so every file fails
+			sourceProvider := provider.NewSourceProvider(zerolog.New(io.Discard), nil)
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			sourceProvider.RegisterFlags(fs, "update", config.New())
+			if err := fs.Parse(append([]string{
+				"-scala_parser_backend=command",
+				"-scala_parser_command=/nonexistent/parser",
+			}, tc.args...)); err != nil {
+				t.Fatal(err)
+			}
+			defer sourceProvider.OnResolve()
+
+			cfg, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "src", tc.directives...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg.Config().RepoRoot = dir
+			pkg := scalaPackage{
+				cfg:    cfg,
+				logger: zerolog.New(io.Discard),
+				parser: sourceProvider,
+				args:   language.GenerateArgs{Rel: "src"},
+			}
+
+			r := rule.NewRule("scala_library", "lib")
+			r.SetAttr("srcs", []string{"A.scala"})
+			got, err := pkg.ParseRule(r, "srcs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := got.Files()
+			if len(files) != 1 {
+				t.Fatalf("want 1 file, got %d", len(files))
+			}
+			if diff := cmp.Diff(tc.wantDegraded, files[0].Degraded); diff != "" {
+				t.Errorf("degraded (-want +got):\n%s", diff)
+			}
+
+			var gotParseErrors []string
+			for _, file := range pkg.ParseErrors() {
+				gotParseErrors = append(gotParseErrors, file.Filename)
+			}
+			if diff := cmp.Diff(tc.wantParseError, gotParseErrors); diff != "" {
+				t.Errorf("parse errors (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// batchParser is a parser.RulesParser that records the labels of the rules it
+// is asked to parse, per call.
+type batchParser struct {
+	calls [][]string
+}
+
+func (p *batchParser) LoadScalaRule(from label.Label, rule *sppb.Rule) error {
+	return nil
+}
+
+func (p *batchParser) ParseScalaRule(kind string, from label.Label, dialect, dir string, srcs ...string) (*sppb.Rule, error) {
+	return p.ParseScalaRules([]*parser.RuleRequest{{Kind: kind, From: from, Dialect: dialect, Dir: dir, Srcs: srcs}})[0].Rule, nil
+}
+
+func (p *batchParser) ParseScalaRules(requests []*parser.RuleRequest) []*parser.RuleResult {
+	var call []string
+	results := make([]*parser.RuleResult, len(requests))
+	for i, req := range requests {
+		call = append(call, req.From.String())
+		rule := &sppb.Rule{Label: req.From.String(), Kind: req.Kind}
+		for _, src := range req.Srcs {
+			rule.Files = append(rule.Files, &sppb.File{Filename: src})
+		}
+		results[i] = &parser.RuleResult{Rule: rule}
+	}
+	p.calls = append(p.calls, call)
+	return results
+}
+
+func TestScalaPackageParseRules(t *testing.T) {
+	for name, tc := range map[string]struct {
+		srcs      map[string][]string
+		wantCalls [][]string
+	}{
+		"single rule is parsed when resolved": {
+			srcs: map[string][]string{
+				"a": {"A.scala"},
+			},
+			wantCalls: [][]string{{"//src:a"}},
+		},
+		"rules are parsed together": {
+			srcs: map[string][]string{
+				"a": {"A.scala"},
+				"b": {"B.scala", "C.java"},
+			},
+			wantCalls: [][]string{{"//src:a", "//src:b"}},
+		},
+		"rules without srcs are skipped": {
+			srcs: map[string][]string{
+				"a": {"A.scala"},
+				"b": {"BUILD.bazel"},
+				"c": {"C.scala"},
+			},
+			wantCalls: [][]string{{"//src:a", "//src:c"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "src")
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := &batchParser{}
+			pkg := scalaPackage{
+				cfg:    cfg,
+				logger: zerolog.New(io.Discard),
+				parser: p,
+				args:   language.GenerateArgs{Rel: "src"},
+			}
+
+			var rules []*rule.Rule
+			for _, name := range []string{"a", "b", "c"} {
+				if srcs, ok := tc.srcs[name]; ok {
+					r := rule.NewRule("scala_library", name)
+					r.SetAttr("srcs", srcs)
+					rules = append(rules, r)
+				}
+			}
+
+			pkg.parseRules("srcs", rules)
+			for _, r := range rules {
+				got, err := pkg.ParseRule(r, "srcs")
+				if err == ErrRuleHasNoSrcs {
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(r.AttrStrings("srcs"), fileNames(got.Files())); diff != "" {
+					t.Errorf("%s files (-want +got):\n%s", r.Name(), diff)
+				}
+			}
+
+			if diff := cmp.Diff(tc.wantCalls, p.calls); diff != "" {
+				t.Errorf("calls (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func fileNames(files []*sppb.File) []string {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.Filename
+	}
+	return names
+}
@@ -107,7 +107,7 @@ func (r *scalaRule) ResolveExports(rctx *scalarule.ResolveContext) resolver.Impo
 		if symbol, ok := r.ResolveSymbol(rctx.Config, rctx.RuleIndex, rctx.From, scalaLangName, imp.Imp); ok {
 			imp.Symbol = symbol
 		} else {
-			r.logger.Print(r.pb.Label + ": unresolved export: " + imp.Imp)
+			r.logger.Print(importMessage(imp, r.pb.Label+": unresolved export: "+imp.Imp))
 			imp.Error = resolver.ErrSymbolNotFound
 		}
 	}
@@ -115,6 +115,33 @@ func (r *scalaRule) ResolveExports(rctx *scalarule.ResolveContext) resolver.Impo
 	return exports
 }
 
+// ResolveRuntimeImports performs symbol resolution for the given runtime
+// imports of the rule.  Imports that resolve to the rule itself are discarded.
+func (r *scalaRule) ResolveRuntimeImports(rctx *scalarule.ResolveContext, imports resolver.ImportMap) resolver.ImportMap {
+	sc := scalaconfig.Get(rctx.Config)
+	resolved := resolver.NewImportMap()
+	putImport := resolver.PutImportIfNotSelf(resolved, rctx.From)
+
+	for _, imp := range imports.Values() {
+		if symbol, ok := r.ResolveSymbol(rctx.Config, rctx.RuleIndex, rctx.From, scalaLangName, imp.Imp); ok {
+			imp.Symbol = symbol
+			if len(imp.Symbol.Conflicts) > 0 {
+				if sym, ok := sc.ResolveConflict(rctx.Rule, rctx.From, imports, imp, imp.Symbol); ok {
+					imp.Symbol = sym
+				} else {
+					r.logger.Warn().Msg(resolver.SymbolConfictMessage(imp.Symbol, imp, rctx.From))
+				}
+			}
+		} else {
+			r.logger.Print(importMessage(imp, r.pb.Label+": unresolved runtime import: "+imp.Imp))
+			imp.Error = resolver.ErrSymbolNotFound
+		}
+		putImport(imp)
+	}
+
+	return resolved
+}
+
 // ResolveImports performs symbol resolution for imports of the rule.
 func (r *scalaRule) ResolveImports(rctx *scalarule.ResolveContext) resolver.ImportMap {
 	imports := r.Imports(rctx.From)
@@ -127,7 +154,7 @@ func (r *scalaRule) ResolveImports(rctx *scalarule.ResolveContext) resolver.Impo
 		if symbol, ok := r.ResolveSymbol(rctx.Config, rctx.RuleIndex, rctx.From, scalaLangName, imp.Imp); ok {
 			imp.Symbol = symbol
 			if len(imp.Symbol.Conflicts) > 0 {
-				if resolved, ok := sc.ResolveConflict(rctx.Rule, imports, imp, imp.Symbol); ok {
+				if resolved, ok := sc.ResolveConflict(rctx.Rule, rctx.From, imports, imp, imp.Symbol); ok {
 					imp.Symbol = resolved
 					r.logger.Debug().
 						Msgf("conflict resolved import %s to %v", imp.Imp, resolved)
@@ -143,7 +170,7 @@ func (r *scalaRule) ResolveImports(rctx *scalarule.ResolveContext) resolver.Impo
 					Msgf("resolved unconflicted import %s to %v", imp.Imp, symbol)
 			}
 		} else {
-			r.logger.Print(r.pb.Label + ": unresolved import: " + imp.Imp)
+			r.logger.Print(importMessage(imp, r.pb.Label+": unresolved import: "+imp.Imp))
 			imp.Error = resolver.ErrSymbolNotFound
 		}
 	}
@@ -236,6 +263,7 @@ func (r *scalaRule) fileExports(file *sppb.File, exports resolver.ImportMap, fro
 		}
 
 		name := parts[1] // note: parts[0] is the 'kind'
+		location := resolver.Location(file, file.DefinitionPositions[name])
 
 		// assume the name is fully-qualified so resolve it from the "root"
 		// scope rather than involving package scopes.
@@ -245,7 +273,7 @@ func (r *scalaRule) fileExports(file *sppb.File, exports resolver.ImportMap, fro
 			if sym, ok := scope.GetSymbol(imp); ok {
 				// if the symbol has conflicts, don't export it
 				if len(sym.Conflicts) > 0 {
-					r.logger.Print(r.warnf("%s: %q extends %q, but symbol %q is conflicted", file.Filename, name, imp, imp))
+					r.logger.Print(r.warnf("%s: %q extends %q, but symbol %q is conflicted", location, name, imp, imp))
 				} else {
 					putExport(resolver.NewExtendsImport(sym.Name, file, name, sym))
 					if resolvedOK && resolved != sym {
@@ -254,11 +282,108 @@ func (r *scalaRule) fileExports(file *sppb.File, exports resolver.ImportMap, fro
 				}
 			} else {
 				putExport(resolver.NewExtendsImport(imp, file, name, nil))
-				r.logger.Print(r.warnf("%s: %q extends %q, but symbol %q is unknown", file.Filename, name, imp, imp))
+				r.logger.Print(r.warnf("%s: %q extends %q, but symbol %q is unknown", location, name, imp, imp))
+			}
+		}
+	}
+
+	// types named in the signatures of public members, if enabled.  Unlike
+	// extends clauses, these only include symbols known to the scope, as
+	// semanticdb routinely names types from the standard library and the jdk.
+	if r.ctx.scalaConfig.ShouldExportSignatures() {
+		for _, name := range file.SemanticExports {
+			sym, ok := r.ctx.scope.GetSymbol(name)
+			if !ok {
+				continue
+			}
+			if len(sym.Conflicts) > 0 {
+				r.logger.Print(r.warnf("%s: public signature type %q is conflicted", file.Filename, name))
+				continue
 			}
+			imp := resolver.NewSemanticImport(name, file)
+			imp.Symbol = sym
+			putExport(imp)
+		}
+	}
+
+	// names in (scala 3) export clauses are re-exported by the rule.
+	for _, name := range file.Exports {
+		sym, ok := r.exportClauseSymbol(name)
+		if !ok {
+			continue
+		}
+		if len(sym.Conflicts) > 0 {
+			r.logger.Print(r.warnf("%s: export clause symbol %q is conflicted", resolver.Location(file, file.ImportPositions[name]), name))
+			continue
+		}
+		putExport(resolver.NewExportImport(sym.Name, file, name, sym))
+	}
+}
+
+// resolveRelativeImport returns the fully-qualified form of the given import
+// as written in the file.  Imports that are known to the scope are returned
+// as-is.  Otherwise the import may be relative, so it is tried against the
+// prefixes given by relativeImportPrefixes.  If none match, the original
+// import is returned.
+func (r *scalaRule) resolveRelativeImport(file *sppb.File, imp string) string {
+	if wimp, ok := resolver.IsWildcardImport(imp); ok {
+		if _, ok := r.ctx.scope.GetScope(wimp); ok {
+			return imp
+		}
+	}
+	if _, ok := r.ctx.scope.GetSymbol(imp); ok {
+		return imp
+	}
+	if resolved, ok := resolver.ResolveRelativeImport(r.ctx.scope, imp, relativeImportPrefixes(file, imp)); ok {
+		r.logger.Debug().Msgf("%s: resolved relative import %s as %s", file.Filename, imp, resolved)
+		return resolved
+	}
+	return imp
+}
+
+// relativeImportPrefixes returns the list of prefixes that the given import
+// may be relative to, in order of precedence: explicit imports that name the
+// first segment of the import, wildcard imports, the enclosing packages
+// (innermost first) and finally the implicit 'scala' and 'java.lang' root
+// imports.
+func relativeImportPrefixes(file *sppb.File, imp string) []string {
+	first := importFirstSegment(imp)
+
+	var explicit, wildcard []string
+	for _, other := range file.Imports {
+		if other == imp {
+			continue
+		}
+		if wimp, ok := resolver.IsWildcardImport(other); ok {
+			wildcard = append(wildcard, wimp)
+			continue
+		}
+		if i := strings.LastIndex(other, "."); i != -1 && other[i+1:] == first {
+			explicit = append(explicit, other[:i])
 		}
 	}
 
+	packages := make([]string, len(file.Packages))
+	copy(packages, file.Packages)
+	sort.SliceStable(packages, func(i, j int) bool {
+		return len(packages[i]) > len(packages[j])
+	})
+
+	prefixes := make([]string, 0, len(explicit)+len(wildcard)+len(packages)+2)
+	prefixes = append(prefixes, explicit...)
+	prefixes = append(prefixes, wildcard...)
+	prefixes = append(prefixes, packages...)
+	prefixes = append(prefixes, "scala", "java.lang")
+	return prefixes
+}
+
+// exportClauseSymbol resolves a name from an export clause.  For wildcard
+// exports such as 'com.foo.Bar._' the symbol of the qualifier is returned.
+func (r *scalaRule) exportClauseSymbol(name string) (*resolver.Symbol, bool) {
+	if wimp, ok := resolver.IsWildcardImport(name); ok {
+		name = wimp
+	}
+	return r.ctx.scope.GetSymbol(name)
 }
 
 // fileSemanticImports gathers needed semantic imports for the given file.
@@ -284,8 +409,33 @@ func (r *scalaRule) fileImports(imports resolver.ImportMap, file *sppb.File, fro
 
 	putImport := resolver.PutImportIfNotSelf(imports, from)
 
+	// renamed selectors bind the alias rather than the original name in the
+	// file scope.  Hidden selectors are never imported, so names that resolve
+	// to them are not imported.  They are keyed by qualifier: 'import
+	// a.{X => _, _}' hides 'a.X', but not the 'X' of another import.
+	aliased := resolver.NewTrieScope()
+	aliases := make(map[string]string)
+	hidden := make(map[hiddenSelector]bool)
+	for _, sel := range file.ImportSelectors {
+		if sel.Hidden {
+			imp := r.resolveRelativeImport(file, sel.Imp)
+			hidden[hiddenSelector{qualifier: importParent(imp), name: importBasename(imp)}] = true
+		} else if sel.Alias != "" {
+			aliases[sel.Imp] = sel.Alias
+		}
+	}
+
+	// in 'names' mode, wildcard imports of packages are resolved after the
+	// explicit imports of the file are known.
+	preciseWildcards := r.ctx.scalaConfig.WildcardImports() == scalaconfig.WildcardImportsNames
+	var wildcards []*packageWildcardImport
+
 	// gather direct imports and import scopes
-	for _, name := range file.Imports {
+	for _, written := range file.Imports {
+		position := file.ImportPositions[written]
+		location := resolver.Location(file, position)
+		name := r.resolveRelativeImport(file, written)
+
 		if wimp, ok := resolver.IsWildcardImport(name); ok {
 			r.logger.Debug().Msgf("isWildcardImport true %s: %s", name, file.Filename)
 			if r.ctx.scalaConfig.ShouldFixWildcardImport(file.Filename, name) {
@@ -296,34 +446,59 @@ func (r *scalaRule) fileImports(imports resolver.ImportMap, file *sppb.File, fro
 				for _, symName := range symbolNames {
 					fqn := wimp + "." + symName
 					if sym, ok := r.ctx.scope.GetSymbol(fqn); ok {
-						putImport(resolver.NewResolvedNameImport(sym.Name, file, fqn, sym))
+						imp := resolver.NewResolvedNameImport(sym.Name, file, fqn, sym)
+						imp.Position = position
+						putImport(imp)
 					} else {
-						r.logger.Printf("%s: warning: unresolved fix wildcard import: symbol %q: was not found' (%s)", r.pb.Label, name, file.Filename)
+						r.logger.Printf("%s: warning: unresolved fix wildcard import: symbol %q: was not found' (%s)", r.pb.Label, name, location)
 					}
 				}
 			}
 
 			// collect the (package) symbol for import
-			if sym, ok := r.ctx.scope.GetSymbol(name); ok {
-				// log.Printf("%v: WARN: resolved name import: %v %v %v", from, sym.Name, file.Filename, sym.Label)
-				putImport(resolver.NewResolvedNameImport(sym.Name, file, name, sym))
-			} else {
-				r.logger.Printf("%s: warning: unresolved wildcard import: symbol %q: was not found' (%s)", r.pb.Label, name, file.Filename)
-				imp := resolver.NewDirectImport(name, file)
-				putImport(imp)
+			sym, symOK := r.ctx.scope.GetSymbol(name)
+			putWildcardImport := func() {
+				if symOK {
+					// log.Printf("%v: WARN: resolved name import: %v %v %v", from, sym.Name, file.Filename, sym.Label)
+					imp := resolver.NewResolvedNameImport(sym.Name, file, name, sym)
+					imp.Position = position
+					putImport(imp)
+				} else {
+					r.logger.Printf("%s: warning: unresolved wildcard import: symbol %q: was not found' (%s)", r.pb.Label, name, location)
+					imp := resolver.NewDirectImport(name, file)
+					imp.Position = position
+					putImport(imp)
+				}
 			}
 
 			// collect the scope
-			if scope, ok := r.ctx.scope.GetScope(wimp); ok {
+			scope, scopeOK := r.ctx.scope.GetScope(wimp)
+			if scopeOK {
 				scopes = append(scopes, scope)
 			} else if debugNameNotFound {
 				log.Printf("%s | warning: wildcard import scope not found: %s", r.pb.Label, wimp)
 			}
+
+			if preciseWildcards && scopeOK && (!symOK || isPackageSymbol(sym)) {
+				wildcards = append(wildcards, &packageWildcardImport{
+					name:     name,
+					scope:    scope,
+					position: position,
+					fallback: putWildcardImport,
+				})
+			} else {
+				putWildcardImport()
+			}
 		} else {
 			imp := resolver.NewDirectImport(name, file)
+			imp.Position = position
 			if sym, ok := r.ctx.scope.GetSymbol(name); ok {
 				imp.Symbol = sym
-				direct.Put(importBasename(name), sym)
+				if alias, ok := aliases[written]; ok {
+					aliased.Put(alias, sym)
+				} else {
+					direct.Put(importBasename(name), sym)
+				}
 			} else if debugNameNotFound {
 				log.Printf("%s | warning: direct symbol not found: %s", r.pb.Label, name)
 			}
@@ -331,17 +506,47 @@ func (r *scalaRule) fileImports(imports resolver.ImportMap, file *sppb.File, fro
 		}
 	}
 
+	// resolve the names used in the file against the wildcard imports of
+	// packages.  If none of the names resolve (e.g. only implicits are used),
+	// the package itself is imported.
+	if len(wildcards) > 0 {
+		shadowed := explicitlyBoundNames(file, aliases)
+		for _, wildcard := range wildcards {
+			imps := wildcardNameImports(file, wildcard, shadowed, hidden)
+			if len(imps) == 0 {
+				wildcard.fallback()
+				continue
+			}
+			for _, imp := range imps {
+				putImport(imp)
+			}
+		}
+	}
+
+	// names in export clauses must also be compiled against
+	for _, name := range file.Exports {
+		if sym, ok := r.exportClauseSymbol(name); ok {
+			putImport(resolver.NewExportImport(sym.Name, file, name, sym))
+		} else if debugNameNotFound {
+			log.Printf("%s | warning: export clause symbol not found: %s", r.pb.Label, name)
+		}
+	}
+
 	// gather package scopes
+	var packageScopes []resolver.Scope
 	for _, pkg := range file.Packages {
 		if scope, ok := r.ctx.scope.GetScope(pkg); ok {
-			scopes = append(scopes, scope)
+			packageScopes = append(packageScopes, scope)
 		} else if debugNameNotFound {
 			log.Printf("%s | warning: package scope not found: %s", r.pb.Label, pkg)
 		}
 	}
+	scopes = append(scopes, packageScopes...)
 
 	// add in outer scope
 	scopes = append(scopes, r.ctx.scope, direct)
+	// aliases shadow all other names in the file
+	scopes = append([]resolver.Scope{aliased}, scopes...)
 	// build final scope used to resolve names in the file.
 	scope := resolver.NewChainScope(scopes...)
 
@@ -361,12 +566,13 @@ func (r *scalaRule) fileImports(imports resolver.ImportMap, file *sppb.File, fro
 
 		// kind := parts[0]
 		name := parts[1]
+		location := resolver.Location(file, file.DefinitionPositions[name])
 
 		// assume the name if fully-qualified, so resolve it from the "root"
 		// scope rather than involving package scopes.
 		resolved, resolvedOK := r.ctx.scope.GetSymbol(name)
 		if !resolvedOK {
-			r.logger.Print(r.warnf("%s: extends symbol not found: %s", file.Filename, name))
+			r.logger.Print(r.warnf("%s: extends symbol not found: %s", location, name))
 		}
 
 		for _, imp := range extends.Classes {
@@ -376,7 +582,7 @@ func (r *scalaRule) fileImports(imports resolver.ImportMap, file *sppb.File, fro
 					resolved.Require(sym)
 				}
 			} else {
-				r.logger.Print(r.warnf("%s: extends symbol not found: %s", file.Filename, imp))
+				r.logger.Print(r.warnf("%s: extends symbol not found: %s", location, imp))
 
 				if debugExtendsNameNotFound {
 					putImport(resolver.NewExtendsImport(imp, file, name, nil))
@@ -385,6 +591,30 @@ func (r *scalaRule) fileImports(imports resolver.ImportMap, file *sppb.File, fro
 		}
 	}
 
+	// resolve names in the file.  Names matching a resolve_file_symbol_name
+	// directive are resolved against the full file scope.  In 'file'
+	// granularity mode, names that resolve in the package scope(s) of the file
+	// are also included, as symbols defined in the same package need no import
+	// statement but are likely provided by a different rule.
+	isFileGranularity := r.ctx.scalaConfig.Granularity() == scalaconfig.GranularityFile
+	packageScope := resolver.NewChainScope(packageScopes...)
+	for _, name := range file.Names {
+		if r.ctx.scalaConfig.ShouldResolveFileSymbolName(file.Filename, name) {
+			if sym, ok := scope.GetSymbol(name); ok {
+				if !isHiddenSymbol(hidden, sym.Name) {
+					putImport(resolver.NewResolvedNameImport(sym.Name, file, name, sym))
+				}
+			} else if debugNameNotFound {
+				log.Printf("%s | warning: name not found: %s", r.pb.Label, name)
+			}
+			continue
+		}
+		if isFileGranularity {
+			if sym, ok := packageScope.GetSymbol(name); ok && !isHiddenSymbol(hidden, sym.Name) {
+				putImport(resolver.NewResolvedNameImport(sym.Name, file, name, sym))
+			}
+		}
+	}
 }
 
 // Files implements part of the scalarule.Rule interface.
@@ -424,6 +654,15 @@ func (r *scalaRule) putExports(file *sppb.File) {
 	for _, imp := range file.Vals {
 		r.putExport(imp)
 	}
+	for _, imp := range file.Enums {
+		r.putExport(imp)
+	}
+	for _, imp := range file.Givens {
+		r.putExport(imp)
+	}
+	for _, imp := range file.Defs {
+		r.putExport(imp)
+	}
 }
 
 func (r *scalaRule) putExport(imp string) {
@@ -475,6 +714,15 @@ func (r *scalaRule) printf(level, format string, args ...any) string {
 	return fmt.Sprintf(level+" ["+r.ctx.scalaConfig.Rel()+": "+format, args...)
 }
 
+// importMessage prefixes the given message with the source location of the
+// import, if known.
+func importMessage(imp *resolver.Import, message string) string {
+	if location := imp.Location(); location != "" {
+		return location + ": " + message
+	}
+	return message
+}
+
 func ImportsPutIfNotSelfImport(imports resolver.ImportMap, repo, rel, ruleName string) func(*resolver.Import) {
 	return func(imp *resolver.Import) {
 		if !resolver.IsSelfImport(imp.Symbol, repo, rel, ruleName) {
@@ -487,6 +735,115 @@ func isBinaryRule(kind string) bool {
 	return strings.Contains(kind, "binary") || strings.Contains(kind, "test")
 }
 
+// packageWildcardImport represents a wildcard import of a package, such as
+// 'import com.foo.model._', that is resolved by the names used in the file.
+type packageWildcardImport struct {
+	// name is the import, as resolved from what was written.
+	name string
+	// scope is the scope of the package.
+	scope resolver.Scope
+	// position is the position of the import statement.
+	position *sppb.Position
+	// fallback puts the import of the package itself.
+	fallback func()
+}
+
+// hiddenSelector identifies a hidden import selector: 'import a.{X => _}'
+// has qualifier 'a' and name 'X'.
+type hiddenSelector struct {
+	qualifier, name string
+}
+
+// isHiddenSymbol tests whether the given fully-qualified symbol name, or one
+// of its enclosing names, is hidden by an import selector.
+func isHiddenSymbol(hidden map[hiddenSelector]bool, name string) bool {
+	for name != "" {
+		if hidden[hiddenSelector{qualifier: importParent(name), name: importBasename(name)}] {
+			return true
+		}
+		name = importParent(name)
+	}
+	return false
+}
+
+// wildcardNameImports returns a list of imports for the names used in the
+// given file that resolve in the scope of the wildcard import.  Names bound
+// otherwise (shadowed) or hidden by a selector of the wildcard import are
+// skipped, as are names that resolve to packages.
+func wildcardNameImports(file *sppb.File, wildcard *packageWildcardImport, shadowed map[string]bool, hidden map[hiddenSelector]bool) []*resolver.Import {
+	qualifier, _ := resolver.IsWildcardImport(wildcard.name)
+	var imps []*resolver.Import
+	seen := make(map[string]bool)
+	for _, name := range file.Names {
+		first := importFirstSegment(name)
+		if shadowed[first] || hidden[hiddenSelector{qualifier: qualifier, name: first}] {
+			continue
+		}
+		sym, ok := wildcard.scope.GetSymbol(name)
+		if !ok || isPackageSymbol(sym) || seen[sym.Name] {
+			continue
+		}
+		seen[sym.Name] = true
+		imp := resolver.NewResolvedNameImport(sym.Name, file, wildcard.name, sym)
+		imp.Position = wildcard.position
+		imps = append(imps, imp)
+	}
+	return imps
+}
+
+// explicitlyBoundNames returns the set of simple names that are bound in the
+// given file by explicit (non-wildcard) imports or by definitions in the file.
+// These take precedence over names from wildcard imports.
+func explicitlyBoundNames(file *sppb.File, aliases map[string]string) map[string]bool {
+	names := make(map[string]bool)
+	for _, imp := range file.Imports {
+		if _, ok := resolver.IsWildcardImport(imp); ok {
+			continue
+		}
+		if alias, ok := aliases[imp]; ok {
+			names[alias] = true
+		} else {
+			names[importBasename(imp)] = true
+		}
+	}
+	for _, list := range [][]string{
+		file.Classes,
+		file.Objects,
+		file.Traits,
+		file.Types,
+		file.Vals,
+		file.Enums,
+		file.Givens,
+	} {
+		for _, name := range list {
+			names[importBasename(name)] = true
+		}
+	}
+	return names
+}
+
+// isPackageSymbol returns true if the given symbol is a (proto) package.
+func isPackageSymbol(sym *resolver.Symbol) bool {
+	return sym.Type == sppb.ImportType_PACKAGE || sym.Type == sppb.ImportType_PROTO_PACKAGE
+}
+
+func importFirstSegment(imp string) string {
+	if index := strings.Index(imp, "."); index != -1 {
+		return imp[:index]
+	}
+	return imp
+}
+
+// importParent returns the qualifier of the given name, or the empty string
+// if it has none.
+func importParent(imp string) string {
+	index := strings.LastIndex(imp, ".")
+	if index == -1 {
+		return ""
+	}
+	return imp[:index]
+}
+
 func importBasename(imp string) string {
 	index := strings.LastIndex(imp, ".")
 	if index == -1 {
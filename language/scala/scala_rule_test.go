@@ -62,6 +62,24 @@ func TestScalaRuleExports(t *testing.T) {
 				makeImportSpec("com.foo.ValB"),
 			},
 		},
+		"scala 3 exports": {
+			rule: rule.NewRule("scala_library", "somelib"),
+			from: label.Label{Pkg: "com/foo", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Packages: []string{"com.foo"},
+					Enums:    []string{"com.foo.Color"},
+					Givens:   []string{"com.foo.intOrdering"},
+					Defs:     []string{"com.foo.greet"},
+				},
+			},
+			want: []resolve.ImportSpec{
+				makeImportSpec("com.foo.Color"),
+				makeImportSpec("com.foo.greet"),
+				makeImportSpec("com.foo.intOrdering"),
+			},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			universe := mocks.NewUniverse(t)
@@ -221,6 +239,90 @@ func TestScalaRuleImports(t *testing.T) {
 				`✅ com.foo.ClassB<> (IMPLICIT via "com.foo.ClassA")`,
 			},
 		},
+		"file granularity resolves same-package names": {
+			directives: []string{
+				"scala_granularity file",
+			},
+			globalSymbols: []*resolver.Symbol{
+				{
+					Type:     sppb.ImportType_OBJECT,
+					Name:     "com.foo.ToActorId",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/foo", Name: "Ids_scala"},
+				},
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "com.foo.User",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/foo", Name: "User_scala"},
+				},
+			},
+			rule: rule.NewRule("scala_library", "User_scala"),
+			from: label.Label{Pkg: "com/foo", Name: "User_scala"},
+			files: []*sppb.File{
+				{
+					Filename: "User.scala",
+					Packages: []string{"com.foo"},
+					Classes:  []string{"com.foo.User"},
+					Names:    []string{"String", "ToActorId", "User"},
+				},
+			},
+			want: []string{
+				`✅ com.foo.ToActorId<OBJECT> //com/foo:Ids_scala<source> (RESOLVED_NAME of User.scala via "ToActorId")`,
+			},
+		},
+		"names are not resolved by default": {
+			globalSymbols: []*resolver.Symbol{
+				{
+					Type:     sppb.ImportType_OBJECT,
+					Name:     "com.foo.ToActorId",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/foo", Name: "Ids_scala"},
+				},
+			},
+			rule: rule.NewRule("scala_library", "User_scala"),
+			from: label.Label{Pkg: "com/foo", Name: "User_scala"},
+			files: []*sppb.File{
+				{
+					Filename: "User.scala",
+					Packages: []string{"com.foo"},
+					Names:    []string{"ToActorId"},
+				},
+			},
+			want: []string{},
+		},
+		"resolve_file_symbol_name": {
+			directives: []string{
+				"resolve_file_symbol_name LogField.scala +ObjectAppendingMarker -MapEntriesAppendingMarker",
+			},
+			globalSymbols: []*resolver.Symbol{
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "net.logstash.logback.marker.ObjectAppendingMarker",
+					Provider: "java",
+					Label:    label.Label{Repo: "maven", Name: "logstash"},
+				},
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "net.logstash.logback.marker.MapEntriesAppendingMarker",
+					Provider: "java",
+					Label:    label.Label{Repo: "maven", Name: "logstash"},
+				},
+			},
+			rule: rule.NewRule("scala_library", "somelib"),
+			from: label.Label{Pkg: "com/foo", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "LogField.scala",
+					Imports:  []string{"net.logstash.logback.marker._"},
+					Names:    []string{"MapEntriesAppendingMarker", "ObjectAppendingMarker"},
+				},
+			},
+			want: []string{
+				"✅ net.logstash.logback.marker._<> (DIRECT of LogField.scala)",
+				`✅ net.logstash.logback.marker.ObjectAppendingMarker<CLASS> @maven//:logstash<java> (RESOLVED_NAME of LogField.scala via "ObjectAppendingMarker")`,
+			},
+		},
 		"transitive require - this is done later": {
 			globalSymbols: []*resolver.Symbol{
 				{
@@ -255,6 +357,213 @@ func TestScalaRuleImports(t *testing.T) {
 				`✅ com.foo.proto.FooMessage<CLASS> //proto:foo_proto_scala_library<protobuf> (EXTENDS of A.scala via "com.foo.ClassA")`,
 			},
 		},
+		"relative imports": {
+			globalSymbols: []*resolver.Symbol{
+				{
+					Type:     sppb.ImportType_OBJECT,
+					Name:     "example.MainContext",
+					Provider: "source",
+					Label:    label.Label{Pkg: "example", Name: "context"},
+				},
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "akka.actor.Actor",
+					Provider: "maven",
+					Label:    label.Label{Repo: "maven", Name: "akka_actor_akka_actor"},
+				},
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "scala.concurrent.duration.Duration",
+					Provider: "java",
+					Label:    label.Label{Repo: "maven", Name: "scala_library"},
+				},
+			},
+			rule: rule.NewRule("scala_library", "somelib"),
+			from: label.Label{Pkg: "example", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Packages: []string{"example"},
+					Imports:  []string{"MainContext._", "actor.Actor", "akka.actor", "concurrent.duration._"},
+				},
+			},
+			want: []string{
+				`✅ example.MainContext<OBJECT> //example:context<source> (RESOLVED_NAME of A.scala via "example.MainContext._")`,
+				`✅ akka.actor.Actor<CLASS> @maven//:akka_actor_akka_actor<maven> (DIRECT of A.scala)`,
+				"✅ akka.actor<> (DIRECT of A.scala)",
+				"✅ scala.concurrent.duration._<> (DIRECT of A.scala)",
+			},
+		},
+		"renamed and hidden import selectors": {
+			directives: []string{
+				"resolve_file_symbol_name A.scala +C +D",
+			},
+			globalSymbols: []*resolver.Symbol{
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "a.B",
+					Provider: "source",
+					Label:    label.Label{Pkg: "a", Name: "b"},
+				},
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "com.foo.C",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/foo", Name: "c"},
+				},
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "x.D",
+					Provider: "source",
+					Label:    label.Label{Pkg: "x", Name: "d"},
+				},
+			},
+			rule: rule.NewRule("scala_library", "somelib"),
+			from: label.Label{Pkg: "com/foo", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Packages: []string{"com.foo"},
+					Imports:  []string{"a.B", "x._"},
+					ImportSelectors: []*sppb.ImportSelector{
+						{Imp: "a.B", Name: "B", Alias: "C"},
+						{Imp: "x.D", Name: "D", Hidden: true},
+					},
+					Names: []string{"C", "D"},
+				},
+			},
+			want: []string{
+				"✅ a.B<CLASS> //a:b<source> (DIRECT of A.scala)",
+				"✅ x._<> (DIRECT of A.scala)",
+			},
+		},
+		"chained package clauses": {
+			globalSymbols: []*resolver.Symbol{
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "a.b.d.E",
+					Provider: "source",
+					Label:    label.Label{Pkg: "a/b/d", Name: "d"},
+				},
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "a.b.c.d.E",
+					Provider: "source",
+					Label:    label.Label{Pkg: "a/b/c/d", Name: "d"},
+				},
+			},
+			rule: rule.NewRule("scala_library", "somelib"),
+			from: label.Label{Pkg: "a/b/c", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Packages: []string{"a.b", "a.b.c"},
+					Imports:  []string{"d.E"},
+				},
+			},
+			want: []string{
+				`✅ a.b.c.d.E<CLASS> //a/b/c/d<source> (DIRECT of A.scala)`,
+			},
+		},
+		"wildcard import of a split package": {
+			globalSymbols: splitPackageSymbols,
+			rule:          rule.NewRule("scala_library", "somelib"),
+			from:          label.Label{Pkg: "com/foo", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Imports:  []string{"com.foo.model._"},
+					Names:    []string{"A"},
+				},
+			},
+			want: []string{
+				`✅ com.foo.model<PACKAGE> //com/foo/model/a<source> (RESOLVED_NAME of A.scala via "com.foo.model._")`,
+			},
+		},
+		"wildcard import of a split package resolved by names": {
+			directives:    []string{"scala_wildcard_imports names"},
+			globalSymbols: splitPackageSymbols,
+			rule:          rule.NewRule("scala_library", "somelib"),
+			from:          label.Label{Pkg: "com/foo", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Imports:  []string{"com.foo.model._", "com.foo.other.B"},
+					Classes:  []string{"com.foo.C"},
+					Names:    []string{"A", "A.apply", "B", "C", "Unknown"},
+				},
+			},
+			want: []string{
+				`✅ com.foo.other.B<CLASS> //com/foo/other<source> (DIRECT of A.scala)`,
+				`✅ com.foo.model.A<CLASS> //com/foo/model/a<source> (RESOLVED_NAME of A.scala via "com.foo.model._")`,
+			},
+		},
+		"wildcard import of a split package resolved by names falls back to the package": {
+			directives:    []string{"scala_wildcard_imports names"},
+			globalSymbols: splitPackageSymbols,
+			rule:          rule.NewRule("scala_library", "somelib"),
+			from:          label.Label{Pkg: "com/foo", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Imports:  []string{"com.foo.model._"},
+					Names:    []string{"Unknown"},
+				},
+			},
+			want: []string{
+				`✅ com.foo.model<PACKAGE> //com/foo/model/a<source> (RESOLVED_NAME of A.scala via "com.foo.model._")`,
+			},
+		},
+		"hidden selector only hides the name of its qualifier": {
+			directives:    []string{"scala_wildcard_imports names"},
+			globalSymbols: splitPackageSymbols,
+			rule:          rule.NewRule("scala_library", "somelib"),
+			from:          label.Label{Pkg: "com/foo", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Imports:  []string{"com.foo.model._", "com.foo.other._"},
+					ImportSelectors: []*sppb.ImportSelector{
+						{Imp: "com.foo.model.B", Name: "B", Hidden: true},
+						{Imp: "com.foo.model._"},
+					},
+					Names: []string{"B"},
+				},
+			},
+			want: []string{
+				`✅ com.foo.model<PACKAGE> //com/foo/model/a<source> (RESOLVED_NAME of A.scala via "com.foo.model._")`,
+				`✅ com.foo.other.B<CLASS> //com/foo/other<source> (RESOLVED_NAME of A.scala via "com.foo.other._")`,
+			},
+		},
+		"export clauses": {
+			globalSymbols: []*resolver.Symbol{
+				{
+					Type:     sppb.ImportType_OBJECT,
+					Name:     "com.bar.Codecs",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/bar", Name: "codecs"},
+				},
+				{
+					Type:     sppb.ImportType_ENUM,
+					Name:     "com.baz.Color",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/baz", Name: "color"},
+				},
+			},
+			rule: rule.NewRule("scala_library", "somelib"),
+			from: label.Label{Pkg: "com/foo", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Packages: []string{"com.foo"},
+					Exports:  []string{"com.bar.Codecs._", "com.baz.Color", "com.qux.Unknown"},
+				},
+			},
+			want: []string{
+				`✅ com.bar.Codecs<OBJECT> //com/bar:codecs<source> (EXPORT of A.scala via "com.bar.Codecs._")`,
+				`✅ com.baz.Color<ENUM> //com/baz:color<source> (EXPORT of A.scala via "com.baz.Color")`,
+			},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			universe := newMockGlobalScope(t, tc.globalSymbols)
@@ -294,6 +603,158 @@ func TestScalaRuleImports(t *testing.T) {
 	}
 }
 
+func TestScalaRuleResolvedExports(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives    []string
+		rule          *rule.Rule
+		from          label.Label
+		files         []*sppb.File
+		globalSymbols []*resolver.Symbol // list of symbols in global scope
+		want          []string
+	}{
+		"degenerate": {
+			rule: rule.NewRule("scala_library", "somelib"), // rule must not be nil
+			want: []string{},
+		},
+		"extends": {
+			globalSymbols: []*resolver.Symbol{
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "com.foo.ClassA",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/foo", Name: "somelib"},
+				},
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "akka.actor.Actor",
+					Provider: "maven",
+					Label:    label.Label{Repo: "maven", Name: "akka_actor_akka_actor"},
+				},
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "com.bar.Model",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/bar", Name: "model"},
+				},
+			},
+			rule: rule.NewRule("scala_library", "somelib"),
+			from: label.Label{Pkg: "com/foo", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Classes:  []string{"com.foo.ClassA"},
+					Extends: map[string]*sppb.ClassList{
+						"class com.foo.ClassA": {Classes: []string{"akka.actor.Actor"}},
+					},
+					SemanticExports: []string{"com.bar.Model", "com.foo.ClassA", "scala.Int"},
+				},
+			},
+			want: []string{
+				`✅ akka.actor.Actor<CLASS> @maven//:akka_actor_akka_actor<maven> (EXTENDS of A.scala via "com.foo.ClassA")`,
+			},
+		},
+		"extends and public signatures": {
+			directives: []string{
+				"scala_export_signatures true",
+			},
+			globalSymbols: []*resolver.Symbol{
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "com.foo.ClassA",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/foo", Name: "somelib"},
+				},
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "akka.actor.Actor",
+					Provider: "maven",
+					Label:    label.Label{Repo: "maven", Name: "akka_actor_akka_actor"},
+				},
+				{
+					Type:     sppb.ImportType_CLASS,
+					Name:     "com.bar.Model",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/bar", Name: "model"},
+				},
+			},
+			rule: rule.NewRule("scala_library", "somelib"),
+			from: label.Label{Pkg: "com/foo", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Classes:  []string{"com.foo.ClassA"},
+					Extends: map[string]*sppb.ClassList{
+						"class com.foo.ClassA": {Classes: []string{"akka.actor.Actor"}},
+					},
+					SemanticExports: []string{"com.bar.Model", "com.foo.ClassA", "scala.Int"},
+				},
+			},
+			want: []string{
+				`✅ akka.actor.Actor<CLASS> @maven//:akka_actor_akka_actor<maven> (EXTENDS of A.scala via "com.foo.ClassA")`,
+				`✅ com.bar.Model<CLASS> //com/bar:model<source> (SEMANTIC)`,
+			},
+		},
+		"export clauses": {
+			globalSymbols: []*resolver.Symbol{
+				{
+					Type:     sppb.ImportType_GIVEN,
+					Name:     "com.bar.Codecs.given_Codec",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/bar", Name: "codecs"},
+				},
+				{
+					Type:     sppb.ImportType_OBJECT,
+					Name:     "com.bar.Codecs",
+					Provider: "source",
+					Label:    label.Label{Pkg: "com/bar", Name: "codecs"},
+				},
+			},
+			rule: rule.NewRule("scala_library", "somelib"),
+			from: label.Label{Pkg: "com/foo", Name: "somelib"},
+			files: []*sppb.File{
+				{
+					Filename: "A.scala",
+					Exports:  []string{"com.bar.Codecs._", "com.qux.Unknown"},
+				},
+			},
+			want: []string{
+				`✅ com.bar.Codecs<OBJECT> //com/bar:codecs<source> (EXPORT of A.scala via "com.bar.Codecs._")`,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			universe := newMockGlobalScope(t, tc.globalSymbols)
+
+			sc, err := NewTestScalaConfig(t, universe, tc.from.Pkg, makeDirectives(tc.directives)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &scalaRuleContext{
+				rule:        tc.rule,
+				scalaConfig: sc,
+				resolver:    universe,
+				scope:       universe,
+			}
+
+			scalaRule := newScalaRule(zerolog.New(os.Stderr), ctx, &sppb.Rule{
+				Label: tc.from.String(),
+				Kind:  tc.rule.Kind(),
+				Files: tc.files,
+			})
+
+			exports := scalaRule.Exports(tc.from)
+			got := make([]string, len(exports.Keys()))
+			for i, imp := range exports.Values() {
+				got[i] = imp.String()
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func makeDirectives(in []string) (out []rule.Directive) {
 	for _, s := range in {
 		if s == "" {
@@ -310,6 +771,41 @@ type mockGlobalScope struct {
 	Global resolver.Scope
 }
 
+// splitPackageSymbols is a list of symbols of the package 'com.foo.model',
+// which is split over two rules.
+var splitPackageSymbols = []*resolver.Symbol{
+	{
+		Type:     sppb.ImportType_PACKAGE,
+		Name:     "com.foo.model",
+		Provider: "source",
+		Label:    label.Label{Pkg: "com/foo/model/a", Name: "a"},
+	},
+	{
+		Type:     sppb.ImportType_CLASS,
+		Name:     "com.foo.model.A",
+		Provider: "source",
+		Label:    label.Label{Pkg: "com/foo/model/a", Name: "a"},
+	},
+	{
+		Type:     sppb.ImportType_CLASS,
+		Name:     "com.foo.model.B",
+		Provider: "source",
+		Label:    label.Label{Pkg: "com/foo/model/b", Name: "b"},
+	},
+	{
+		Type:     sppb.ImportType_CLASS,
+		Name:     "com.foo.model.C",
+		Provider: "source",
+		Label:    label.Label{Pkg: "com/foo/model/b", Name: "b"},
+	},
+	{
+		Type:     sppb.ImportType_CLASS,
+		Name:     "com.foo.other.B",
+		Provider: "source",
+		Label:    label.Label{Pkg: "com/foo/other", Name: "other"},
+	},
+}
+
 func newMockGlobalScope(t *testing.T, known []*resolver.Symbol) *mockGlobalScope {
 	scope := &mockGlobalScope{
 		Universe: mocks.NewUniverse(t),
@@ -0,0 +1,7 @@
+package a.b.c
+
+import java.util.LinkedList
+
+object Lib {
+  val items = new LinkedList[String]()
+}
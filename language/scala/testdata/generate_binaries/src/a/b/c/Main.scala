@@ -0,0 +1,7 @@
+package a.b.c
+
+object Main {
+  def main(args: Array[String]): Unit = {
+    println(Lib.items)
+  }
+}
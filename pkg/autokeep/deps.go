@@ -86,6 +86,15 @@ func MergeDepsFromCache(deps DepsMap, cache *scpb.Cache) {
 			for _, s := range file.Types {
 				deps[s] = rule.Label
 			}
+			for _, s := range file.Enums {
+				deps[s] = rule.Label
+			}
+			for _, s := range file.Givens {
+				deps[s] = rule.Label
+			}
+			for _, s := range file.Defs {
+				deps[s] = rule.Label
+			}
 		}
 	}
 }
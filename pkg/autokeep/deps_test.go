@@ -9,6 +9,8 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	akpb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/autokeep"
+	scpb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/cache"
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
 	"github.com/stackb/scala-gazelle/pkg/testutil"
 )
 
@@ -64,6 +66,57 @@ func TestMakeDeltaDeps(t *testing.T) {
 	}
 }
 
+func TestMergeDepsFromCache(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cache *scpb.Cache
+		want  DepsMap
+	}{
+		"degenerate": {
+			cache: &scpb.Cache{},
+			want:  DepsMap{},
+		},
+		"symbols of all kinds": {
+			cache: &scpb.Cache{
+				Rules: []*sppb.Rule{
+					{
+						Label: "//com/foo:lib",
+						Files: []*sppb.File{
+							{
+								Filename: "com/foo/A.scala",
+								Classes:  []string{"com.foo.A"},
+								Objects:  []string{"com.foo.B"},
+								Traits:   []string{"com.foo.C"},
+								Types:    []string{"com.foo.D"},
+								Enums:    []string{"com.foo.E"},
+								Givens:   []string{"com.foo.given_F"},
+								Defs:     []string{"com.foo.g"},
+							},
+						},
+					},
+				},
+			},
+			want: DepsMap{
+				"com.foo.A":       "//com/foo:lib",
+				"com.foo.B":       "//com/foo:lib",
+				"com.foo.C":       "//com/foo:lib",
+				"com.foo.D":       "//com/foo:lib",
+				"com.foo.E":       "//com/foo:lib",
+				"com.foo.given_F": "//com/foo:lib",
+				"com.foo.g":       "//com/foo:lib",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := make(DepsMap)
+			MergeDepsFromCache(got, tc.cache)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestApplyDeltaDeps(t *testing.T) {
 	for name, tc := range map[string]struct {
 		DeltaDeps   *akpb.DeltaDeps
@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/rs/zerolog"
+)
+
+// Backend is a named parser implementation.  The source provider parses files
+// using a pool of workers created by the selected backend.
+type Backend interface {
+	// Name returns the name of the backend.
+	Name() string
+	// RegisterFlags configures the flags of the backend.  Flags of all known
+	// backends are registered, whether or not they are selected.
+	RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config)
+	// CheckFlags asserts that the flags are correct.  It is only called for
+	// the selected backend.
+	CheckFlags(fs *flag.FlagSet, c *config.Config) error
+	// Version returns a string that changes whenever the output of the
+	// backend may change.  It is part of the key of cached parse results.
+	Version() string
+	// NewWorker creates a new (unstarted) worker.
+	NewWorker(id int, logger zerolog.Logger) PoolWorker
+}
+
+// NewBackendFunc creates a new instance of a parser backend.  Backends hold
+// the values of their flags, so each source provider creates its own
+// instances rather than sharing them.
+type NewBackendFunc func() Backend
+
+// BackendRegistry is an index of known parser backends keyed by their name.
+type BackendRegistry interface {
+	// GetBackend returns the function that creates the named backend.  If not
+	// known `(nil, false)` is returned.
+	GetBackend(name string) (NewBackendFunc, bool)
+
+	// PutBackend adds the given backend to the registry.  It is an error to
+	// attempt duplicate registration of the same backend twice.
+	PutBackend(name string, newBackend NewBackendFunc) error
+}
+
+var globalBackends = make(globalBackendMap)
+
+// GlobalBackendRegistry returns a default parser backend registry.
+// Third-party gazelle extensions can append to this list and configure their
+// own implementations.
+func GlobalBackendRegistry() BackendRegistry {
+	return globalBackends
+}
+
+type globalBackendMap map[string]NewBackendFunc
+
+// GetBackend implements part of the parser.BackendRegistry interface.
+func (r globalBackendMap) GetBackend(name string) (NewBackendFunc, bool) {
+	newBackend, ok := r[name]
+	return newBackend, ok
+}
+
+// PutBackend implements part of the parser.BackendRegistry interface.
+func (r globalBackendMap) PutBackend(name string, newBackend NewBackendFunc) error {
+	if _, ok := r[name]; ok {
+		return fmt.Errorf("duplicate parser Backend %q", name)
+	}
+	r[name] = newBackend
+	return nil
+}
+
+// NewGlobalBackends returns a new instance of each known parser backend,
+// sorted by name.
+func NewGlobalBackends() []Backend {
+	keys := make([]string, 0, len(globalBackends))
+	for k := range globalBackends {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	backends := make([]Backend, len(keys))
+	for i, k := range keys {
+		backends[i] = globalBackends[k]()
+	}
+	return backends
+}
@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+	"github.com/stackb/scala-gazelle/pkg/collections"
+	"github.com/stackb/scala-gazelle/pkg/protobuf"
+)
+
+const (
+	scalaParserCommandFlagName       = "scala_parser_command"
+	scalaParserCommandArgFlagName    = "scala_parser_command_arg"
+	scalaParserCommandFormatFlagName = "scala_parser_command_format"
+)
+
+const (
+	// CommandFormatProto is the command protocol where requests and responses
+	// are varint length-delimited binary protobuf messages.
+	CommandFormatProto = "proto"
+	// CommandFormatJSON is the command protocol where requests and responses
+	// are protojson messages, one per line.
+	CommandFormatJSON = "json"
+)
+
+func init() {
+	GlobalBackendRegistry().PutBackend("command", func() Backend {
+		return &CommandBackend{}
+	})
+}
+
+// CommandBackend is a parser backend that delegates to an external command.
+// The command is started once per worker and must implement the following
+// protocol: for each build.stack.gazelle.scala.parse.ParseRequest read from
+// stdin, write exactly one build.stack.gazelle.scala.parse.ParseResponse to
+// stdout.  Messages are either varint length-delimited binary protobuf
+// (format 'proto', the same framing as bazel persistent workers) or protojson
+// terminated by a newline (format 'json').  The command should exit when
+// stdin is closed.  Stderr is passed through.
+type CommandBackend struct {
+	command string
+	args    collections.StringSlice
+	format  string
+}
+
+// Name implements part of the parser.Backend interface.
+func (b *CommandBackend) Name() string {
+	return "command"
+}
+
+// RegisterFlags implements part of the parser.Backend interface.
+func (b *CommandBackend) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {
+	fs.StringVar(&b.command, scalaParserCommandFlagName, "", "path to the parser command (for -scala_parser_backend=command)")
+	fs.Var(&b.args, scalaParserCommandArgFlagName, "repeatable argument to pass to the parser command")
+	fs.StringVar(&b.format, scalaParserCommandFormatFlagName, CommandFormatProto, "message format of the parser command protocol (proto|json)")
+}
+
+// CheckFlags implements part of the parser.Backend interface.
+func (b *CommandBackend) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	if b.command == "" {
+		return fmt.Errorf("-%s is required for the %s parser backend", scalaParserCommandFlagName, b.Name())
+	}
+	switch b.format {
+	case CommandFormatProto, CommandFormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("-%s: unknown format %q (want %s or %s)", scalaParserCommandFormatFlagName, b.format, CommandFormatProto, CommandFormatJSON)
+	}
+}
+
+// Version implements part of the parser.Backend interface.  The command line
+// is the only thing known about the command, so a new command version should
+// be installed at a new path (or the cache key changed).
+func (b *CommandBackend) Version() string {
+	return strings.Join(append([]string{b.Name(), b.format, b.command}, b.args...), " ")
+}
+
+// NewWorker implements part of the parser.Backend interface.
+func (b *CommandBackend) NewWorker(id int, logger zerolog.Logger) PoolWorker {
+	return NewCommandParser(b.format, b.command, b.args...)
+}
+
+// CommandParser is a PoolWorker that communicates with an external parser
+// process over stdin/stdout.  See CommandBackend for a description of the
+// protocol.
+type CommandParser struct {
+	format string
+	name   string
+	args   []string
+
+	// mu serializes requests
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	// exited is closed when the process exits
+	exited chan struct{}
+}
+
+// NewCommandParser creates a new CommandParser that runs the named program
+// with the given arguments.
+func NewCommandParser(format, name string, args ...string) *CommandParser {
+	return &CommandParser{format: format, name: name, args: args}
+}
+
+// Start implements part of the parser.PoolWorker interface.
+func (p *CommandParser) Start() error {
+	cmd := exec.Command(p.name, p.args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting parser command %s: %w", p.name, err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	p.cmd = cmd
+	p.stdin = stdin
+	p.stdout = bufio.NewReader(stdout)
+	p.exited = exited
+	return nil
+}
+
+// Stop implements part of the parser.PoolWorker interface.
+func (p *CommandParser) Stop() {
+	if p.cmd == nil {
+		return
+	}
+	p.stdin.Close()
+	p.cmd.Process.Kill()
+	<-p.exited
+	p.cmd = nil
+}
+
+// IsRunning implements part of the parser.PoolWorker interface.
+func (p *CommandParser) IsRunning() bool {
+	if p.cmd == nil {
+		return false
+	}
+	select {
+	case <-p.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// Parse implements part of the parser.PoolWorker interface.  If the context is
+// cancelled before a response is read, the process is stopped.
+func (p *CommandParser) Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.IsRunning() {
+		return nil, fmt.Errorf("parser command %s is not running", p.name)
+	}
+
+	type result struct {
+		response *sppb.ParseResponse
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		response, err := p.roundTrip(in)
+		done <- result{response, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.response, r.err
+	case <-ctx.Done():
+		p.Stop()
+		return nil, ctx.Err()
+	}
+}
+
+func (p *CommandParser) roundTrip(in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	var response sppb.ParseResponse
+
+	switch p.format {
+	case CommandFormatJSON:
+		data, err := protojson.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling request: %w", err)
+		}
+		if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+			return nil, fmt.Errorf("writing request: %w", err)
+		}
+		line, err := p.stdout.ReadBytes('\n')
+		if err != nil {
+			return nil, fmt.Errorf("reading response: %w", err)
+		}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(line, &response); err != nil {
+			return nil, fmt.Errorf("unmarshaling response: %w", err)
+		}
+	default:
+		if err := protobuf.WriteDelimitedTo(in, p.stdin); err != nil {
+			return nil, fmt.Errorf("writing request: %w", err)
+		}
+		if err := protobuf.ReadDelimitedFrom(&response, p.stdout); err != nil {
+			return nil, fmt.Errorf("reading response: %w", err)
+		}
+	}
+
+	return &response, nil
+}
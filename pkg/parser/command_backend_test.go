@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+	"github.com/stackb/scala-gazelle/pkg/protobuf"
+)
+
+// commandParserHelperEnv is set to the message format when the test binary is
+// run as a parser command.
+const commandParserHelperEnv = "SCALA_GAZELLE_PARSER_HELPER_FORMAT"
+
+// TestCommandParserHelperProcess is not a real test; it implements the parser
+// command protocol when the test binary is started by a CommandParser.  Each
+// file 'Foo.scala' is reported as defining the class 'fake.Foo'.  A file named
+// 'crash.scala' makes the process exit; 'hang.scala' never responds.
+func TestCommandParserHelperProcess(t *testing.T) {
+	format := os.Getenv(commandParserHelperEnv)
+	if format == "" {
+		return
+	}
+	stdin := bufio.NewReader(os.Stdin)
+	for {
+		var request sppb.ParseRequest
+		if format == CommandFormatJSON {
+			line, err := stdin.ReadBytes('\n')
+			if err != nil {
+				os.Exit(0)
+			}
+			if err := protojson.Unmarshal(line, &request); err != nil {
+				os.Exit(2)
+			}
+		} else {
+			if err := protobuf.ReadDelimitedFrom(&request, stdin); err != nil {
+				os.Exit(0)
+			}
+		}
+
+		response := &sppb.ParseResponse{}
+		for _, filename := range request.Filenames {
+			base := filepath.Base(filename)
+			switch base {
+			case "crash.scala":
+				os.Exit(1)
+			case "hang.scala":
+				select {}
+			}
+			response.Files = append(response.Files, &sppb.File{
+				Filename: filename,
+				Classes:  []string{"fake." + strings.TrimSuffix(base, ".scala")},
+			})
+		}
+
+		if format == CommandFormatJSON {
+			data, _ := protojson.Marshal(response)
+			fmt.Fprintf(os.Stdout, "%s\n", data)
+		} else {
+			protobuf.WriteDelimitedTo(response, os.Stdout)
+		}
+	}
+}
+
+func newHelperCommandParser(t *testing.T, format string) *CommandParser {
+	t.Setenv(commandParserHelperEnv, format)
+	p := NewCommandParser(format, os.Args[0], "-test.run=^TestCommandParserHelperProcess$")
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Stop)
+	return p
+}
+
+func TestCommandParserParse(t *testing.T) {
+	for _, format := range []string{CommandFormatProto, CommandFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			p := newHelperCommandParser(t, format)
+
+			// multiple requests are served by the same process
+			for _, filenames := range [][]string{
+				{"A.scala", "B.scala"},
+				{"C.scala"},
+			} {
+				got, err := p.Parse(context.Background(), &sppb.ParseRequest{Filenames: filenames})
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := &sppb.ParseResponse{}
+				for _, filename := range filenames {
+					want.Files = append(want.Files, &sppb.File{
+						Filename: filename,
+						Classes:  []string{"fake." + strings.TrimSuffix(filename, ".scala")},
+					})
+				}
+				if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(
+					sppb.ParseResponse{},
+					sppb.File{},
+				)); diff != "" {
+					t.Errorf("(-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandParserCrash(t *testing.T) {
+	p := newHelperCommandParser(t, CommandFormatProto)
+	if !p.IsRunning() {
+		t.Fatal("expected IsRunning() == true after Start()")
+	}
+
+	_, err := p.Parse(context.Background(), &sppb.ParseRequest{Filenames: []string{"crash.scala"}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got, want := err.Error(), "reading response: EOF"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	// the exit is observed asynchronously
+	deadline := time.Now().Add(5 * time.Second)
+	for p.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if p.IsRunning() {
+		t.Error("expected IsRunning() == false after crash")
+	}
+}
+
+func TestCommandParserContextCancel(t *testing.T) {
+	p := newHelperCommandParser(t, CommandFormatJSON)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := p.Parse(ctx, &sppb.ParseRequest{Filenames: []string{"hang.scala"}})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("want %v, got %v", context.DeadlineExceeded, err)
+	}
+	if p.IsRunning() {
+		t.Error("expected the process to be stopped after cancellation")
+	}
+}
+
+func TestCommandBackendCheckFlags(t *testing.T) {
+	for name, tc := range map[string]struct {
+		args    []string
+		wantErr string
+	}{
+		"missing command": {
+			wantErr: "-scala_parser_command is required for the command parser backend",
+		},
+		"bad format": {
+			args:    []string{"-scala_parser_command=parse", "-scala_parser_command_format=xml"},
+			wantErr: `-scala_parser_command_format: unknown format "xml" (want proto or json)`,
+		},
+		"ok": {
+			args: []string{"-scala_parser_command=parse", "-scala_parser_command_arg=--fast", "-scala_parser_command_format=json"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := &CommandBackend{}
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			c := &config.Config{}
+			b.RegisterFlags(fs, "update", c)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			var gotErr string
+			if err := b.CheckFlags(fs, c); err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Errorf("error (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGlobalBackends(t *testing.T) {
+	var names []string
+	for _, b := range NewGlobalBackends() {
+		names = append(names, b.Name())
+	}
+	if diff := cmp.Diff([]string{"command", "java", "lexer", "scalameta"}, names); diff != "" {
+		t.Errorf("backends (-want +got):\n%s", diff)
+	}
+
+	if NewGlobalBackends()[0] == NewGlobalBackends()[0] {
+		t.Errorf("expected a new backend instance for each call")
+	}
+
+	err := GlobalBackendRegistry().PutBackend("scalameta", func() Backend {
+		return &ScalametaBackend{}
+	})
+	if err == nil || err.Error() != `duplicate parser Backend "scalameta"` {
+		t.Errorf("expected duplicate registration error, got %v", err)
+	}
+}
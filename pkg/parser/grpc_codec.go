@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// JSONCodecName is the name of the grpc codec (content-subtype) used to
+// communicate with parser servers.  The node parser runtime has no protobuf
+// library, so messages are exchanged in the canonical protojson encoding
+// ("application/grpc+json").
+const JSONCodecName = "json"
+
+// NewJSONCodec returns the grpc codec used to communicate with parser servers.
+// The codec is not registered globally (as that could clash with another
+// codec of the same name in the binary); pass it explicitly with
+// grpc.ForceCodec or grpc.ForceServerCodec.
+func NewJSONCodec() encoding.Codec {
+	return jsonCodec{}
+}
+
+// jsonCodec is a grpc codec that encodes proto messages as protojson.
+type jsonCodec struct{}
+
+// Marshal implements part of the encoding.Codec interface.
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("json codec: cannot marshal %T (not a proto.Message)", v)
+	}
+	return protojson.Marshal(msg)
+}
+
+// Unmarshal implements part of the encoding.Codec interface.
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("json codec: cannot unmarshal into %T (not a proto.Message)", v)
+	}
+	return protojson.Unmarshal(data, msg)
+}
+
+// Name implements part of the encoding.Codec interface.
+func (jsonCodec) Name() string {
+	return JSONCodecName
+}
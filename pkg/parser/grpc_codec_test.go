@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+// echoParserServer is a Parser service that returns a file for each filename
+// in the request.
+type echoParserServer struct {
+	sppb.UnimplementedParserServer
+}
+
+func (s *echoParserServer) Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	if len(in.Filenames) == 0 {
+		return &sppb.ParseResponse{Error: "bad request"}, nil
+	}
+	response := &sppb.ParseResponse{}
+	for _, filename := range in.Filenames {
+		file := &sppb.File{Filename: filename}
+		if in.WantPositions {
+			file.ImportPositions = map[string]*sppb.Position{"a.B": {Line: 1, Column: 8}}
+		}
+		response.Files = append(response.Files, file)
+	}
+	return response, nil
+}
+
+func startEchoParserServer(t *testing.T) (string, func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(grpc.ForceServerCodec(NewJSONCodec()))
+	sppb.RegisterParserServer(server, &echoParserServer{})
+	go server.Serve(lis)
+	return lis.Addr().String(), server.Stop
+}
+
+func TestScalametaParserGrpcTransport(t *testing.T) {
+	address, stop := startEchoParserServer(t)
+	defer stop()
+
+	p := NewScalametaParser(WithTransport(TransportGRPC), WithAddress(address))
+	if err := p.Start(); err != nil {
+		t.Fatal("Start:", err)
+	}
+	defer p.Stop()
+
+	if !p.IsRunning() {
+		t.Error("expected IsRunning() == true when connected to a server")
+	}
+
+	for name, tc := range map[string]struct {
+		in   *sppb.ParseRequest
+		want *sppb.ParseResponse
+	}{
+		"degenerate": {
+			in:   &sppb.ParseRequest{},
+			want: &sppb.ParseResponse{Error: "bad request"},
+		},
+		"files": {
+			in: &sppb.ParseRequest{
+				Filenames:     []string{"A.scala", "B.scala"},
+				WantPositions: true,
+			},
+			want: &sppb.ParseResponse{
+				Files: []*sppb.File{
+					{
+						Filename:        "A.scala",
+						ImportPositions: map[string]*sppb.Position{"a.B": {Line: 1, Column: 8}},
+					},
+					{
+						Filename:        "B.scala",
+						ImportPositions: map[string]*sppb.Position{"a.B": {Line: 1, Column: 8}},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := p.Parse(context.Background(), tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(
+				sppb.ParseResponse{},
+				sppb.File{},
+				sppb.Position{},
+			)); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	stop()
+	if p.IsRunning() {
+		t.Error("expected IsRunning() == false after the server stopped")
+	}
+}
+
+func TestScalametaParserUnknownTransport(t *testing.T) {
+	p := NewScalametaParser(WithTransport("carrier-pigeon"))
+	err := p.Start()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got, want := err.Error(), `unknown parser transport "carrier-pigeon" (want "http" or "grpc")`; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestJSONCodec(t *testing.T) {
+	codec := NewJSONCodec()
+	data, err := codec.Marshal(&sppb.ParseRequest{Filenames: []string{"A.scala"}, WantPositions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the node runtime expects camelCase field names
+	if !strings.Contains(string(data), `"wantPositions"`) {
+		t.Errorf("marshal: want camelCase field names, got %s", data)
+	}
+
+	var got sppb.ParseRequest
+	if err := codec.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"A.scala"}, got.Filenames); diff != "" {
+		t.Errorf("unmarshal (-want +got):\n%s", diff)
+	}
+
+	if _, err := codec.Marshal("not a message"); err == nil {
+		t.Error("expected error marshaling a non-proto value")
+	}
+
+	// the codec is passed explicitly, not registered globally
+	if got := encoding.GetCodec(JSONCodecName); got != nil {
+		t.Errorf("want no globally registered %q codec, got %T", JSONCodecName, got)
+	}
+}
@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/rs/zerolog"
+)
+
+// javaVersion should be incremented whenever the output of the JavaParser
+// changes.
+const javaVersion = 1
+
+func init() {
+	GlobalBackendRegistry().PutBackend("java", func() Backend {
+		return &JavaBackend{}
+	})
+}
+
+// JavaBackend is a parser backend that uses the JavaParser.  It is the
+// default backend for .java files (see -java_parser_backend); it is not
+// meaningful for .scala files.
+type JavaBackend struct{}
+
+// Name implements part of the parser.Backend interface.
+func (b *JavaBackend) Name() string {
+	return "java"
+}
+
+// RegisterFlags implements part of the parser.Backend interface.
+func (b *JavaBackend) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {
+}
+
+// CheckFlags implements part of the parser.Backend interface.
+func (b *JavaBackend) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	return nil
+}
+
+// Version implements part of the parser.Backend interface.
+func (b *JavaBackend) Version() string {
+	return fmt.Sprintf("%s-%d", b.Name(), javaVersion)
+}
+
+// NewWorker implements part of the parser.Backend interface.
+func (b *JavaBackend) NewWorker(id int, logger zerolog.Logger) PoolWorker {
+	return NewJavaParser()
+}
@@ -0,0 +1,484 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+// JavaParser is a parser for .java source files.  Unlike the ScalametaParser
+// it does not require an external process; it scans the source text for the
+// package declaration, imports, and top-level type declarations (including
+// their extends/implements clauses).  Java interfaces and annotation types are
+// reported as traits, as that is how they appear to scala code.
+//
+// The JavaParser is the worker of the 'java' parser backend.
+type JavaParser struct{}
+
+// NewJavaParser constructs a new JavaParser.
+func NewJavaParser() *JavaParser {
+	return &JavaParser{}
+}
+
+// Start implements part of the parser.PoolWorker interface.  It is a no-op.
+func (p *JavaParser) Start() error {
+	return nil
+}
+
+// Stop implements part of the parser.PoolWorker interface.  It is a no-op.
+func (p *JavaParser) Stop() {
+}
+
+// IsRunning implements part of the parser.PoolWorker interface.  It is always
+// true.
+func (p *JavaParser) IsRunning() bool {
+	return true
+}
+
+// Parse parses the given java files.  It has the same contract as
+// ScalametaParser.Parse: a file that cannot be read is reported by setting
+// the File.Error field.
+func (p *JavaParser) Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	t1 := time.Now()
+
+	response := &sppb.ParseResponse{}
+	for _, filename := range in.Filenames {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			response.Files = append(response.Files, &sppb.File{
+				Filename: filename,
+				Error:    err.Error(),
+			})
+			continue
+		}
+		response.Files = append(response.Files, ParseJavaFile(filename, data, in.WantPositions))
+	}
+
+	response.ElapsedMillis = time.Since(t1).Milliseconds()
+	return response, nil
+}
+
+// ParseJavaFile parses the given java source text.  If wantPositions is true,
+// the positions of imports and top-level types are recorded.
+func ParseJavaFile(filename string, src []byte, wantPositions bool) *sppb.File {
+	jf := &javaFile{
+		src:     string(src),
+		tokens:  tokenizeJava(string(src)),
+		imports: make(map[string]bool),
+		extends: make(map[string]*sppb.ClassList),
+		file:    &sppb.File{Filename: filename},
+	}
+	if wantPositions {
+		jf.lineStarts = lineStarts(jf.src)
+		jf.file.ImportPositions = make(map[string]*sppb.Position)
+		jf.file.DefinitionPositions = make(map[string]*sppb.Position)
+	}
+	jf.parse()
+	return jf.toFile()
+}
+
+type javaTokenKind int
+
+const (
+	javaIdent javaTokenKind = iota
+	javaPunct
+	javaLiteral
+)
+
+type javaToken struct {
+	kind   javaTokenKind
+	text   string
+	offset int
+}
+
+// javaFile holds the state of a single file parse.
+type javaFile struct {
+	src        string
+	tokens     []javaToken
+	pos        int
+	lineStarts []int
+
+	pkg     string
+	imports map[string]bool
+	// explicit maps the simple name of a single-type import to the import.
+	explicit map[string]string
+	extends  map[string]*sppb.ClassList
+	file     *sppb.File
+}
+
+func (f *javaFile) peek(n int) javaToken {
+	if f.pos+n < len(f.tokens) {
+		return f.tokens[f.pos+n]
+	}
+	return javaToken{kind: javaPunct}
+}
+
+func (f *javaFile) next() javaToken {
+	tok := f.peek(0)
+	f.pos++
+	return tok
+}
+
+func (f *javaFile) done() bool {
+	return f.pos >= len(f.tokens)
+}
+
+func (f *javaFile) parse() {
+	f.explicit = make(map[string]string)
+	depth := 0
+	for !f.done() {
+		tok := f.peek(0)
+		if tok.kind == javaPunct {
+			switch tok.text {
+			case "{":
+				depth++
+			case "}":
+				depth--
+			case "@":
+				if depth == 0 && f.peek(1).text == "interface" {
+					f.pos += 2
+					f.parseTypeDeclaration("interface")
+					continue
+				}
+				f.pos++
+				f.skipAnnotation()
+				continue
+			}
+			f.pos++
+			continue
+		}
+		if depth != 0 || tok.kind != javaIdent {
+			f.pos++
+			continue
+		}
+		switch tok.text {
+		case "package":
+			f.pos++
+			f.pkg, _ = f.parseQualifiedName()
+		case "import":
+			f.pos++
+			f.parseImport()
+		case "class", "interface", "enum":
+			f.pos++
+			f.parseTypeDeclaration(tok.text)
+		case "record":
+			// 'record' is a contextual keyword
+			if f.peek(1).kind == javaIdent && (f.peek(2).text == "(" || f.peek(2).text == "<") {
+				f.pos++
+				f.parseTypeDeclaration("class")
+			} else {
+				f.pos++
+			}
+		default:
+			f.pos++
+		}
+	}
+}
+
+// parseQualifiedName parses a dotted name.  It returns the name and the first
+// token of it.
+func (f *javaFile) parseQualifiedName() (string, javaToken) {
+	first := f.peek(0)
+	var parts []string
+	for {
+		tok := f.peek(0)
+		if tok.kind == javaIdent {
+			parts = append(parts, tok.text)
+			f.pos++
+		} else if tok.text == "*" {
+			parts = append(parts, "_")
+			f.pos++
+			break
+		} else {
+			break
+		}
+		if f.peek(0).text != "." {
+			break
+		}
+		f.pos++
+	}
+	return strings.Join(parts, "."), first
+}
+
+func (f *javaFile) parseImport() {
+	if f.peek(0).text == "static" {
+		f.pos++
+	}
+	imp, first := f.parseQualifiedName()
+	if imp == "" {
+		return
+	}
+	f.imports[imp] = true
+	if !strings.HasSuffix(imp, "._") {
+		f.explicit[importSimpleName(imp)] = imp
+	}
+	f.putPosition(f.file.ImportPositions, imp, first)
+}
+
+// skipAnnotation skips the name and arguments of an annotation (the '@' has
+// already been consumed).
+func (f *javaFile) skipAnnotation() {
+	f.parseQualifiedName()
+	if f.peek(0).text == "(" {
+		f.skipBalanced("(", ")")
+	}
+}
+
+// skipBalanced skips from the current open token to the matching close token.
+func (f *javaFile) skipBalanced(open, close string) {
+	depth := 0
+	for !f.done() {
+		tok := f.next()
+		if tok.kind != javaPunct {
+			continue
+		}
+		switch tok.text {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// parseTypeDeclaration parses the header of a top-level type declaration up
+// to (but not including) its body.
+func (f *javaFile) parseTypeDeclaration(kind string) {
+	nameTok := f.peek(0)
+	if nameTok.kind != javaIdent {
+		return
+	}
+	f.pos++
+
+	qName := nameTok.text
+	if f.pkg != "" {
+		qName = f.pkg + "." + qName
+	}
+	switch kind {
+	case "interface":
+		f.file.Traits = append(f.file.Traits, qName)
+	default:
+		f.file.Classes = append(f.file.Classes, qName)
+	}
+	f.putPosition(f.file.DefinitionPositions, qName, nameTok)
+
+	if f.peek(0).text == "<" {
+		f.skipBalanced("<", ">")
+	}
+	if f.peek(0).text == "(" { // record header
+		f.skipBalanced("(", ")")
+	}
+
+	key := kind + " " + qName
+	for !f.done() {
+		tok := f.peek(0)
+		if tok.text == "{" || tok.text == ";" {
+			return
+		}
+		switch tok.text {
+		case "extends", "implements":
+			f.pos++
+			f.parseTypeList(key)
+		case "@":
+			f.pos++
+			f.skipAnnotation()
+		default:
+			f.pos++
+		}
+	}
+}
+
+// parseTypeList parses a comma-separated list of (possibly generic or
+// annotated) type names and records them as supertypes of the given key.
+func (f *javaFile) parseTypeList(key string) {
+	for !f.done() {
+		for f.peek(0).text == "@" {
+			f.pos++
+			f.skipAnnotation()
+		}
+		name, _ := f.parseQualifiedName()
+		if name != "" {
+			classList, ok := f.extends[key]
+			if !ok {
+				classList = &sppb.ClassList{}
+				f.extends[key] = classList
+			}
+			classList.Classes = append(classList.Classes, f.resolveTypeName(name))
+		}
+		if f.peek(0).text == "<" {
+			f.skipBalanced("<", ">")
+		}
+		if f.peek(0).text != "," {
+			return
+		}
+		f.pos++
+	}
+}
+
+// resolveTypeName resolves the first segment of the given name against the
+// single-type imports of the file.
+func (f *javaFile) resolveTypeName(name string) string {
+	first := name
+	rest := ""
+	if i := strings.Index(name, "."); i != -1 {
+		first, rest = name[:i], name[i:]
+	}
+	if imp, ok := f.explicit[first]; ok {
+		return imp + rest
+	}
+	return name
+}
+
+func (f *javaFile) putPosition(positions map[string]*sppb.Position, key string, tok javaToken) {
+	if positions == nil {
+		return
+	}
+	if _, ok := positions[key]; ok {
+		return
+	}
+	if pos := sourcePosition(f.src, f.lineStarts, tok.offset); pos != nil {
+		positions[key] = pos
+	}
+}
+
+func (f *javaFile) toFile() *sppb.File {
+	file := f.file
+	if f.pkg != "" {
+		file.Packages = []string{f.pkg}
+	}
+	for imp := range f.imports {
+		file.Imports = append(file.Imports, imp)
+	}
+	sort.Strings(file.Imports)
+	sort.Strings(file.Classes)
+	sort.Strings(file.Traits)
+	if len(f.extends) > 0 {
+		file.Extends = f.extends
+	}
+	if len(file.ImportPositions) == 0 {
+		file.ImportPositions = nil
+	}
+	if len(file.DefinitionPositions) == 0 {
+		file.DefinitionPositions = nil
+	}
+	return file
+}
+
+func importSimpleName(imp string) string {
+	if i := strings.LastIndex(imp, "."); i != -1 {
+		return imp[i+1:]
+	}
+	return imp
+}
+
+// lineStarts returns the byte offsets of the start of each line in the given
+// text.
+func lineStarts(text string) []int {
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// sourcePosition returns the 1-based line and column of the given byte offset,
+// or nil if the offset precedes the first line.
+func sourcePosition(src string, lineStarts []int, offset int) *sppb.Position {
+	line := sort.Search(len(lineStarts), func(i int) bool {
+		return lineStarts[i] > offset
+	}) - 1
+	if line < 0 {
+		return nil
+	}
+	column := utf8.RuneCountInString(src[lineStarts[line]:offset]) + 1
+	return &sppb.Position{Line: int32(line + 1), Column: int32(column)}
+}
+
+// tokenizeJava splits java source text into identifiers, punctuation and
+// literals.  Comments and whitespace are discarded.
+func tokenizeJava(src string) []javaToken {
+	var tokens []javaToken
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				i = len(src)
+			} else {
+				i += 2 + end + 2
+			}
+		case strings.HasPrefix(src[i:], `"""`):
+			start := i
+			end := strings.Index(src[i+3:], `"""`)
+			if end == -1 {
+				i = len(src)
+			} else {
+				i += 3 + end + 3
+			}
+			tokens = append(tokens, javaToken{kind: javaLiteral, text: src[start:i], offset: start})
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			for i < len(src) && src[i] != c && src[i] != '\n' {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+			if i > len(src) {
+				i = len(src)
+			}
+			tokens = append(tokens, javaToken{kind: javaLiteral, text: src[start:i], offset: start})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && (isJavaIdentByte(src[i]) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, javaToken{kind: javaLiteral, text: src[start:i], offset: start})
+		default:
+			r, size := utf8.DecodeRuneInString(src[i:])
+			if r == '_' || r == '$' || unicode.IsLetter(r) {
+				start := i
+				for i < len(src) {
+					r, size := utf8.DecodeRuneInString(src[i:])
+					if !(r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+						break
+					}
+					i += size
+				}
+				tokens = append(tokens, javaToken{kind: javaIdent, text: src[start:i], offset: start})
+			} else {
+				tokens = append(tokens, javaToken{kind: javaPunct, text: src[i : i+size], offset: i})
+				i += size
+			}
+		}
+	}
+	return tokens
+}
+
+func isJavaIdentByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,216 @@
+package parser
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/testtools"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+func TestParseJavaFile(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content       string
+		wantPositions bool
+		want          *sppb.File
+	}{
+		"degenerate": {
+			want: &sppb.File{Filename: "A.java"},
+		},
+		"package and imports": {
+			content: `
+package com.foo;
+
+import java.util.List;
+import java.util.*;
+import static org.junit.Assert.assertEquals;
+import static org.junit.Assert.*;
+`,
+			want: &sppb.File{
+				Filename: "A.java",
+				Packages: []string{"com.foo"},
+				Imports: []string{
+					"java.util.List",
+					"java.util._",
+					"org.junit.Assert._",
+					"org.junit.Assert.assertEquals",
+				},
+			},
+		},
+		"type declarations": {
+			content: `
+package com.foo;
+
+public final class A {
+    class Inner {}
+    interface InnerInterface {}
+}
+interface B {}
+enum C { X, Y }
+record D(int x, String y) {}
+@interface E {}
+`,
+			want: &sppb.File{
+				Filename: "A.java",
+				Packages: []string{"com.foo"},
+				Classes:  []string{"com.foo.A", "com.foo.C", "com.foo.D"},
+				Traits:   []string{"com.foo.B", "com.foo.E"},
+			},
+		},
+		"extends and implements": {
+			content: `
+package com.foo;
+
+import java.util.HashMap;
+import java.io.Serializable;
+import com.bar.Outer;
+
+public class A<K extends Comparable<K>, V> extends HashMap<K, V> implements Serializable, Outer.Inner, Runnable {
+}
+interface B extends com.baz.C, java.util.function.Supplier<String> {}
+`,
+			want: &sppb.File{
+				Filename: "A.java",
+				Packages: []string{"com.foo"},
+				Imports:  []string{"com.bar.Outer", "java.io.Serializable", "java.util.HashMap"},
+				Classes:  []string{"com.foo.A"},
+				Traits:   []string{"com.foo.B"},
+				Extends: map[string]*sppb.ClassList{
+					"class com.foo.A": {
+						Classes: []string{"java.util.HashMap", "java.io.Serializable", "com.bar.Outer.Inner", "Runnable"},
+					},
+					"interface com.foo.B": {
+						Classes: []string{"com.baz.C", "java.util.function.Supplier"},
+					},
+				},
+			},
+		},
+		"comments, strings and annotations are skipped": {
+			content: `
+// package com.comment;
+/* import com.comment.Foo; */
+package com.foo;
+
+import com.google.inject.Inject;
+
+/**
+ * class NotAClass {}
+ */
+@SuppressWarnings({"unchecked", "class Foo {"})
+@javax.annotation.Generated(value = "import x.y.Z;")
+public class A {
+    String s = "class B {}";
+    String t = """
+        interface C {}
+        """;
+    char c = '{';
+}
+`,
+			want: &sppb.File{
+				Filename: "A.java",
+				Packages: []string{"com.foo"},
+				Imports:  []string{"com.google.inject.Inject"},
+				Classes:  []string{"com.foo.A"},
+			},
+		},
+		"default package": {
+			content: `class A {}`,
+			want: &sppb.File{
+				Filename: "A.java",
+				Classes:  []string{"A"},
+			},
+		},
+		"record is a contextual keyword": {
+			content: `
+package com.foo;
+
+class A {
+}
+class record {}
+`,
+			want: &sppb.File{
+				Filename: "A.java",
+				Packages: []string{"com.foo"},
+				Classes:  []string{"com.foo.A", "com.foo.record"},
+			},
+		},
+		"positions": {
+			content: `package com.foo;
+
+import java.util.List;
+
+  public class A {}
+`,
+			wantPositions: true,
+			want: &sppb.File{
+				Filename: "A.java",
+				Packages: []string{"com.foo"},
+				Imports:  []string{"java.util.List"},
+				Classes:  []string{"com.foo.A"},
+				ImportPositions: map[string]*sppb.Position{
+					"java.util.List": {Line: 3, Column: 8},
+				},
+				DefinitionPositions: map[string]*sppb.Position{
+					"com.foo.A": {Line: 5, Column: 16},
+				},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := ParseJavaFile("A.java", []byte(tc.content), tc.wantPositions)
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(
+				sppb.File{},
+				sppb.ClassList{},
+				sppb.Position{},
+			)); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestJavaParserParse(t *testing.T) {
+	files := []testtools.FileSpec{
+		{
+			Path:    "A.java",
+			Content: "package a;\nclass A {}\n",
+		},
+	}
+	tmpDir, cleanup := testtools.CreateFiles(t, files)
+	defer cleanup()
+
+	a := filepath.Join(tmpDir, "A.java")
+	missing := filepath.Join(tmpDir, "Missing.java")
+
+	got, err := NewJavaParser().Parse(context.Background(), &sppb.ParseRequest{
+		Filenames: []string{a, missing},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.ElapsedMillis = 0
+
+	want := &sppb.ParseResponse{
+		Files: []*sppb.File{
+			{
+				Filename: a,
+				Packages: []string{"a"},
+				Classes:  []string{"a.A"},
+			},
+			{
+				Filename: missing,
+				Error:    "open " + missing + ": no such file or directory",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(
+		sppb.ParseResponse{},
+		sppb.File{},
+	)); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+}
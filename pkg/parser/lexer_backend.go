@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/rs/zerolog"
+)
+
+// lexerVersion should be incremented whenever the output of the LexerParser
+// changes.
+const lexerVersion = 1
+
+func init() {
+	GlobalBackendRegistry().PutBackend("lexer", func() Backend {
+		return &LexerBackend{}
+	})
+}
+
+// LexerBackend is a parser backend that uses the LexerParser for all files.
+// It is much faster than the scalameta backend and does not need node, at
+// the cost of degraded results (no names or extends clauses).
+type LexerBackend struct{}
+
+// Name implements part of the parser.Backend interface.
+func (b *LexerBackend) Name() string {
+	return "lexer"
+}
+
+// RegisterFlags implements part of the parser.Backend interface.
+func (b *LexerBackend) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {
+}
+
+// CheckFlags implements part of the parser.Backend interface.
+func (b *LexerBackend) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	return nil
+}
+
+// Version implements part of the parser.Backend interface.
+func (b *LexerBackend) Version() string {
+	return fmt.Sprintf("%s-%d", b.Name(), lexerVersion)
+}
+
+// NewWorker implements part of the parser.Backend interface.
+func (b *LexerBackend) NewWorker(id int, logger zerolog.Logger) PoolWorker {
+	return NewLexerParser()
+}
@@ -0,0 +1,866 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+// LexerParser is a lightweight parser for .scala source files that does not
+// require an external process.  It works at the level of tokens rather than a
+// syntax tree: it recovers package clauses, imports (including nested
+// imports) and the names of top-level classes, objects, traits, enums, types,
+// vals and defs.  It does not report names or extends clauses, and does not
+// fail on invalid source.  Files parsed by it are marked as degraded.
+//
+// The LexerParser is used as a fallback for files the primary parser fails
+// on, and as the 'lexer' parser backend.
+type LexerParser struct{}
+
+// NewLexerParser constructs a new LexerParser.
+func NewLexerParser() *LexerParser {
+	return &LexerParser{}
+}
+
+// Start implements part of the parser.PoolWorker interface.  It is a no-op.
+func (p *LexerParser) Start() error {
+	return nil
+}
+
+// Stop implements part of the parser.PoolWorker interface.  It is a no-op.
+func (p *LexerParser) Stop() {
+}
+
+// IsRunning implements part of the parser.PoolWorker interface.  It is always
+// true.
+func (p *LexerParser) IsRunning() bool {
+	return true
+}
+
+// Parse parses the given scala files.  It has the same contract as
+// ScalametaParser.Parse: a file that cannot be read is reported by setting
+// the File.Error field.
+func (p *LexerParser) Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	t1 := time.Now()
+
+	response := &sppb.ParseResponse{}
+	for _, filename := range in.Filenames {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			response.Files = append(response.Files, &sppb.File{
+				Filename: filename,
+				Error:    err.Error(),
+				Degraded: true,
+			})
+			continue
+		}
+		response.Files = append(response.Files, LexScalaFile(filename, data, in.WantPositions))
+	}
+
+	response.ElapsedMillis = time.Since(t1).Milliseconds()
+	return response, nil
+}
+
+// LexScalaFile parses the given scala source text with the lexer parser.  If
+// wantPositions is true, the positions of imports and top-level definitions
+// are recorded.
+func LexScalaFile(filename string, src []byte, wantPositions bool) *sppb.File {
+	sf := &scalaFile{
+		src:       string(src),
+		tokens:    tokenizeScala(string(src)),
+		packages:  make(map[string]bool),
+		imports:   make(map[string]bool),
+		selectors: make(map[string]*sppb.ImportSelector),
+		file:      &sppb.File{Filename: filename, Degraded: true},
+	}
+	if wantPositions {
+		sf.lineStarts = lineStarts(sf.src)
+		sf.file.ImportPositions = make(map[string]*sppb.Position)
+		sf.file.DefinitionPositions = make(map[string]*sppb.Position)
+	}
+	sf.parse()
+	return sf.toFile()
+}
+
+type scalaTokenKind int
+
+const (
+	scalaIdent scalaTokenKind = iota
+	// scalaQuotedIdent is a backquoted identifier; it is never a keyword.
+	scalaQuotedIdent
+	scalaPunct
+	scalaLiteral
+)
+
+type scalaToken struct {
+	kind   scalaTokenKind
+	text   string
+	offset int
+	// nl is true if the token is the first on its line.
+	nl bool
+	// col is the 0-based (byte) column of the token.
+	col int
+}
+
+// keyword returns true if the token is the given (unquoted) keyword.
+func (t scalaToken) keyword(text string) bool {
+	return t.kind == scalaIdent && t.text == text
+}
+
+// isName returns true if the token can be used as a name.
+func (t scalaToken) isName() bool {
+	return t.kind == scalaQuotedIdent || (t.kind == scalaIdent && !scalaKeywords[t.text])
+}
+
+var scalaKeywords = map[string]bool{
+	"abstract": true, "case": true, "catch": true, "class": true, "def": true,
+	"do": true, "else": true, "enum": true, "export": true, "extends": true,
+	"false": true, "final": true, "finally": true, "for": true, "given": true,
+	"if": true, "implicit": true, "import": true, "lazy": true, "match": true,
+	"new": true, "null": true, "object": true, "override": true,
+	"package": true, "private": true, "protected": true, "return": true,
+	"sealed": true, "super": true, "then": true, "this": true, "throw": true,
+	"trait": true, "true": true, "try": true, "type": true, "val": true,
+	"var": true, "while": true, "with": true, "yield": true, "_": true,
+}
+
+// scalaModifiers are the (soft) keywords that may precede a definition.
+var scalaModifiers = map[string]bool{
+	"abstract": true, "final": true, "implicit": true, "lazy": true,
+	"override": true, "private": true, "protected": true, "sealed": true,
+	"case": true, "inline": true, "opaque": true, "open": true,
+	"transparent": true, "infix": true,
+}
+
+// scalaContinuations are the tokens that continue a statement when they start
+// a line at the same indentation as the statement.
+var scalaContinuations = map[string]bool{
+	"extends": true, "with": true, "derives": true, "else": true,
+	"catch": true, "finally": true, "then": true, "do": true, "yield": true,
+	"match": true, ".": true,
+}
+
+// scalaFile holds the state of a single file parse.
+type scalaFile struct {
+	src        string
+	tokens     []scalaToken
+	pos        int
+	lineStarts []int
+
+	// templateDepth is the number of enclosing package objects.
+	templateDepth int
+	// blockDepth is the number of enclosing braced packages and package
+	// objects.
+	blockDepth int
+
+	packages  map[string]bool
+	imports   map[string]bool
+	selectors map[string]*sppb.ImportSelector
+	file      *sppb.File
+}
+
+func (f *scalaFile) peek(n int) scalaToken {
+	if f.pos+n < len(f.tokens) {
+		return f.tokens[f.pos+n]
+	}
+	return scalaToken{kind: scalaPunct}
+}
+
+func (f *scalaFile) done() bool {
+	return f.pos >= len(f.tokens)
+}
+
+func (f *scalaFile) parse() {
+	f.parseStats("", false, -1)
+}
+
+// parseStats parses a sequence of statements whose definitions are qualified
+// by the given package name.  If braced, the statements end with (and consume)
+// a closing brace.  Otherwise they end before the closing brace of an
+// enclosing block or, if indent is not negative, before a line indented at or
+// below the given column.
+func (f *scalaFile) parseStats(pkg string, braced bool, indent int) {
+	if braced {
+		f.blockDepth++
+		defer func() { f.blockDepth-- }()
+	}
+	for !f.done() {
+		tok := f.peek(0)
+		switch {
+		case tok.kind == scalaPunct && tok.text == "}":
+			if braced {
+				f.pos++
+				return
+			}
+			if f.blockDepth > 0 {
+				return
+			}
+			// a stray closing brace
+			f.pos++
+			continue
+		case tok.kind == scalaPunct && (tok.text == ";" || tok.text == ")" || tok.text == "]"):
+			f.pos++
+			continue
+		case !braced && indent >= 0 && tok.nl && tok.col <= indent:
+			return
+		}
+		f.parseStat(pkg)
+	}
+}
+
+// parseStat parses a single statement.
+func (f *scalaFile) parseStat(pkg string) {
+	start := f.peek(0)
+	startPos := f.pos
+
+	switch {
+	case start.keyword("package"):
+		f.pos++
+		if f.peek(0).keyword("object") {
+			f.pos++
+			f.parsePackageObject(pkg, start)
+			return
+		}
+		name, _ := f.parseQualifiedName()
+		if name == "" {
+			return
+		}
+		name = qualifyName(pkg, name)
+		f.packages[name] = true
+		switch f.peek(0).text {
+		case "{":
+			f.pos++
+			f.parseStats(name, true, -1)
+		case ":":
+			f.pos++
+			f.parseStats(name, false, start.col)
+		default:
+			// a package clause applies to the remainder of the block
+			f.parseStats(name, false, -1)
+		}
+		return
+	case start.keyword("import"):
+		f.pos++
+		f.parseImport(f.templateDepth > 0)
+		return
+	}
+
+	// annotations and modifiers
+	for !f.done() {
+		tok := f.peek(0)
+		if tok.kind == scalaPunct && tok.text == "@" {
+			f.pos++
+			f.skipAnnotation()
+			continue
+		}
+		if tok.kind == scalaIdent && scalaModifiers[tok.text] && !(f.peek(1).kind == scalaPunct && f.peek(1).text != "[") {
+			f.pos++
+			if f.peek(0).text == "[" { // private[pkg]
+				f.skipBalanced("[", "]")
+			}
+			continue
+		}
+		break
+	}
+
+	tok := f.peek(0)
+	if tok.kind == scalaIdent {
+		name := f.peek(1)
+		switch tok.text {
+		case "class", "trait", "object", "enum", "type", "val", "def":
+			if !name.isName() {
+				break
+			}
+			f.pos += 2
+			if tok.text == "val" && f.peek(0).text == "(" {
+				// an extractor pattern
+				break
+			}
+			qName := qualifyName(pkg, name.text)
+			f.putPosition(f.file.DefinitionPositions, qName, name)
+			switch tok.text {
+			case "class":
+				f.file.Classes = append(f.file.Classes, qName)
+			case "trait":
+				f.file.Traits = append(f.file.Traits, qName)
+			case "object":
+				f.file.Objects = append(f.file.Objects, qName)
+			case "enum":
+				f.file.Enums = append(f.file.Enums, qName)
+			case "type":
+				f.file.Types = append(f.file.Types, qName)
+			case "val":
+				f.file.Vals = append(f.file.Vals, qName)
+			case "def":
+				f.file.Defs = append(f.file.Defs, qName)
+			}
+			if f.skipStat(start) && tok.text == "object" {
+				f.file.MainObjects = append(f.file.MainObjects, qName)
+			}
+			return
+		}
+	}
+
+	if f.pos == startPos {
+		// make progress on an unrecognized statement
+		f.pos++
+	}
+	f.skipStat(start)
+}
+
+// parsePackageObject parses a package object (the 'package object' keywords
+// have already been consumed).  Its members are qualified by the name of the
+// package object.
+func (f *scalaFile) parsePackageObject(pkg string, start scalaToken) {
+	nameTok := f.peek(0)
+	if !nameTok.isName() {
+		return
+	}
+	f.pos++
+	qName := qualifyName(pkg, nameTok.text)
+	f.packages[qName] = true
+	f.file.Objects = append(f.file.Objects, qName)
+	f.putPosition(f.file.DefinitionPositions, qName, nameTok)
+
+	f.templateDepth++
+	defer func() { f.templateDepth-- }()
+
+	// the template header
+	for !f.done() {
+		tok := f.peek(0)
+		if tok.nl && tok.col <= start.col && !scalaContinuations[tok.text] {
+			return
+		}
+		if tok.kind == scalaPunct {
+			switch tok.text {
+			case "{":
+				f.pos++
+				f.parseStats(qName, true, -1)
+				return
+			case ":":
+				if f.peek(1).nl {
+					f.pos++
+					f.parseStats(qName, false, start.col)
+					return
+				}
+			case "(", "[":
+				f.skipBalanced(tok.text, closingDelimiter(tok.text))
+				continue
+			case ";", "}":
+				return
+			}
+		}
+		f.pos++
+	}
+}
+
+// skipStat skips to the end of the current statement.  Imports within the
+// statement are recorded as nested imports.  It returns true if a method of
+// the form 'def main(args: Array[String])' is found directly within the body
+// of the statement.
+func (f *scalaFile) skipStat(start scalaToken) bool {
+	var hasMain bool
+	depth := 0
+	for !f.done() {
+		tok := f.peek(0)
+		if depth == 0 {
+			if tok.kind == scalaPunct && (tok.text == ";" || tok.text == "}" || tok.text == ")" || tok.text == "]") {
+				return hasMain
+			}
+			if tok.nl && tok.col <= start.col && !scalaContinuations[tok.text] {
+				return hasMain
+			}
+		}
+		switch tok.kind {
+		case scalaPunct:
+			switch tok.text {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth--
+			}
+		case scalaIdent:
+			switch tok.text {
+			case "import":
+				f.pos++
+				f.parseImport(true)
+				continue
+			case "def":
+				if f.isMainMethod(depth) {
+					hasMain = true
+				}
+			}
+		}
+		f.pos++
+	}
+	return hasMain
+}
+
+// isMainMethod returns true if the tokens at the current position are of the
+// form 'def main(args: Array' at the top-level of a template body (either
+// braced or indented).
+func (f *scalaFile) isMainMethod(depth int) bool {
+	if depth > 1 {
+		return false
+	}
+	return f.peek(1).text == "main" &&
+		f.peek(2).text == "(" &&
+		f.peek(3).isName() &&
+		f.peek(4).text == ":" &&
+		f.peek(5).text == "Array"
+}
+
+// parseQualifiedName parses a dotted name.  It returns the name and the last
+// token of it.
+func (f *scalaFile) parseQualifiedName() (string, scalaToken) {
+	var parts []string
+	var last scalaToken
+	for f.peek(0).isName() {
+		last = f.peek(0)
+		parts = append(parts, last.text)
+		f.pos++
+		if f.peek(0).text != "." || !f.peek(1).isName() {
+			break
+		}
+		f.pos++
+	}
+	return strings.Join(parts, "."), last
+}
+
+// parseImport parses the importers of an import clause (the 'import' keyword
+// has already been consumed).
+func (f *scalaFile) parseImport(nested bool) {
+	for !f.done() {
+		f.parseImporter(nested)
+		if f.peek(0).text != "," {
+			return
+		}
+		f.pos++
+	}
+}
+
+func (f *scalaFile) parseImporter(nested bool) {
+	ref, last := f.parseQualifiedName()
+	if ref == "" {
+		return
+	}
+
+	if f.peek(0).text != "." {
+		// 'import a.B' or 'import a.B as C'
+		qualifier := ""
+		if i := strings.LastIndex(ref, "."); i != -1 {
+			qualifier = ref[:i]
+		}
+		if qualifier == "" {
+			// 'import a' imports nothing from a package
+			return
+		}
+		if f.peek(0).keyword("as") {
+			f.pos++
+			f.parseSelectorRename(qualifier, last, nested)
+			return
+		}
+		f.addImport(ref, last.text, last, nested)
+		return
+	}
+
+	f.pos++ // '.'
+	tok := f.peek(0)
+	switch {
+	case tok.text == "_" || tok.text == "*":
+		f.pos++
+		f.addImport(ref+"._", "", tok, nested)
+	case tok.keyword("given"):
+		f.pos++
+		f.addImport(ref, "", tok, nested)
+	case tok.text == "{":
+		f.pos++
+		f.parseSelectors(ref, nested)
+	}
+}
+
+// parseSelectors parses the selectors of an import clause up to and
+// including the closing brace.
+func (f *scalaFile) parseSelectors(ref string, nested bool) {
+	for !f.done() {
+		tok := f.peek(0)
+		switch {
+		case tok.text == "}":
+			f.pos++
+			return
+		case tok.text == ",":
+			f.pos++
+		case tok.text == "_" || tok.text == "*":
+			f.pos++
+			f.addImport(ref+"._", "", tok, nested)
+		case tok.keyword("given"):
+			f.pos++
+			// 'given' or 'given T': the given instances are members of the
+			// qualifier.
+			for !f.done() && f.peek(0).text != "," && f.peek(0).text != "}" {
+				f.pos++
+			}
+			f.addImport(ref, "", tok, nested)
+		case tok.isName():
+			f.pos++
+			next := f.peek(0)
+			if next.text == "=>" || next.text == "⇒" || next.keyword("as") {
+				f.pos++
+				f.parseSelectorRename(ref, tok, nested)
+			} else {
+				f.addImport(ref+"."+tok.text, tok.text, tok, nested)
+			}
+		default:
+			// invalid syntax; give up on this clause
+			return
+		}
+	}
+}
+
+// parseSelectorRename parses the target of a renamed or hidden selector (the
+// '=>' or 'as' has already been consumed).
+func (f *scalaFile) parseSelectorRename(ref string, name scalaToken, nested bool) {
+	imp := ref + "." + name.text
+	target := f.peek(0)
+	switch {
+	case target.text == "_":
+		f.pos++
+		f.addSelector(&sppb.ImportSelector{Imp: imp, Name: name.text, Hidden: true, Nested: nested})
+	case target.isName():
+		f.pos++
+		f.imports[imp] = true
+		f.putPosition(f.file.ImportPositions, imp, name)
+		f.addSelector(&sppb.ImportSelector{Imp: imp, Name: name.text, Alias: target.text, Nested: nested})
+	}
+}
+
+// addImport records an import.  The name is empty for wildcard and given
+// imports.
+func (f *scalaFile) addImport(imp, name string, tok scalaToken, nested bool) {
+	f.imports[imp] = true
+	f.putPosition(f.file.ImportPositions, imp, tok)
+	if nested {
+		f.addSelector(&sppb.ImportSelector{Imp: imp, Name: name, Nested: true})
+	}
+}
+
+// addSelector records an import selector.  The first occurrence of a selector
+// wins.
+func (f *scalaFile) addSelector(selector *sppb.ImportSelector) {
+	key := selector.Imp + "|" + selector.Alias
+	if _, ok := f.selectors[key]; ok {
+		return
+	}
+	f.selectors[key] = selector
+}
+
+// skipAnnotation skips the name and arguments of an annotation (the '@' has
+// already been consumed).
+func (f *scalaFile) skipAnnotation() {
+	f.parseQualifiedName()
+	if f.peek(0).text == "[" {
+		f.skipBalanced("[", "]")
+	}
+	for f.peek(0).text == "(" && !f.peek(0).nl {
+		f.skipBalanced("(", ")")
+	}
+}
+
+// skipBalanced skips from the current open token to the matching close token.
+func (f *scalaFile) skipBalanced(open, close string) {
+	depth := 0
+	for !f.done() {
+		tok := f.peek(0)
+		f.pos++
+		if tok.kind != scalaPunct {
+			continue
+		}
+		switch tok.text {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+func (f *scalaFile) putPosition(positions map[string]*sppb.Position, key string, tok scalaToken) {
+	if positions == nil {
+		return
+	}
+	if _, ok := positions[key]; ok {
+		return
+	}
+	if pos := sourcePosition(f.src, f.lineStarts, tok.offset); pos != nil {
+		positions[key] = pos
+	}
+}
+
+func (f *scalaFile) toFile() *sppb.File {
+	file := f.file
+	for pkg := range f.packages {
+		file.Packages = append(file.Packages, pkg)
+	}
+	for imp := range f.imports {
+		file.Imports = append(file.Imports, imp)
+	}
+	for _, selector := range f.selectors {
+		file.ImportSelectors = append(file.ImportSelectors, selector)
+	}
+	sort.Slice(file.ImportSelectors, func(i, j int) bool {
+		a := file.ImportSelectors[i]
+		b := file.ImportSelectors[j]
+		if a.Imp != b.Imp {
+			return a.Imp < b.Imp
+		}
+		return a.Alias < b.Alias
+	})
+	for _, list := range [][]string{
+		file.Packages,
+		file.Imports,
+		file.Classes,
+		file.Objects,
+		file.Traits,
+		file.Enums,
+		file.Types,
+		file.Vals,
+		file.Defs,
+		file.MainObjects,
+	} {
+		sort.Strings(list)
+	}
+	if len(file.ImportPositions) == 0 {
+		file.ImportPositions = nil
+	}
+	if len(file.DefinitionPositions) == 0 {
+		file.DefinitionPositions = nil
+	}
+	return file
+}
+
+// qualifyName joins the given package and name.
+func qualifyName(pkg, name string) string {
+	if pkg == "" {
+		return name
+	}
+	return pkg + "." + name
+}
+
+func closingDelimiter(open string) string {
+	switch open {
+	case "(":
+		return ")"
+	case "[":
+		return "]"
+	default:
+		return "}"
+	}
+}
+
+// tokenizeScala splits scala source text into identifiers, punctuation and
+// literals.  Comments and whitespace are discarded.  Consecutive operator
+// characters form a single token.
+func tokenizeScala(src string) []scalaToken {
+	var tokens []scalaToken
+	lineStart := 0
+	nl := true
+	emit := func(kind scalaTokenKind, text string, offset int) {
+		tokens = append(tokens, scalaToken{kind: kind, text: text, offset: offset, nl: nl, col: offset - lineStart})
+		nl = false
+	}
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			i++
+			lineStart = i
+			nl = true
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			// block comments nest
+			depth := 0
+			for i < len(src) {
+				if strings.HasPrefix(src[i:], "/*") {
+					depth++
+					i += 2
+				} else if strings.HasPrefix(src[i:], "*/") {
+					depth--
+					i += 2
+					if depth == 0 {
+						break
+					}
+				} else {
+					i++
+				}
+			}
+			if j := strings.LastIndexByte(src[:i], '\n'); j >= lineStart {
+				lineStart = j + 1
+				nl = true
+			}
+		case c == '"':
+			start := i
+			interpolated := len(tokens) > 0 && tokens[len(tokens)-1].offset+len(tokens[len(tokens)-1].text) == i && tokens[len(tokens)-1].kind == scalaIdent
+			i = skipScalaString(src, i, interpolated)
+			emit(scalaLiteral, src[start:i], start)
+			if j := strings.LastIndexByte(src[start:i], '\n'); j != -1 {
+				lineStart = start + j + 1
+			}
+		case c == '`':
+			start := i
+			end := strings.IndexAny(src[i+1:], "`\n")
+			if end == -1 || src[i+1+end] != '`' {
+				i++
+				continue
+			}
+			i += 1 + end + 1
+			emit(scalaQuotedIdent, src[start+1:i-1], start)
+		case c == '\'':
+			// a character literal, or a symbol literal or quote
+			start := i
+			if end := scalaCharLiteralEnd(src, i); end != -1 {
+				i = end
+				emit(scalaLiteral, src[start:i], start)
+			} else {
+				i++
+				emit(scalaPunct, "'", start)
+			}
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && (isJavaIdentByte(src[i]) || (src[i] == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9')) {
+				i++
+			}
+			emit(scalaLiteral, src[start:i], start)
+		case strings.IndexByte("()[]{},;.", c) != -1:
+			emit(scalaPunct, src[i:i+1], i)
+			i++
+		case isScalaOpChar(c):
+			start := i
+			for i < len(src) && isScalaOpChar(src[i]) && !strings.HasPrefix(src[i:], "//") && !strings.HasPrefix(src[i:], "/*") {
+				i++
+			}
+			emit(scalaPunct, src[start:i], start)
+		default:
+			r, size := utf8.DecodeRuneInString(src[i:])
+			if r == '_' || r == '$' || unicode.IsLetter(r) {
+				start := i
+				for i < len(src) {
+					r, size := utf8.DecodeRuneInString(src[i:])
+					if !(r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+						break
+					}
+					i += size
+				}
+				emit(scalaIdent, src[start:i], start)
+			} else {
+				emit(scalaPunct, src[i:i+size], i)
+				i += size
+			}
+		}
+	}
+	return tokens
+}
+
+// skipScalaString returns the offset following the string literal that starts
+// at the given offset.  Splices ('${...}') of interpolated strings may contain
+// nested string literals.
+func skipScalaString(src string, i int, interpolated bool) int {
+	multiline := strings.HasPrefix(src[i:], `"""`)
+	if multiline {
+		i += 3
+	} else {
+		i++
+	}
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case multiline && strings.HasPrefix(src[i:], `"""`):
+			i += 3
+			// a multi-line string may end with additional quotes
+			for i < len(src) && src[i] == '"' {
+				i++
+			}
+			return i
+		case !multiline && c == '"':
+			return i + 1
+		case !multiline && c == '\n':
+			return i
+		case !multiline && c == '\\':
+			i += 2
+		case interpolated && c == '$' && i+1 < len(src) && src[i+1] == '{':
+			i = skipScalaSplice(src, i+2)
+		case interpolated && c == '$':
+			i += 2
+		default:
+			i++
+		}
+	}
+	return len(src)
+}
+
+// skipScalaSplice returns the offset following the closing brace of a splice
+// in an interpolated string.
+func skipScalaSplice(src string, i int) int {
+	depth := 1
+	for i < len(src) {
+		switch src[i] {
+		case '{':
+			depth++
+			i++
+		case '}':
+			depth--
+			i++
+			if depth == 0 {
+				return i
+			}
+		case '"':
+			interpolated := i > 0 && isJavaIdentByte(src[i-1])
+			i = skipScalaString(src, i, interpolated)
+		default:
+			i++
+		}
+	}
+	return len(src)
+}
+
+// scalaCharLiteralEnd returns the offset following the character literal that
+// starts at the given offset, or -1 if there is none (a symbol literal or a
+// quote).
+func scalaCharLiteralEnd(src string, i int) int {
+	if i+1 >= len(src) {
+		return -1
+	}
+	if src[i+1] == '\\' {
+		end := strings.IndexAny(src[i+2:], "'\n")
+		if end == -1 || src[i+2+end] != '\'' {
+			return -1
+		}
+		return i + 2 + end + 1
+	}
+	_, size := utf8.DecodeRuneInString(src[i+1:])
+	if i+1+size < len(src) && src[i+1+size] == '\'' {
+		return i + 1 + size + 1
+	}
+	return -1
+}
+
+func isScalaOpChar(c byte) bool {
+	return strings.IndexByte("!#%&*+-/:<=>?@\\^|~", c) != -1
+}
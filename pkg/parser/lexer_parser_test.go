@@ -0,0 +1,336 @@
+package parser
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/testtools"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+func TestLexScalaFile(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content       string
+		wantPositions bool
+		want          *sppb.File
+	}{
+		"degenerate": {
+			want: &sppb.File{Filename: "A.scala", Degraded: true},
+		},
+		"package and imports": {
+			content: `
+package com.foo
+
+import java.util.List
+import scala.collection.mutable._
+import a.b.{C, D => E, F => _, _}
+import x.y.Z, x.y.W
+import scala3.*
+import scala3.{given, A as B}
+import givens.given
+import renamed.X as Y
+`,
+			want: &sppb.File{
+				Filename: "A.scala",
+				Packages: []string{"com.foo"},
+				Imports: []string{
+					"a.b.C",
+					"a.b.D",
+					"a.b._",
+					"givens",
+					"java.util.List",
+					"renamed.X",
+					"scala.collection.mutable._",
+					"scala3",
+					"scala3.A",
+					"scala3._",
+					"x.y.W",
+					"x.y.Z",
+				},
+				ImportSelectors: []*sppb.ImportSelector{
+					{Imp: "a.b.D", Name: "D", Alias: "E"},
+					{Imp: "a.b.F", Name: "F", Hidden: true},
+					{Imp: "renamed.X", Name: "X", Alias: "Y"},
+					{Imp: "scala3.A", Name: "A", Alias: "B"},
+				},
+				Degraded: true,
+			},
+		},
+		"top-level definitions": {
+			content: `
+package com.foo
+
+import com.bar.Base
+
+sealed trait A extends Base
+final case class B(val x: Int, y: String) extends A {
+  class NotTopLevel
+  val notTopLevel = 1
+}
+case object C extends A
+object D {
+  def main(args: Array[String]): Unit = ()
+}
+abstract class E[T <: A](implicit ev: T)
+  extends Base
+  with A {
+  type NotTopLevel = Int
+}
+private[foo] class F
+@deprecated("use B", "1.0")
+class G
+enum H {
+  case X, Y
+}
+`,
+			want: &sppb.File{
+				Filename:    "A.scala",
+				Packages:    []string{"com.foo"},
+				Imports:     []string{"com.bar.Base"},
+				Classes:     []string{"com.foo.B", "com.foo.E", "com.foo.F", "com.foo.G"},
+				Objects:     []string{"com.foo.C", "com.foo.D"},
+				Traits:      []string{"com.foo.A"},
+				Enums:       []string{"com.foo.H"},
+				MainObjects: []string{"com.foo.D"},
+				Degraded:    true,
+			},
+		},
+		"package object": {
+			content: `
+package com
+
+package object foo extends Bar {
+  import scala.concurrent.Future
+
+  type Result = Future[Int]
+  val Default = 1
+  lazy val Lazy = 2
+  def helper(): Int = 3
+  val (a, b) = (1, 2)
+}
+`,
+			want: &sppb.File{
+				Filename: "A.scala",
+				Packages: []string{"com", "com.foo"},
+				Imports:  []string{"scala.concurrent.Future"},
+				Objects:  []string{"com.foo"},
+				Types:    []string{"com.foo.Result"},
+				Vals:     []string{"com.foo.Default", "com.foo.Lazy"},
+				Defs:     []string{"com.foo.helper"},
+				ImportSelectors: []*sppb.ImportSelector{
+					{Imp: "scala.concurrent.Future", Name: "Future", Nested: true},
+				},
+				Degraded: true,
+			},
+		},
+		"chained and braced packages": {
+			content: `
+package com
+package foo
+
+package bar {
+  class A
+}
+class B
+`,
+			want: &sppb.File{
+				Filename: "A.scala",
+				Packages: []string{"com", "com.foo", "com.foo.bar"},
+				Classes:  []string{"com.foo.B", "com.foo.bar.A"},
+				Degraded: true,
+			},
+		},
+		"nested imports": {
+			content: `
+package com.foo
+
+import a.B
+
+class A {
+  import c.D
+  def f = {
+    import e._
+    1
+  }
+}
+`,
+			want: &sppb.File{
+				Filename: "A.scala",
+				Packages: []string{"com.foo"},
+				Imports:  []string{"a.B", "c.D", "e._"},
+				Classes:  []string{"com.foo.A"},
+				ImportSelectors: []*sppb.ImportSelector{
+					{Imp: "c.D", Name: "D", Nested: true},
+					{Imp: "e._", Nested: true},
+				},
+				Degraded: true,
+			},
+		},
+		"scala 3 indentation syntax": {
+			content: `
+package com.foo
+
+object A:
+  val notTopLevel = 1
+  import inner.Thing
+
+  def main(args: Array[String]): Unit =
+    println("hi")
+end A
+
+trait B:
+  def f: Int
+
+given Ordering[Int] = ???
+def topLevel(x: Int): Int = x
+`,
+			want: &sppb.File{
+				Filename:    "A.scala",
+				Packages:    []string{"com.foo"},
+				Imports:     []string{"inner.Thing"},
+				Objects:     []string{"com.foo.A"},
+				Traits:      []string{"com.foo.B"},
+				Defs:        []string{"com.foo.topLevel"},
+				MainObjects: []string{"com.foo.A"},
+				ImportSelectors: []*sppb.ImportSelector{
+					{Imp: "inner.Thing", Name: "Thing", Nested: true},
+				},
+				Degraded: true,
+			},
+		},
+		"comments, strings and literals are skipped": {
+			content: `
+// package com.comment
+/* import com.comment.Foo /* nested */ class NotAClass */
+package com.foo
+
+import com.bar.Baz
+
+class A {
+  val s = "class B {"
+  val t = """
+    object C }
+    """
+  val u = s"${Map("k" -> "}").size} import x.y"
+  val c = '{'
+  val sym = 'symbol
+  val ` + "`class`" + ` = 1
+}
+object D
+`,
+			want: &sppb.File{
+				Filename: "A.scala",
+				Packages: []string{"com.foo"},
+				Imports:  []string{"com.bar.Baz"},
+				Classes:  []string{"com.foo.A"},
+				Objects:  []string{"com.foo.D"},
+				Degraded: true,
+			},
+		},
+		"invalid source is tolerated": {
+			content: `
+package com.foo
+
+import a.b.C
+
+class A {
+  def f = (
+}}}
+object B
+`,
+			want: &sppb.File{
+				Filename: "A.scala",
+				Packages: []string{"com.foo"},
+				Imports:  []string{"a.b.C"},
+				Classes:  []string{"com.foo.A"},
+				Objects:  []string{"com.foo.B"},
+				Degraded: true,
+			},
+		},
+		"positions": {
+			content: `package com.foo
+
+import java.util.{List, Map => JMap}
+
+  class A
+`,
+			wantPositions: true,
+			want: &sppb.File{
+				Filename: "A.scala",
+				Packages: []string{"com.foo"},
+				Imports:  []string{"java.util.List", "java.util.Map"},
+				Classes:  []string{"com.foo.A"},
+				ImportSelectors: []*sppb.ImportSelector{
+					{Imp: "java.util.Map", Name: "Map", Alias: "JMap"},
+				},
+				ImportPositions: map[string]*sppb.Position{
+					"java.util.List": {Line: 3, Column: 19},
+					"java.util.Map":  {Line: 3, Column: 25},
+				},
+				DefinitionPositions: map[string]*sppb.Position{
+					"com.foo.A": {Line: 5, Column: 9},
+				},
+				Degraded: true,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := LexScalaFile("A.scala", []byte(tc.content), tc.wantPositions)
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(
+				sppb.File{},
+				sppb.ImportSelector{},
+				sppb.Position{},
+			)); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLexerParserParse(t *testing.T) {
+	files := []testtools.FileSpec{
+		{
+			Path:    "A.scala",
+			Content: "package a\nclass A\n",
+		},
+	}
+	tmpDir, cleanup := testtools.CreateFiles(t, files)
+	defer cleanup()
+
+	a := filepath.Join(tmpDir, "A.scala")
+	missing := filepath.Join(tmpDir, "Missing.scala")
+
+	got, err := NewLexerParser().Parse(context.Background(), &sppb.ParseRequest{
+		Filenames: []string{a, missing},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.ElapsedMillis = 0
+
+	want := &sppb.ParseResponse{
+		Files: []*sppb.File{
+			{
+				Filename: a,
+				Packages: []string{"a"},
+				Classes:  []string{"a.A"},
+				Degraded: true,
+			},
+			{
+				Filename: missing,
+				Error:    "open " + missing + ": no such file or directory",
+				Degraded: true,
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(
+		sppb.ParseResponse{},
+		sppb.File{},
+	)); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+}
@@ -4,10 +4,17 @@ import (
 	"bytes"
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/bazelbuild/bazel-gazelle/label"
+	"google.golang.org/protobuf/proto"
+
+	scpb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/cache"
 	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
 	"github.com/stackb/scala-gazelle/pkg/collections"
 )
@@ -15,74 +22,451 @@ import (
 const debugMemoParser = false
 
 // MemoParser is a Parser frontend that uses cached state of the files sha256
-// values are up-to-date.
+// values are up-to-date.  Results are cached per rule (all files unchanged)
+// and per file, such that only the changed files of a rule are parsed again.
 type MemoParser struct {
 	next  Parser
 	rules map[label.Label]*sppb.Rule
+	// visited is the set of rules parsed (or cache hits) during this run.
+	visited map[label.Label]bool
+	// parserVersion is the version of the parser backend, part of the key of
+	// the files cache.
+	parserVersion string
+	// files is the per-file cache.
+	files map[parsedFileKey]*scpb.ParsedFile
+	// seen is a mapping from a workspace-relative filename to the key of its
+	// content, for files that were parsed (or cache hits) during this run.
+	seen map[string]parsedFileKey
+	// stats is a mapping from a workspace-relative filename to the stat of the
+	// file when it was last hashed.
+	stats map[string]fileStat
+	// verifyFiles disables the stat fast path: every file is hashed.
+	verifyFiles bool
+	// cacheWriteNanos is the modification time of the cache file the stats
+	// are loaded from.  As with the git index, a stat whose mtime is at or
+	// after it is "racy": the file may have changed after it was hashed
+	// without changing its mtime, so the stat is not trusted.
+	cacheWriteNanos int64
+	// hashWorkers is the number of goroutines used to hash files.
+	hashWorkers int
+}
+
+// fileStat records the size and mtime of a file along with its hash.
+type fileStat struct {
+	size       int64
+	mtimeNanos int64
+	sha256     string
+}
+
+// parsedFileKey is the key of the files cache.
+type parsedFileKey struct {
+	sha256        string
+	parserVersion string
 }
 
 func NewMemoParser(next Parser) *MemoParser {
 	return &MemoParser{
-		next:  next,
-		rules: make(map[label.Label]*sppb.Rule),
+		next:    next,
+		rules:   make(map[label.Label]*sppb.Rule),
+		visited: make(map[label.Label]bool),
+		files:   make(map[parsedFileKey]*scpb.ParsedFile),
+		seen:    make(map[string]parsedFileKey),
+		stats:   make(map[string]fileStat),
+
+		hashWorkers: runtime.GOMAXPROCS(0),
 	}
 }
 
+// SetParserVersion sets the version of the parser backend.  Cached files
+// produced by a different version are not used.
+func (p *MemoParser) SetParserVersion(version string) {
+	p.parserVersion = version
+}
+
+// dialectVersion returns the version that is part of the key of files parsed
+// with the given dialect.  Files whose dialect was detected use the parser
+// version as is.
+func (p *MemoParser) dialectVersion(dialect string) string {
+	if dialect == "" {
+		return p.parserVersion
+	}
+	return p.parserVersion + "+" + dialect
+}
+
+// SetVerifyFiles determines if every file is hashed to validate the cache.
+// Otherwise, files whose size and mtime are unchanged since they were last
+// hashed are assumed to be unchanged.
+func (p *MemoParser) SetVerifyFiles(verify bool) {
+	p.verifyFiles = verify
+}
+
+// SetCacheWriteTime sets the modification time of the cache file that entries
+// are loaded from.  The stats of files modified at or after this time are not
+// trusted.  It must be called before LoadParsedFile; if not called, no stats
+// are trusted.
+func (p *MemoParser) SetCacheWriteTime(t time.Time) {
+	p.cacheWriteNanos = t.UnixNano()
+}
+
 // ParseScalaRule implements parser.Parser
-func (p *MemoParser) ParseScalaRule(kind string, from label.Label, dir string, srcs ...string) (*sppb.Rule, error) {
+func (p *MemoParser) ParseScalaRule(kind string, from label.Label, dialect, dir string, srcs ...string) (*sppb.Rule, error) {
+	result := p.ParseScalaRules([]*RuleRequest{{
+		Kind:    kind,
+		From:    from,
+		Dialect: dialect,
+		Dir:     dir,
+		Srcs:    srcs,
+	}})[0]
+	return result.Rule, result.Err
+}
+
+// memoRule is the state of a rule request in ParseScalaRules.
+type memoRule struct {
+	req         *RuleRequest
+	version     string
+	fileSha256s map[string]string
+	sha256      string
+	// cached are the files that were found in the files cache
+	cached []*sppb.File
+	// need are the srcs that need to be parsed
+	need []string
+	// result is set once the rule is done (a cache hit or an error)
+	result *RuleResult
+}
+
+// ParseScalaRules implements parser.RulesParser.  Rule and file cache hits are
+// resolved first; the files that need to be parsed are passed to the next
+// parser in a single call, such that they can be parsed concurrently.
+func (p *MemoParser) ParseScalaRules(requests []*RuleRequest) []*RuleResult {
+	rules := make([]*memoRule, len(requests))
+	var next []*RuleRequest
+	var nextRules []*memoRule
+	for i, req := range requests {
+		m := p.prepareRule(req)
+		rules[i] = m
+		if m.result == nil && len(m.need) > 0 {
+			next = append(next, &RuleRequest{
+				Kind:    req.Kind,
+				From:    req.From,
+				Dialect: req.Dialect,
+				Dir:     req.Dir,
+				Srcs:    m.need,
+			})
+			nextRules = append(nextRules, m)
+		}
+	}
+
+	parsed := make(map[*memoRule]*RuleResult, len(next))
+	if len(next) > 0 {
+		for i, result := range ParseScalaRules(p.next, next) {
+			parsed[nextRules[i]] = result
+		}
+	}
+
+	results := make([]*RuleResult, len(rules))
+	for i, m := range rules {
+		if m.result == nil {
+			rule, err := p.finishRule(m, parsed[m])
+			m.result = &RuleResult{Rule: rule, Err: err}
+		}
+		results[i] = m.result
+	}
+	return results
+}
+
+// prepareRule hashes the srcs of the given request and looks up the rule and
+// files caches.  The result of the returned rule is set if the rule is a cache
+// hit (or fails).
+func (p *MemoParser) prepareRule(req *RuleRequest) *memoRule {
+	from := req.From
+	srcs := req.Srcs
 	sort.Strings(srcs)
+	p.visited[from] = true
+	m := &memoRule{req: req, version: p.dialectVersion(req.Dialect)}
+
+	fileSha256s, err := p.hashFiles(from, req.Dir, srcs)
+	if err != nil {
+		m.result = &RuleResult{Err: err}
+		return m
+	}
+	m.fileSha256s = fileSha256s
 
 	var hash bytes.Buffer
 	for _, src := range srcs {
-		filename := filepath.Join(dir, src)
-		sha256, err := collections.FileSha256(filename)
-		if err != nil {
-			return nil, fmt.Errorf("hashing %s: %w", filename, err)
-		}
-		if _, err := hash.WriteString(sha256); err != nil {
-			return nil, err
+		if _, err := hash.WriteString(fileSha256s[src]); err != nil {
+			m.result = &RuleResult{Err: err}
+			return m
 		}
 	}
+	if _, err := hash.WriteString(m.version); err != nil {
+		m.result = &RuleResult{Err: err}
+		return m
+	}
 
 	sha256, err := collections.Sha256(&hash)
 	if err != nil {
-		return nil, fmt.Errorf("computing rule files sha256: %w", err)
+		m.result = &RuleResult{Err: fmt.Errorf("computing rule files sha256: %w", err)}
+		return m
 	}
+	m.sha256 = sha256
 
-	if rule, ok := p.rules[from]; ok && rule.Sha256 == sha256 {
+	// rules having degraded or failed files are parsed again, such that the
+	// full parser gets another chance.
+	if rule, ok := p.rules[from]; ok && rule.Sha256 == sha256 && !hasIncompleteFiles(rule) {
 		if debugMemoParser {
 			log.Printf("rule cache hit: %s", from)
 		}
-		return rule, nil
+		p.putParsedFiles(from, m.version, fileSha256s, rule.Files)
+		// the rule was loaded from the cache, its symbols are (re)provided as
+		// those of a visited rule.
+		if err := p.next.LoadScalaRule(from, rule); err != nil {
+			m.result = &RuleResult{Err: err}
+			return m
+		}
+		m.result = &RuleResult{Rule: rule}
+		return m
 	}
 	if debugMemoParser {
 		log.Printf("rule cache miss: %s (%s)", from, sha256)
 	}
 	if len(srcs) == 0 {
-		log.Panicf(`while parsing %s %s: no files to parse! (this is a bug)`, kind, from)
+		log.Panicf(`while parsing %s %s: no files to parse! (this is a bug)`, req.Kind, from)
 	}
 
-	rule, err := p.next.ParseScalaRule(kind, from, dir, srcs...)
-	if err != nil {
-		return nil, err
+	// partition the srcs into cached files and files that need to be parsed.
+	for _, src := range srcs {
+		filename := filepath.Join(from.Pkg, src)
+		key := parsedFileKey{fileSha256s[src], m.version}
+		if entry, ok := p.files[key]; ok {
+			file := proto.Clone(entry.File).(*sppb.File)
+			file.Filename = filename
+			m.cached = append(m.cached, file)
+			p.seen[filename] = key
+		} else {
+			m.need = append(m.need, src)
+		}
 	}
-	if rule == nil {
-		log.Panicf(`while parsing %s %s: ParseScalaRule did not return an error, but the returned rule was nil! (this is a bug) [%v]`, kind, from, srcs)
+	if debugMemoParser {
+		log.Printf("file cache: %s (%d hits, %d misses)", from, len(m.cached), len(m.need))
 	}
-	rule.Sha256 = sha256
+	return m
+}
+
+// finishRule combines the cached files of the given rule with the result of
+// parsing the remaining files (nil if there were none), and stores the rule
+// in the rule cache.
+func (p *MemoParser) finishRule(m *memoRule, parsed *RuleResult) (*sppb.Rule, error) {
+	kind := m.req.Kind
+	from := m.req.From
+
+	rule := &sppb.Rule{
+		Label: from.String(),
+		Kind:  kind,
+	}
+	if parsed != nil {
+		if parsed.Err != nil {
+			return nil, parsed.Err
+		}
+		if parsed.Rule == nil {
+			log.Panicf(`while parsing %s %s: ParseScalaRule did not return an error, but the returned rule was nil! (this is a bug) [%v]`, kind, from, m.need)
+		}
+		rule = parsed.Rule
+		p.putParsedFiles(from, m.version, m.fileSha256s, rule.Files)
+	}
+	if len(m.cached) > 0 {
+		// cached files still need to provide their symbols
+		if err := p.next.LoadScalaRule(from, &sppb.Rule{Label: rule.Label, Kind: kind, Files: m.cached}); err != nil {
+			return nil, err
+		}
+		rule.Files = append(rule.Files, m.cached...)
+		sortRuleFiles(rule.Files)
+	}
+	rule.Sha256 = m.sha256
 	p.rules[from] = rule
 
 	if debugMemoParser {
-		log.Printf("rule cache save: %s (%s)", from, sha256)
+		log.Printf("rule cache save: %s (%s)", from, m.sha256)
 	}
 
 	return rule, nil
 }
 
-// LoadScalaRule loads the given state.
+// hashFiles returns a mapping from src to the sha256 of the file content.
+// Files whose size and mtime match the last time they were hashed are not
+// hashed again (unless verifyFiles is set); the remainder are hashed in
+// parallel.
+func (p *MemoParser) hashFiles(from label.Label, dir string, srcs []string) (map[string]string, error) {
+	stats := make([]fileStat, len(srcs))
+	var todo []int
+	for i, src := range srcs {
+		filename := filepath.Join(dir, src)
+		info, err := os.Stat(filename)
+		if err != nil {
+			return nil, fmt.Errorf("hashing %s: %w", filename, err)
+		}
+		stats[i] = fileStat{size: info.Size(), mtimeNanos: info.ModTime().UnixNano()}
+		if prev, ok := p.stats[filepath.Join(from.Pkg, src)]; ok && !p.verifyFiles &&
+			prev.size == stats[i].size && prev.mtimeNanos == stats[i].mtimeNanos {
+			stats[i].sha256 = prev.sha256
+			continue
+		}
+		todo = append(todo, i)
+	}
+
+	errs := make([]error, len(srcs))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < p.hashWorkers && w < len(todo); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				filename := filepath.Join(dir, srcs[i])
+				sha256, err := collections.FileSha256(filename)
+				if err != nil {
+					errs[i] = fmt.Errorf("hashing %s: %w", filename, err)
+					continue
+				}
+				stats[i].sha256 = sha256
+			}
+		}()
+	}
+	for _, i := range todo {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	sha256s := make(map[string]string, len(srcs))
+	for i, src := range srcs {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		p.stats[filepath.Join(from.Pkg, src)] = stats[i]
+		sha256s[src] = stats[i].sha256
+	}
+	if debugMemoParser {
+		log.Printf("hashed %d of %d files: %s", len(todo), len(srcs), from)
+	}
+	return sha256s, nil
+}
+
+// putParsedFiles adds the given files of a rule to the files cache.  The
+// fileSha256s map is keyed by the filename relative to the rule package.
+// Files that failed to parse are not cached.  Degraded files are cached under
+// the version of the lexer parser that produced them, such that the parser
+// backend gets another chance.
+func (p *MemoParser) putParsedFiles(from label.Label, version string, fileSha256s map[string]string, files []*sppb.File) {
+	for _, file := range files {
+		if file.Error != "" {
+			continue
+		}
+		src, err := filepath.Rel(from.Pkg, file.Filename)
+		if err != nil {
+			continue
+		}
+		sha256, ok := fileSha256s[src]
+		if !ok {
+			continue
+		}
+		fileVersion := version
+		if file.Degraded {
+			fileVersion = (&LexerBackend{}).Version()
+		}
+		key := parsedFileKey{sha256, fileVersion}
+		p.seen[file.Filename] = key
+		if _, ok := p.files[key]; ok {
+			continue
+		}
+		p.files[key] = &scpb.ParsedFile{
+			Sha256:        sha256,
+			ParserVersion: fileVersion,
+			File:          file,
+		}
+	}
+}
+
+// LoadParsedFile loads the given files cache entry.  The stat of the entry is
+// only used if it is not racy (see SetCacheWriteTime).
+func (p *MemoParser) LoadParsedFile(entry *scpb.ParsedFile) {
+	if entry.File == nil {
+		return
+	}
+	p.files[parsedFileKey{entry.Sha256, entry.ParserVersion}] = entry
+	if entry.MtimeNanos != 0 && entry.MtimeNanos < p.cacheWriteNanos {
+		p.stats[entry.File.Filename] = fileStat{
+			size:       entry.Size,
+			mtimeNanos: entry.MtimeNanos,
+			sha256:     entry.Sha256,
+		}
+	}
+}
+
+// ParsedFiles returns the files cache entries sorted by filename.  Entries are
+// pruned if their file no longer exists in the given root directory, or if
+// the file was seen during this run with different content (or parser
+// version).
+func (p *MemoParser) ParsedFiles(root string) []*scpb.ParsedFile {
+	// entries that are referenced by a file seen during this run are retained,
+	// even if they were stored under a different filename.
+	referenced := make(map[parsedFileKey]bool, len(p.seen))
+	for _, key := range p.seen {
+		referenced[key] = true
+	}
+
+	entries := make([]*scpb.ParsedFile, 0, len(p.files))
+	for key, entry := range p.files {
+		if !referenced[key] {
+			if seenKey, ok := p.seen[entry.File.Filename]; ok && seenKey != key {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(root, entry.File.Filename)); err != nil {
+				continue
+			}
+		}
+		if stat, ok := p.stats[entry.File.Filename]; ok && stat.sha256 == entry.Sha256 {
+			entry.Size = stat.size
+			entry.MtimeNanos = stat.mtimeNanos
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a := entries[i]
+		b := entries[j]
+		if a.File.Filename != b.File.Filename {
+			return a.File.Filename < b.File.Filename
+		}
+		return a.ParserVersion < b.ParserVersion
+	})
+	return entries
+}
+
+func hasIncompleteFiles(rule *sppb.Rule) bool {
+	for _, file := range rule.Files {
+		if file.Degraded || file.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadScalaRule loads the given state.  Unlike other parsers, the rule is
+// only stored: its symbols are loaded by the next parser when the rule is
+// visited.
 func (p *MemoParser) LoadScalaRule(from label.Label, rule *sppb.Rule) error {
 	p.rules[from] = rule
-	return p.next.LoadScalaRule(from, rule)
+	return nil
+}
+
+// Visited reports whether the given rule was parsed (or was a cache hit)
+// during this run.
+func (p *MemoParser) Visited(from label.Label) bool {
+	return p.visited[from]
+}
+
+// RemoveScalaRule removes the given rule from the rule cache.
+func (p *MemoParser) RemoveScalaRule(from label.Label) {
+	delete(p.rules, from)
 }
 
 // ScalaRules returns a list of all scala rules sorted by label
@@ -121,6 +505,10 @@ func sortRuleFiles(files []*sppb.File) {
 		sort.Strings(file.Traits)
 		sort.Strings(file.Types)
 		sort.Strings(file.Vals)
+		sort.Strings(file.Enums)
+		sort.Strings(file.Givens)
+		sort.Strings(file.Defs)
+		sort.Strings(file.Exports)
 		sort.Strings(file.Names)
 	}
 }
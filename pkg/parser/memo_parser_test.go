@@ -0,0 +1,555 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/testtools"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	scpb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/cache"
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+	"github.com/stackb/scala-gazelle/pkg/collections"
+)
+
+// recordingParser is a Parser that records the srcs and dialect of each call.  Each file
+// 'Foo.scala' is reported as defining the class 'fake.Foo'.  Files named
+// 'Bad.scala' fail to parse; files named 'Degraded.scala' are degraded.
+type recordingParser struct {
+	parsed   [][]string
+	dialects []string
+	loaded   [][]string
+}
+
+func (p *recordingParser) ParseScalaRule(kind string, from label.Label, dialect, dir string, srcs ...string) (*sppb.Rule, error) {
+	p.parsed = append(p.parsed, srcs)
+	p.dialects = append(p.dialects, dialect)
+	rule := &sppb.Rule{Label: from.String(), Kind: kind}
+	for _, src := range srcs {
+		file := &sppb.File{Filename: filepath.Join(from.Pkg, src)}
+		switch src {
+		case "Bad.scala":
+			file.Error = "syntax error"
+		case "Degraded.scala":
+			file.Degraded = true
+		default:
+			file.Classes = []string{"fake." + strings.TrimSuffix(src, ".scala")}
+		}
+		rule.Files = append(rule.Files, file)
+	}
+	return rule, nil
+}
+
+func (p *recordingParser) LoadScalaRule(from label.Label, rule *sppb.Rule) error {
+	var filenames []string
+	for _, file := range rule.Files {
+		filenames = append(filenames, file.Filename)
+	}
+	p.loaded = append(p.loaded, filenames)
+	return nil
+}
+
+func TestMemoParserFileCache(t *testing.T) {
+	type parse struct {
+		pkg        string
+		srcs       []string
+		wantParsed [][]string
+		wantLoaded [][]string
+		wantFiles  []string
+	}
+
+	for name, tc := range map[string]struct {
+		files   []testtools.FileSpec
+		version string
+		// change is applied to the files before the second round.
+		change  func(t *testing.T, dir string)
+		first   parse
+		second  parse
+		wantErr string
+	}{
+		"unchanged rule is a rule cache hit": {
+			files: []testtools.FileSpec{
+				{Path: "a/A.scala", Content: "A"},
+				{Path: "a/B.scala", Content: "B"},
+			},
+			first: parse{
+				pkg:        "a",
+				srcs:       []string{"A.scala", "B.scala"},
+				wantParsed: [][]string{{"A.scala", "B.scala"}},
+				wantFiles:  []string{"a/A.scala", "a/B.scala"},
+			},
+			second: parse{
+				pkg:        "a",
+				srcs:       []string{"A.scala", "B.scala"},
+				wantLoaded: [][]string{{"a/A.scala", "a/B.scala"}},
+				wantFiles:  []string{"a/A.scala", "a/B.scala"},
+			},
+		},
+		"only changed files are parsed": {
+			files: []testtools.FileSpec{
+				{Path: "a/A.scala", Content: "A"},
+				{Path: "a/B.scala", Content: "B"},
+			},
+			change: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "a/B.scala"), "B2")
+			},
+			first: parse{
+				pkg:        "a",
+				srcs:       []string{"A.scala", "B.scala"},
+				wantParsed: [][]string{{"A.scala", "B.scala"}},
+				wantFiles:  []string{"a/A.scala", "a/B.scala"},
+			},
+			second: parse{
+				pkg:        "a",
+				srcs:       []string{"A.scala", "B.scala"},
+				wantParsed: [][]string{{"B.scala"}},
+				wantLoaded: [][]string{{"a/A.scala"}},
+				wantFiles:  []string{"a/A.scala", "a/B.scala"},
+			},
+		},
+		"files are shared across rules": {
+			files: []testtools.FileSpec{
+				{Path: "a/A.scala", Content: "same"},
+				{Path: "b/A.scala", Content: "same"},
+				{Path: "b/B.scala", Content: "B"},
+			},
+			first: parse{
+				pkg:        "a",
+				srcs:       []string{"A.scala"},
+				wantParsed: [][]string{{"A.scala"}},
+				wantFiles:  []string{"a/A.scala"},
+			},
+			second: parse{
+				pkg:        "b",
+				srcs:       []string{"A.scala", "B.scala"},
+				wantParsed: [][]string{{"B.scala"}},
+				wantLoaded: [][]string{{"b/A.scala"}},
+				wantFiles:  []string{"b/A.scala", "b/B.scala"},
+			},
+		},
+		"failed and degraded files are parsed again": {
+			files: []testtools.FileSpec{
+				{Path: "a/A.scala", Content: "A"},
+				{Path: "a/Bad.scala", Content: "Bad"},
+				{Path: "a/Degraded.scala", Content: "Degraded"},
+			},
+			version: "scalameta-1",
+			first: parse{
+				pkg:        "a",
+				srcs:       []string{"A.scala", "Bad.scala", "Degraded.scala"},
+				wantParsed: [][]string{{"A.scala", "Bad.scala", "Degraded.scala"}},
+				wantFiles:  []string{"a/A.scala", "a/Bad.scala", "a/Degraded.scala"},
+			},
+			second: parse{
+				pkg:        "a",
+				srcs:       []string{"A.scala", "Bad.scala", "Degraded.scala"},
+				wantParsed: [][]string{{"Bad.scala", "Degraded.scala"}},
+				wantLoaded: [][]string{{"a/A.scala"}},
+				wantFiles:  []string{"a/A.scala", "a/Bad.scala", "a/Degraded.scala"},
+			},
+		},
+		"missing file": {
+			first: parse{
+				pkg:  "a",
+				srcs: []string{"Missing.scala"},
+			},
+			wantErr: "hashing",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, cleanup := testtools.CreateFiles(t, tc.files)
+			defer cleanup()
+
+			next := &recordingParser{}
+			p := NewMemoParser(next)
+			p.SetParserVersion(tc.version)
+
+			for i, round := range []parse{tc.first, tc.second} {
+				if i == 1 && tc.change != nil {
+					tc.change(t, dir)
+				}
+				if round.pkg == "" {
+					continue
+				}
+				next.parsed = nil
+				next.loaded = nil
+
+				from := label.Label{Pkg: round.pkg, Name: "lib"}
+				rule, err := p.ParseScalaRule("scala_library", from, "", filepath.Join(dir, round.pkg), round.srcs...)
+				if tc.wantErr != "" {
+					if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr) {
+						t.Fatalf("expected error starting with %q, got %v", tc.wantErr, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var gotFiles []string
+				for _, file := range rule.Files {
+					gotFiles = append(gotFiles, file.Filename)
+				}
+				if diff := cmp.Diff(round.wantFiles, gotFiles); diff != "" {
+					t.Errorf("round %d files (-want +got):\n%s", i, diff)
+				}
+				if diff := cmp.Diff(round.wantParsed, next.parsed); diff != "" {
+					t.Errorf("round %d parsed (-want +got):\n%s", i, diff)
+				}
+				if diff := cmp.Diff(round.wantLoaded, next.loaded); diff != "" {
+					t.Errorf("round %d loaded (-want +got):\n%s", i, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoParserParsedFiles(t *testing.T) {
+	dir, cleanup := testtools.CreateFiles(t, []testtools.FileSpec{
+		{Path: "a/A.scala", Content: "A"},
+		{Path: "a/B.scala", Content: "B"},
+		{Path: "a/C.scala", Content: "C"},
+	})
+	defer cleanup()
+
+	from := label.Label{Pkg: "a", Name: "lib"}
+	srcDir := filepath.Join(dir, "a")
+
+	// first run: parse all files and save the entries
+	p := NewMemoParser(&recordingParser{})
+	p.SetParserVersion("v1")
+	if _, err := p.ParseScalaRule("scala_library", from, "", srcDir, "A.scala", "B.scala", "C.scala"); err != nil {
+		t.Fatal(err)
+	}
+	entries := p.ParsedFiles(dir)
+
+	// change B, delete C
+	writeFile(t, filepath.Join(srcDir, "B.scala"), "B2")
+	if err := os.Remove(filepath.Join(srcDir, "C.scala")); err != nil {
+		t.Fatal(err)
+	}
+
+	// second run: load the entries (but not the rules, as if the rule cache
+	// was evicted)
+	next := &recordingParser{}
+	p = NewMemoParser(next)
+	p.SetParserVersion("v1")
+	for _, entry := range entries {
+		p.LoadParsedFile(entry)
+	}
+	if _, err := p.ParseScalaRule("scala_library", from, "", srcDir, "A.scala", "B.scala"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([][]string{{"B.scala"}}, next.parsed); diff != "" {
+		t.Errorf("parsed (-want +got):\n%s", diff)
+	}
+
+	var got []string
+	for _, entry := range p.ParsedFiles(dir) {
+		got = append(got, entry.File.Filename+"@"+entry.ParserVersion)
+	}
+	if diff := cmp.Diff([]string{"a/A.scala@v1", "a/B.scala@v1"}, got); diff != "" {
+		t.Errorf("entries (-want +got):\n%s", diff)
+	}
+
+	// a different parser version does not use the entries
+	next = &recordingParser{}
+	p = NewMemoParser(next)
+	p.SetParserVersion("v2")
+	for _, entry := range entries {
+		p.LoadParsedFile(entry)
+	}
+	if _, err := p.ParseScalaRule("scala_library", from, "", srcDir, "A.scala"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([][]string{{"A.scala"}}, next.parsed); diff != "" {
+		t.Errorf("parsed (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(&scpb.ParsedFile{
+		Sha256:        entries[0].Sha256,
+		ParserVersion: "v2",
+		File:          &sppb.File{Filename: "a/A.scala", Classes: []string{"fake.A"}},
+	}, p.ParsedFiles(dir)[0],
+		cmpopts.IgnoreUnexported(scpb.ParsedFile{}, sppb.File{}),
+		cmpopts.IgnoreFields(scpb.ParsedFile{}, "Size", "MtimeNanos"),
+	); diff != "" {
+		t.Errorf("entry (-want +got):\n%s", diff)
+	}
+}
+
+func TestMemoParserDialect(t *testing.T) {
+	dir, cleanup := testtools.CreateFiles(t, []testtools.FileSpec{
+		{Path: "a/A.scala", Content: "A"},
+	})
+	defer cleanup()
+
+	from := label.Label{Pkg: "a", Name: "lib"}
+	srcDir := filepath.Join(dir, "a")
+
+	next := &recordingParser{}
+	p := NewMemoParser(next)
+	p.SetParserVersion("v1")
+	for _, dialect := range []string{"Scala3", "", "Scala3", ""} {
+		if _, err := p.ParseScalaRule("scala_library", from, dialect, srcDir, "A.scala"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// parsed once per dialect
+	if diff := cmp.Diff([][]string{{"A.scala"}, {"A.scala"}}, next.parsed); diff != "" {
+		t.Errorf("parsed (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"Scala3", ""}, next.dialects); diff != "" {
+		t.Errorf("dialects (-want +got):\n%s", diff)
+	}
+
+	var got []string
+	for _, entry := range p.ParsedFiles(dir) {
+		got = append(got, entry.File.Filename+"@"+entry.ParserVersion)
+	}
+	if diff := cmp.Diff([]string{"a/A.scala@v1"}, got); diff != "" {
+		t.Errorf("entries (-want +got):\n%s", diff)
+	}
+}
+
+// rulesRecordingParser is a recordingParser that also implements RulesParser,
+// recording the labels of the rules of each call.
+type rulesRecordingParser struct {
+	recordingParser
+	calls [][]string
+}
+
+func (p *rulesRecordingParser) ParseScalaRules(requests []*RuleRequest) []*RuleResult {
+	var call []string
+	results := make([]*RuleResult, len(requests))
+	for i, req := range requests {
+		call = append(call, req.From.String())
+		rule, err := p.ParseScalaRule(req.Kind, req.From, req.Dialect, req.Dir, req.Srcs...)
+		results[i] = &RuleResult{Rule: rule, Err: err}
+	}
+	p.calls = append(p.calls, call)
+	return results
+}
+
+func TestMemoParserParseScalaRules(t *testing.T) {
+	dir, cleanup := testtools.CreateFiles(t, []testtools.FileSpec{
+		{Path: "a/A.scala", Content: "A"},
+		{Path: "a/B.scala", Content: "B"},
+		{Path: "a/C.scala", Content: "C"},
+		{Path: "a/D.scala", Content: "D"},
+	})
+	defer cleanup()
+
+	srcDir := filepath.Join(dir, "a")
+	next := &rulesRecordingParser{}
+	p := NewMemoParser(next)
+	p.SetParserVersion("v1")
+
+	// //a:a is a rule cache hit and the file A.scala of //a:b a file cache hit
+	if _, err := p.ParseScalaRule("scala_library", label.Label{Pkg: "a", Name: "a"}, "", srcDir, "A.scala"); err != nil {
+		t.Fatal(err)
+	}
+
+	results := p.ParseScalaRules([]*RuleRequest{
+		{Kind: "scala_library", From: label.Label{Pkg: "a", Name: "a"}, Dir: srcDir, Srcs: []string{"A.scala"}},
+		{Kind: "scala_library", From: label.Label{Pkg: "a", Name: "b"}, Dir: srcDir, Srcs: []string{"B.scala", "A.scala"}},
+		{Kind: "scala_library", From: label.Label{Pkg: "a", Name: "c"}, Dir: srcDir, Srcs: []string{"C.scala", "D.scala"}},
+	})
+
+	// the first rule is parsed by itself (via ParseScalaRule), the misses of
+	// the others together
+	if diff := cmp.Diff([][]string{{"//a"}, {"//a:b", "//a:c"}}, next.calls); diff != "" {
+		t.Errorf("calls (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([][]string{{"A.scala"}, {"B.scala"}, {"C.scala", "D.scala"}}, next.parsed); diff != "" {
+		t.Errorf("parsed (-want +got):\n%s", diff)
+	}
+
+	var got [][]string
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		var classes []string
+		for _, file := range result.Rule.Files {
+			classes = append(classes, file.Classes...)
+		}
+		got = append(got, classes)
+	}
+	want := [][]string{
+		{"fake.A"},
+		{"fake.A", "fake.B"},
+		{"fake.C", "fake.D"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("classes (-want +got):\n%s", diff)
+	}
+}
+
+func TestMemoParserVerifyFiles(t *testing.T) {
+	for name, tc := range map[string]struct {
+		verify bool
+		// noCacheWriteTime skips SetCacheWriteTime
+		noCacheWriteTime bool
+		// cacheWriteDelay is the time between the mtime of the file and the
+		// write of the cache.
+		cacheWriteDelay time.Duration
+		wantParsed      [][]string
+	}{
+		"unchanged stat skips hashing": {
+			cacheWriteDelay: time.Second,
+			wantParsed:      nil,
+		},
+		"verify hashes every file": {
+			verify:          true,
+			cacheWriteDelay: time.Second,
+			wantParsed:      [][]string{{"A.scala"}},
+		},
+		"racy stat is hashed": {
+			cacheWriteDelay: 0,
+			wantParsed:      [][]string{{"A.scala"}},
+		},
+		"unknown cache write time hashes every file": {
+			noCacheWriteTime: true,
+			wantParsed:       [][]string{{"A.scala"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, cleanup := testtools.CreateFiles(t, []testtools.FileSpec{
+				{Path: "a/A.scala", Content: "A1"},
+			})
+			defer cleanup()
+
+			from := label.Label{Pkg: "a", Name: "lib"}
+			srcDir := filepath.Join(dir, "a")
+			filename := filepath.Join(srcDir, "A.scala")
+
+			p := NewMemoParser(&recordingParser{})
+			if _, err := p.ParseScalaRule("scala_library", from, "", srcDir, "A.scala"); err != nil {
+				t.Fatal(err)
+			}
+			entries := p.ParsedFiles(dir)
+			if len(entries) != 1 || entries[0].Size != 2 || entries[0].MtimeNanos == 0 {
+				t.Fatalf("expected entry with size and mtime, got %v", entries)
+			}
+
+			// change the content, but not the size and mtime
+			info, err := os.Stat(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeFile(t, filename, "A2")
+			if err := os.Chtimes(filename, info.ModTime(), info.ModTime()); err != nil {
+				t.Fatal(err)
+			}
+
+			next := &recordingParser{}
+			p = NewMemoParser(next)
+			p.SetVerifyFiles(tc.verify)
+			if !tc.noCacheWriteTime {
+				p.SetCacheWriteTime(info.ModTime().Add(tc.cacheWriteDelay))
+			}
+			for _, entry := range entries {
+				p.LoadParsedFile(entry)
+			}
+			if _, err := p.ParseScalaRule("scala_library", from, "", srcDir, "A.scala"); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.wantParsed, next.parsed); diff != "" {
+				t.Errorf("parsed (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMemoParserHashFilesParallel(t *testing.T) {
+	var files []testtools.FileSpec
+	var srcs []string
+	for i := 0; i < 50; i++ {
+		src := fmt.Sprintf("F%d.scala", i)
+		files = append(files, testtools.FileSpec{Path: "a/" + src, Content: src})
+		srcs = append(srcs, src)
+	}
+	dir, cleanup := testtools.CreateFiles(t, files)
+	defer cleanup()
+
+	p := NewMemoParser(&recordingParser{})
+	p.hashWorkers = 4
+	got, err := p.hashFiles(label.Label{Pkg: "a", Name: "lib"}, filepath.Join(dir, "a"), srcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range srcs {
+		want, err := collections.FileSha256(filepath.Join(dir, "a", src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[src] != want {
+			t.Errorf("%s: want %s, got %s", src, want, got[src])
+		}
+	}
+}
+
+func writeFile(t *testing.T, filename, content string) {
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoParserLoadScalaRule(t *testing.T) {
+	dir, cleanup := testtools.CreateFiles(t, []testtools.FileSpec{
+		{Path: "a/A.scala", Content: "A"},
+	})
+	defer cleanup()
+
+	a := label.Label{Pkg: "a", Name: "lib"}
+	b := label.Label{Pkg: "b", Name: "lib"}
+
+	// produce a cached rule for a
+	p := NewMemoParser(&recordingParser{})
+	rule, err := p.ParseScalaRule("scala_library", a, "", filepath.Join(dir, "a"), "A.scala")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := &recordingParser{}
+	p = NewMemoParser(next)
+	for _, cached := range []*sppb.Rule{rule, {Label: b.String(), Files: []*sppb.File{{Filename: "b/B.scala"}}}} {
+		from, _ := label.Parse(cached.Label)
+		if err := p.LoadScalaRule(from, cached); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if next.loaded != nil {
+		t.Errorf("loaded rules should not be delegated until visited, got %v", next.loaded)
+	}
+
+	if _, err := p.ParseScalaRule("scala_library", a, "", filepath.Join(dir, "a"), "A.scala"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([][]string{{"a/A.scala"}}, next.loaded); diff != "" {
+		t.Errorf("loaded (-want +got):\n%s", diff)
+	}
+	if next.parsed != nil {
+		t.Errorf("expected rule cache hit, got parsed %v", next.parsed)
+	}
+
+	if !p.Visited(a) {
+		t.Errorf("want %s visited", a)
+	}
+	if p.Visited(b) {
+		t.Errorf("want %s not visited", b)
+	}
+	if got := len(p.ScalaRules()); got != 2 {
+		t.Errorf("want 2 rules, got %d", got)
+	}
+	p.RemoveScalaRule(b)
+	if got := len(p.ScalaRules()); got != 1 {
+		t.Errorf("want 1 rule after remove, got %d", got)
+	}
+}
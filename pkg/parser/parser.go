@@ -12,6 +12,45 @@ type Parser interface {
 	LoadScalaRule(from label.Label, rule *sppb.Rule) error
 
 	// ParseScalaRule is used to parse a list of source files.  The srcs list
-	// is expected to be relative to dir.
-	ParseScalaRule(kind string, from label.Label, dir string, srcs ...string) (*sppb.Rule, error)
+	// is expected to be relative to dir.  The dialect names the scalameta
+	// dialect of the .scala files; if empty, the dialect is detected.
+	ParseScalaRule(kind string, from label.Label, dialect, dir string, srcs ...string) (*sppb.Rule, error)
+}
+
+// RuleRequest is a request to parse the srcs of a rule, having the same
+// meaning as the arguments of Parser.ParseScalaRule.
+type RuleRequest struct {
+	Kind    string
+	From    label.Label
+	Dialect string
+	Dir     string
+	Srcs    []string
+}
+
+// RuleResult is the result of a RuleRequest.
+type RuleResult struct {
+	Rule *sppb.Rule
+	Err  error
+}
+
+// RulesParser is implemented by parsers that can parse several rules at
+// once, such that the rules can be parsed concurrently.
+type RulesParser interface {
+	// ParseScalaRules parses the given rules.  The results are in request
+	// order.
+	ParseScalaRules(requests []*RuleRequest) []*RuleResult
+}
+
+// ParseScalaRules parses the given rules with the given parser.  If the parser
+// does not implement RulesParser, the rules are parsed one at a time.
+func ParseScalaRules(p Parser, requests []*RuleRequest) []*RuleResult {
+	if rp, ok := p.(RulesParser); ok {
+		return rp.ParseScalaRules(requests)
+	}
+	results := make([]*RuleResult, len(requests))
+	for i, req := range requests {
+		rule, err := p.ParseScalaRule(req.Kind, req.From, req.Dialect, req.Dir, req.Srcs...)
+		results[i] = &RuleResult{Rule: rule, Err: err}
+	}
+	return results
 }
@@ -0,0 +1,306 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+// PoolWorker is a parser backend that can be managed by a ParserPool.
+// *ScalametaParser satisfies this interface.
+type PoolWorker interface {
+	// Start starts the worker.
+	Start() error
+	// Stop stops the worker.  A stopped worker can be started again.
+	Stop()
+	// IsRunning reports whether the worker is healthy.
+	IsRunning() bool
+	// Parse parses the files in the given request.
+	Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error)
+}
+
+// WorkerStats records timing and lifecycle statistics for a single worker of
+// a ParserPool.
+type WorkerStats struct {
+	// ID is the index of the worker in the pool
+	ID int
+	// Requests is the number of successful requests handled by the worker
+	Requests int
+	// Files is the number of files parsed by the worker
+	Files int
+	// Restarts is the number of times the worker was restarted
+	Restarts int
+	// Elapsed is the cumulative time spent in successful requests
+	Elapsed time.Duration
+}
+
+// String returns a one-line summary of the stats.
+func (s WorkerStats) String() string {
+	return fmt.Sprintf("worker %d: %d requests, %d files, %d restarts, %v",
+		s.ID, s.Requests, s.Files, s.Restarts, s.Elapsed.Round(time.Millisecond))
+}
+
+type ParserPoolOption func(*ParserPool) *ParserPool
+
+// WithPoolSize sets the number of workers in the pool.
+func WithPoolSize(size int) ParserPoolOption {
+	return func(p *ParserPool) *ParserPool {
+		p.size = size
+		return p
+	}
+}
+
+// WithPoolMaxRetries sets the number of times a failed request is retried
+// (each time on a restarted worker).
+func WithPoolMaxRetries(retries int) ParserPoolOption {
+	return func(p *ParserPool) *ParserPool {
+		p.maxRetries = retries
+		return p
+	}
+}
+
+// WithPoolLogger sets the logger of the pool.
+func WithPoolLogger(logger zerolog.Logger) ParserPoolOption {
+	return func(p *ParserPool) *ParserPool {
+		p.logger = logger
+		return p
+	}
+}
+
+var defaultPoolOptions = []ParserPoolOption{
+	WithPoolSize(1),
+	WithPoolMaxRetries(1),
+}
+
+// NewParserPool creates a new pool whose workers are created by the given
+// function.  The pool must be started before use.
+func NewParserPool(newWorker func(id int) PoolWorker, options ...ParserPoolOption) *ParserPool {
+	p := &ParserPool{newWorker: newWorker}
+	for _, opt := range append(defaultPoolOptions, options...) {
+		p = opt(p)
+	}
+	if p.size < 1 {
+		p.size = 1
+	}
+	return p
+}
+
+// ParserPool spreads parse requests across a number of workers.  Workers are
+// health-checked before each request; a worker that has crashed (or fails a
+// request) is restarted and the request is retried.
+type ParserPool struct {
+	logger     zerolog.Logger
+	newWorker  func(id int) PoolWorker
+	size       int
+	maxRetries int
+
+	mu      sync.Mutex
+	workers []*pooledWorker
+	idle    chan *pooledWorker
+}
+
+type pooledWorker struct {
+	worker PoolWorker
+	stats  WorkerStats
+}
+
+// Size returns the number of workers in the pool.
+func (p *ParserPool) Size() int {
+	return p.size
+}
+
+// Start creates and starts all workers.  Starting a pool that is already
+// started has no effect.
+func (p *ParserPool) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.idle != nil {
+		return nil
+	}
+
+	idle := make(chan *pooledWorker, p.size)
+	for i := 0; i < p.size; i++ {
+		w := &pooledWorker{
+			worker: p.newWorker(i),
+			stats:  WorkerStats{ID: i},
+		}
+		if err := w.worker.Start(); err != nil {
+			p.stopWorkers()
+			return fmt.Errorf("starting parser worker %d: %w", i, err)
+		}
+		p.workers = append(p.workers, w)
+		idle <- w
+	}
+	p.idle = idle
+	return nil
+}
+
+// Stop stops all workers.
+func (p *ParserPool) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopWorkers()
+}
+
+// stopWorkers stops all workers and drains the idle channel, such that a
+// stopped worker is never handed out.  p.mu must be held.
+func (p *ParserPool) stopWorkers() {
+	for _, w := range p.workers {
+		w.worker.Stop()
+	}
+	p.workers = nil
+	if p.idle == nil {
+		return
+	}
+	for {
+		select {
+		case <-p.idle:
+		default:
+			p.idle = nil
+			return
+		}
+	}
+}
+
+// Stats returns a snapshot of the per-worker statistics.
+func (p *ParserPool) Stats() []WorkerStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]WorkerStats, len(p.workers))
+	for i, w := range p.workers {
+		stats[i] = w.stats
+	}
+	return stats
+}
+
+// Parse implements the same contract as ScalametaParser.Parse.  The files in
+// the request are split into (at most) one batch per worker; the batches are
+// parsed concurrently and the results are merged in request order.  Parse may
+// be called concurrently, in which case the batches of all requests share the
+// workers.
+func (p *ParserPool) Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	p.mu.Lock()
+	idle := p.idle
+	p.mu.Unlock()
+
+	if idle == nil {
+		return nil, status.Error(codes.FailedPrecondition, "parser pool not started")
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "ParseRequest is required")
+	}
+
+	t1 := time.Now()
+
+	batches := partitionParseRequest(in, p.size)
+	responses := make([]*sppb.ParseResponse, len(batches))
+	errs := make([]error, len(batches))
+
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch *sppb.ParseRequest) {
+			defer wg.Done()
+			var w *pooledWorker
+			select {
+			case w = <-idle:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { idle <- w }()
+			responses[i], errs[i] = p.parseWith(ctx, w, batch)
+		}(i, batch)
+	}
+	wg.Wait()
+
+	response := &sppb.ParseResponse{}
+	for i, r := range responses {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if r.Error != "" && response.Error == "" {
+			response.Error = r.Error
+		}
+		response.Files = append(response.Files, r.Files...)
+	}
+	response.ElapsedMillis = time.Since(t1).Milliseconds()
+
+	return response, nil
+}
+
+// parseWith parses the request with the given worker, restarting the worker
+// and retrying the request if it fails.
+func (p *ParserPool) parseWith(ctx context.Context, w *pooledWorker, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	for attempt := 0; ; attempt++ {
+		if !w.worker.IsRunning() {
+			p.logger.Warn().Msgf("parser worker %d is not running, restarting", w.stats.ID)
+			if err := p.restart(w); err != nil {
+				return nil, err
+			}
+		}
+
+		t1 := time.Now()
+		response, err := w.worker.Parse(ctx, in)
+		if err == nil {
+			p.mu.Lock()
+			w.stats.Requests++
+			w.stats.Files += len(in.Filenames)
+			w.stats.Elapsed += time.Since(t1)
+			p.mu.Unlock()
+			return response, nil
+		}
+		if ctx.Err() != nil || attempt >= p.maxRetries {
+			return nil, err
+		}
+
+		p.logger.Warn().Err(err).Msgf("parser worker %d failed, restarting and retrying", w.stats.ID)
+		if err := p.restart(w); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *ParserPool) restart(w *pooledWorker) error {
+	w.worker.Stop()
+	if err := w.worker.Start(); err != nil {
+		return fmt.Errorf("restarting parser worker %d: %w", w.stats.ID, err)
+	}
+	p.mu.Lock()
+	w.stats.Restarts++
+	p.mu.Unlock()
+	return nil
+}
+
+// partitionParseRequest splits the filenames of the given request into at most
+// n contiguous batches of similar size.  A request with no filenames is
+// returned as-is.
+func partitionParseRequest(in *sppb.ParseRequest, n int) []*sppb.ParseRequest {
+	if len(in.Filenames) <= 1 || n <= 1 {
+		return []*sppb.ParseRequest{in}
+	}
+	if n > len(in.Filenames) {
+		n = len(in.Filenames)
+	}
+	batches := make([]*sppb.ParseRequest, 0, n)
+	start := 0
+	for i := 0; i < n; i++ {
+		end := start + (len(in.Filenames)-start)/(n-i)
+		batch := proto.Clone(in).(*sppb.ParseRequest)
+		batch.Filenames = in.Filenames[start:end]
+		batches = append(batches, batch)
+		start = end
+	}
+	return batches
+}
@@ -0,0 +1,299 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+// fakeWorker is a PoolWorker that "parses" a file by echoing its name.
+type fakeWorker struct {
+	mu       sync.Mutex
+	running  bool
+	starts   int
+	requests [][]string
+	// crashes is the number of subsequent requests that should fail by
+	// crashing the worker.
+	crashes int
+	// startErr is returned by Start, if set.
+	startErr error
+}
+
+func (w *fakeWorker) Start() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.startErr != nil {
+		return w.startErr
+	}
+	w.running = true
+	w.starts++
+	return nil
+}
+
+func (w *fakeWorker) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running = false
+}
+
+func (w *fakeWorker) IsRunning() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.running
+}
+
+func (w *fakeWorker) Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.requests = append(w.requests, in.Filenames)
+	if w.crashes > 0 {
+		w.crashes--
+		w.running = false
+		return nil, errors.New("connection refused")
+	}
+	if len(in.Filenames) == 0 {
+		return &sppb.ParseResponse{Error: "bad request"}, nil
+	}
+	response := &sppb.ParseResponse{}
+	for _, filename := range in.Filenames {
+		response.Files = append(response.Files, &sppb.File{Filename: filename})
+	}
+	return response, nil
+}
+
+func TestParserPoolParse(t *testing.T) {
+	for name, tc := range map[string]struct {
+		size       int
+		maxRetries int
+		// crashes is the number of initial crashes per worker
+		crashes     int
+		filenames   []string
+		want        *sppb.ParseResponse
+		wantErr     string
+		wantBatches [][]string
+		wantStarts  []int
+	}{
+		"degenerate": {
+			size:        2,
+			want:        &sppb.ParseResponse{Error: "bad request"},
+			wantBatches: [][]string{nil},
+			wantStarts:  []int{1, 1},
+		},
+		"single worker": {
+			size:      1,
+			filenames: []string{"A.scala", "B.scala"},
+			want: &sppb.ParseResponse{
+				Files: []*sppb.File{{Filename: "A.scala"}, {Filename: "B.scala"}},
+			},
+			wantBatches: [][]string{{"A.scala", "B.scala"}},
+			wantStarts:  []int{1},
+		},
+		"batches are spread across workers": {
+			size:      2,
+			filenames: []string{"A.scala", "B.scala", "C.scala", "D.scala", "E.scala"},
+			want: &sppb.ParseResponse{
+				Files: []*sppb.File{
+					{Filename: "A.scala"},
+					{Filename: "B.scala"},
+					{Filename: "C.scala"},
+					{Filename: "D.scala"},
+					{Filename: "E.scala"},
+				},
+			},
+			wantBatches: [][]string{
+				{"A.scala", "B.scala"},
+				{"C.scala", "D.scala", "E.scala"},
+			},
+			wantStarts: []int{1, 1},
+		},
+		"more workers than files": {
+			size:      3,
+			filenames: []string{"A.scala", "B.scala"},
+			want: &sppb.ParseResponse{
+				Files: []*sppb.File{{Filename: "A.scala"}, {Filename: "B.scala"}},
+			},
+			wantBatches: [][]string{{"A.scala"}, {"B.scala"}},
+			wantStarts:  []int{1, 1, 1},
+		},
+		"crashed worker is restarted and request retried": {
+			size:       1,
+			maxRetries: 1,
+			crashes:    1,
+			filenames:  []string{"A.scala"},
+			want: &sppb.ParseResponse{
+				Files: []*sppb.File{{Filename: "A.scala"}},
+			},
+			wantBatches: [][]string{{"A.scala"}, {"A.scala"}},
+			wantStarts:  []int{2},
+		},
+		"retries exhausted": {
+			size:        1,
+			maxRetries:  1,
+			crashes:     2,
+			filenames:   []string{"A.scala"},
+			wantErr:     "connection refused",
+			wantBatches: [][]string{{"A.scala"}, {"A.scala"}},
+			wantStarts:  []int{2},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var workers []*fakeWorker
+			pool := NewParserPool(func(id int) PoolWorker {
+				w := &fakeWorker{crashes: tc.crashes}
+				workers = append(workers, w)
+				return w
+			}, WithPoolSize(tc.size), WithPoolMaxRetries(tc.maxRetries))
+			if err := pool.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer pool.Stop()
+
+			got, err := pool.Parse(context.Background(), &sppb.ParseRequest{
+				Filenames: tc.filenames,
+			})
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if tc.wantErr != gotErr {
+				t.Fatalf("error: want %q, got %q", tc.wantErr, gotErr)
+			}
+			if got != nil {
+				got.ElapsedMillis = 0
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(
+				sppb.ParseResponse{},
+				sppb.File{},
+			)); diff != "" {
+				t.Errorf("response (-want +got):\n%s", diff)
+			}
+
+			// the assignment of batches to workers is not deterministic, so
+			// compare the sorted list of batches.
+			var gotBatches [][]string
+			var gotStarts []int
+			for _, w := range workers {
+				gotBatches = append(gotBatches, w.requests...)
+				gotStarts = append(gotStarts, w.starts)
+			}
+			sort.Slice(gotBatches, func(i, j int) bool {
+				return strings.Join(gotBatches[i], ",") < strings.Join(gotBatches[j], ",")
+			})
+			if diff := cmp.Diff(tc.wantBatches, gotBatches); diff != "" {
+				t.Errorf("requests (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantStarts, gotStarts); diff != "" {
+				t.Errorf("starts (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParserPoolHealthCheck(t *testing.T) {
+	worker := &fakeWorker{}
+	pool := NewParserPool(func(id int) PoolWorker { return worker })
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+
+	// simulate the process dying between requests
+	worker.Stop()
+
+	if _, err := pool.Parse(context.Background(), &sppb.ParseRequest{
+		Filenames: []string{"A.scala"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := pool.Stats()
+	if len(stats) != 1 {
+		t.Fatalf("want 1 worker stats, got %d", len(stats))
+	}
+	stats[0].Elapsed = 0
+	if diff := cmp.Diff(WorkerStats{ID: 0, Requests: 1, Files: 1, Restarts: 1}, stats[0]); diff != "" {
+		t.Errorf("stats (-want +got):\n%s", diff)
+	}
+	if got := stats[0].String(); got != "worker 0: 1 requests, 1 files, 1 restarts, 0s" {
+		t.Errorf("stats string: got %q", got)
+	}
+}
+
+func TestParserPoolStartStop(t *testing.T) {
+	var workers []*fakeWorker
+	pool := NewParserPool(func(id int) PoolWorker {
+		w := &fakeWorker{}
+		workers = append(workers, w)
+		return w
+	}, WithPoolSize(2))
+
+	// starting twice does not start a second set of workers
+	for i := 0; i < 2; i++ {
+		if err := pool.Start(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(workers) != 2 {
+		t.Fatalf("want 2 workers, got %d", len(workers))
+	}
+
+	// a stopped pool does not hand out its (stopped) workers
+	pool.Stop()
+	_, err := pool.Parse(context.Background(), &sppb.ParseRequest{
+		Filenames: []string{"A.scala"},
+	})
+	if got, want := status.Code(err), codes.FailedPrecondition; got != want {
+		t.Errorf("parse after stop: want %v, got %v", want, err)
+	}
+	for i, w := range workers {
+		if len(w.requests) != 0 || w.IsRunning() {
+			t.Errorf("worker %d: want stopped and unused, got %d requests (running: %t)", i, len(w.requests), w.IsRunning())
+		}
+	}
+}
+
+func TestParserPoolStartError(t *testing.T) {
+	pool := NewParserPool(func(id int) PoolWorker {
+		return &fakeWorker{startErr: errors.New("no node")}
+	})
+	err := pool.Start()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got, want := err.Error(), "starting parser worker 0: no node"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+// TestParserPoolConcurrentStats asserts that the stats may be read while the
+// pool is started and stopped (run with -race).
+func TestParserPoolConcurrentStats(t *testing.T) {
+	pool := NewParserPool(func(id int) PoolWorker {
+		return &fakeWorker{}
+	}, WithPoolSize(2))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			pool.Stats()
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		if err := pool.Start(); err != nil {
+			t.Fatal(err)
+		}
+		pool.Stop()
+	}
+	<-done
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/rs/zerolog"
+)
+
+const (
+	scalaParserTransportFlagName = "scala_parser_transport"
+	scalaParserAddressFlagName   = "scala_parser_address"
+)
+
+func init() {
+	GlobalBackendRegistry().PutBackend("scalameta", func() Backend {
+		return &ScalametaBackend{}
+	})
+}
+
+// ScalametaBackend is the default parser backend.  Workers run the embedded
+// node/scalameta parser, or connect to an already running parser server.
+type ScalametaBackend struct {
+	// transport is the transport used to communicate with parser processes.
+	transport string
+	// address is the optional host:port of an already running parser server.
+	address string
+}
+
+// Name implements part of the parser.Backend interface.
+func (b *ScalametaBackend) Name() string {
+	return "scalameta"
+}
+
+// RegisterFlags implements part of the parser.Backend interface.
+func (b *ScalametaBackend) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {
+	fs.StringVar(&b.transport, scalaParserTransportFlagName, TransportHTTP, "transport used to communicate with the parser (http|grpc)")
+	fs.StringVar(&b.address, scalaParserAddressFlagName, "", "optional host:port of an already running parser server; if set, no parser process is started")
+}
+
+// CheckFlags implements part of the parser.Backend interface.
+func (b *ScalametaBackend) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	switch b.transport {
+	case TransportHTTP, TransportGRPC:
+		return nil
+	default:
+		return fmt.Errorf("-%s: unknown transport %q (want %s or %s)", scalaParserTransportFlagName, b.transport, TransportHTTP, TransportGRPC)
+	}
+}
+
+// Version implements part of the parser.Backend interface.  The version is
+// derived from the embedded parser script and scalameta bundle.
+func (b *ScalametaBackend) Version() string {
+	h := sha256.New()
+	h.Write([]byte(parserrMjs))
+	h.Write([]byte(scalametaParsersIndexJs))
+	return fmt.Sprintf("%s-%x", b.Name(), h.Sum(nil)[:8])
+}
+
+// NewWorker implements part of the parser.Backend interface.
+func (b *ScalametaBackend) NewWorker(id int, logger zerolog.Logger) PoolWorker {
+	return NewScalametaParser(
+		WithLogger(logger),
+		WithTransport(b.transport),
+		WithAddress(b.address),
+	)
+}
@@ -14,7 +14,9 @@ import (
 	"path/filepath"
 	"time"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 
@@ -32,6 +34,13 @@ const (
 	debugParse = false
 )
 
+const (
+	// TransportHTTP is the transport that posts JSON requests over HTTP.
+	TransportHTTP = "http"
+	// TransportGRPC is the transport that calls the Parser grpc service.
+	TransportGRPC = "grpc"
+)
+
 type ScalametaParserOption func(*ScalametaParser) *ScalametaParser
 
 func WithHttpPort(port int) ScalametaParserOption {
@@ -48,6 +57,24 @@ func WithHttpClientTimeout(timeout time.Duration) ScalametaParserOption {
 	}
 }
 
+// WithTransport sets the transport used to communicate with the parser
+// (TransportHTTP or TransportGRPC).
+func WithTransport(transport string) ScalametaParserOption {
+	return func(sp *ScalametaParser) *ScalametaParser {
+		sp.transport = transport
+		return sp
+	}
+}
+
+// WithAddress configures the parser to connect to an already running parser
+// server at the given host:port rather than starting a new process.
+func WithAddress(address string) ScalametaParserOption {
+	return func(sp *ScalametaParser) *ScalametaParser {
+		sp.address = address
+		return sp
+	}
+}
+
 func WithLogger(logger zerolog.Logger) ScalametaParserOption {
 	return func(sp *ScalametaParser) *ScalametaParser {
 		sp.logger = logger
@@ -58,6 +85,7 @@ func WithLogger(logger zerolog.Logger) ScalametaParserOption {
 var defaultOptions = []ScalametaParserOption{
 	WithHttpPort(0),
 	WithHttpClientTimeout(60 * time.Second),
+	WithTransport(TransportHTTP),
 }
 
 func NewScalametaParser(options ...ScalametaParserOption) *ScalametaParser {
@@ -70,12 +98,18 @@ func NewScalametaParser(options ...ScalametaParserOption) *ScalametaParser {
 }
 
 // ScalametaParser is a service that communicates to a scalameta-js parser
-// backend over HTTP.
+// backend over HTTP or grpc.
 type ScalametaParser struct {
 	sppb.UnimplementedParserServer
 
 	logger zerolog.Logger
 
+	// transport is one of TransportHTTP or TransportGRPC
+	transport string
+	// address is the host:port of an already running parser server.  If
+	// empty, a parser process is started.
+	address string
+
 	process    *memexec.Exec
 	processDir string
 	cmd        *exec.Cmd
@@ -83,6 +117,9 @@ type ScalametaParser struct {
 	httpClient *http.Client
 	httpUrl    string
 
+	grpcConn   *grpc.ClientConn
+	grpcClient sppb.ParserClient
+
 	httpClientTimout time.Duration
 	httpPort         int
 }
@@ -92,6 +129,11 @@ func (s *ScalametaParser) Stop() {
 		s.httpClient.CloseIdleConnections()
 		s.httpClient = nil
 	}
+	if s.grpcConn != nil {
+		s.grpcConn.Close()
+		s.grpcConn = nil
+		s.grpcClient = nil
+	}
 	if s.cmd != nil {
 		s.cmd.Process.Kill()
 		s.cmd = nil
@@ -109,8 +151,21 @@ func (s *ScalametaParser) Stop() {
 }
 
 // IsRunning reports whether the parser process is alive.
-// ProcessState is set by Wait(); if non-nil the process has exited.
+// ProcessState is set by Wait(); if non-nil the process has exited.  When
+// connected to an existing server, it reports whether the server accepts
+// connections.
 func (s *ScalametaParser) IsRunning() bool {
+	if s.address != "" {
+		if s.httpClient == nil && s.grpcConn == nil {
+			return false
+		}
+		conn, err := net.DialTimeout("tcp", s.address, time.Second)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
 	if s.cmd == nil {
 		return false
 	}
@@ -118,6 +173,16 @@ func (s *ScalametaParser) IsRunning() bool {
 }
 
 func (s *ScalametaParser) Start() error {
+	switch s.transport {
+	case TransportHTTP, TransportGRPC:
+	default:
+		return fmt.Errorf("unknown parser transport %q (want %q or %q)", s.transport, TransportHTTP, TransportGRPC)
+	}
+
+	if s.address != "" {
+		return s.connect(s.address)
+	}
+
 	t1 := time.Now()
 
 	//
@@ -179,6 +244,7 @@ func (s *ScalametaParser) Start() error {
 	cmd.Env = []string{
 		"NODE_PATH=" + processDir,
 		fmt.Sprintf("PORT=%d", s.httpPort),
+		"TRANSPORT=" + s.transport,
 	}
 	// Don't inherit stdin from parent process
 	cmd.Stdin = nil
@@ -222,17 +288,8 @@ func (s *ScalametaParser) Start() error {
 
 	s.logger.Debug().Msgf("Started parser: %s", s.httpUrl)
 
-	//
-	// Setup the http client
-	//
-	s.httpClient = &http.Client{
-		Timeout: s.httpClientTimout,
-		Transport: &http.Transport{
-			Dial: (&net.Dialer{
-				Timeout: 5 * time.Second,
-			}).Dial,
-			TLSHandshakeTimeout: 5 * time.Second,
-		},
+	if err := s.connect(fmt.Sprintf("127.0.0.1:%d", s.httpPort)); err != nil {
+		return err
 	}
 
 	t2 := time.Since(t1).Round(1 * time.Millisecond)
@@ -243,9 +300,50 @@ func (s *ScalametaParser) Start() error {
 	return nil
 }
 
+// connect sets up the client for the configured transport.
+func (s *ScalametaParser) connect(address string) error {
+	switch s.transport {
+	case TransportGRPC:
+		conn, err := grpc.NewClient(address,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultCallOptions(grpc.ForceCodec(NewJSONCodec())),
+		)
+		if err != nil {
+			return fmt.Errorf("dialing parser server %s: %w", address, err)
+		}
+		s.grpcConn = conn
+		s.grpcClient = sppb.NewParserClient(conn)
+	default:
+		s.httpUrl = "http://" + address
+		s.httpClient = &http.Client{
+			Timeout: s.httpClientTimout,
+			Transport: &http.Transport{
+				Dial: (&net.Dialer{
+					Timeout: 5 * time.Second,
+				}).Dial,
+				TLSHandshakeTimeout: 5 * time.Second,
+			},
+		}
+	}
+	s.logger.Debug().Msgf("Connected to parser: %s (%s)", address, s.transport)
+	return nil
+}
+
 func (s *ScalametaParser) Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
 	s.logger.Debug().Msgf("new parse request: %+v", in)
 
+	if s.grpcClient != nil {
+		if in == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "ParseRequest is required")
+		}
+		if s.httpClientTimout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.httpClientTimout)
+			defer cancel()
+		}
+		return s.grpcClient.Parse(ctx, in)
+	}
+
 	req, err := newHttpParseRequest(s.httpUrl, in)
 	if err != nil {
 		return nil, err
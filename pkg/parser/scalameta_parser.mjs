@@ -4,6 +4,7 @@
  */
 import * as fs from 'node:fs';
 import * as http from 'node:http';
+import * as http2 from 'node:http2';
 import { Worker, parentPort, workerData, isMainThread } from 'node:worker_threads';
 import { Console } from 'node:console';
 import { parseSource } from 'scalameta-parsers';
@@ -12,6 +13,11 @@ const __filename = new URL('', import.meta.url).pathname;
 const debug = false;
 const wantNameTypes = false;
 
+// scala2Dialect and scala3Dialect are the scalameta dialect names used when
+// the dialect is detected.
+const scala2Dialect = 'Scala213';
+const scala3Dialect = 'Scala3';
+
 // enableNestedImports will capture imports not at the top-level.  This can be
 // useful, but in-practive is often used to narrow an import already named at
 // the top-level, which then must be suppressed with resolve directives.
@@ -105,7 +111,12 @@ class Scope {
  * by walking the AST.
  */
 class ScalaFile {
-    constructor(filename) {
+    /**
+     * @param {string} filename
+     * @param {string|undefined} dialect The scalameta dialect name.  If
+     * empty, the dialect is detected.
+     */
+    constructor(filename, dialect) {
         /**
          * a console that always prints to stderr.
          */
@@ -116,6 +127,12 @@ class ScalaFile {
          */
         this.filename = filename;
 
+        /**
+         * The requested dialect, or undefined to detect it.
+         * @type {string|undefined}
+         */
+        this.dialect = dialect || undefined;
+
         /**
          * The raw parse tree.
          */
@@ -180,12 +197,52 @@ class ScalaFile {
          */
         this.topTraits = new Set();
 
+        /**
+         * A set of top-level objects that define a main method, qualified by
+         * their package name.
+         * @type {Set<string>}
+         */
+        this.mainObjects = new Set();
+
+        /**
+         * A set of top-level (Scala 3) enums, qualified by their package name.
+         * @type {Set<string>}
+         */
+        this.topEnums = new Set();
+
+        /**
+         * A set of top-level (Scala 3) named givens, qualified by their
+         * package name.
+         * @type {Set<string>}
+         */
+        this.topGivens = new Set();
+
+        /**
+         * A set of top-level methods (including extension methods),
+         * qualified by their package name.
+         * @type {Set<string>}
+         */
+        this.topDefs = new Set();
+
+        /**
+         * A set of names in (Scala 3) export clauses.
+         * @type {Set<string>}
+         */
+        this.exports = new Set();
+
         /**
          * A set of names anywhere in the file.
          * @type {Set<string>}
          */
         this.names = new Set();
 
+        /**
+         * Import selectors that are renamed, hidden, or nested, keyed by
+         * '{imp}|{alias}'.
+         * @type {Map<string,{imp:string,name:string,alias:string,hidden:boolean,nested:boolean}>}
+         */
+        this.selectors = new Map();
+
         /**
          * If type, trait, or class extends another symbol, record that here.
          * Key is the package-qualified-name, value is a an object with a list
@@ -193,9 +250,83 @@ class ScalaFile {
          * @type {Map<string,{classes:Array<string>}>}
          */
         this.extendsMap = new Map();
+
+        /**
+         * The offsets of the start of each line in the source text.  Used to
+         * convert node positions into line/column numbers.
+         * @type {Array<number>}
+         */
+        this.lineStarts = [0];
+
+        /**
+         * Mapping from an import to the position it was first named.
+         * @type {Map<string,{line:number,column:number}>}
+         */
+        this.importPositions = new Map();
+
+        /**
+         * Mapping from a provided symbol to the position of its definition.
+         * @type {Map<string,{line:number,column:number}>}
+         */
+        this.definitionPositions = new Map();
+
+        /**
+         * Mapping from a name to the position it was first used.
+         * @type {Map<string,{line:number,column:number}>}
+         */
+        this.namePositions = new Map();
+    }
+
+    /**
+     * Computes the 1-based line and column of the given node.
+     * @param {Node|undefined} node
+     * @returns {{line:number,column:number}|undefined}
+     */
+    positionOf(node) {
+        if (!node || !node.pos) {
+            return undefined;
+        }
+        const offset = node.pos.start;
+        let lo = 0;
+        let hi = this.lineStarts.length - 1;
+        while (lo < hi) {
+            const mid = (lo + hi + 1) >> 1;
+            if (this.lineStarts[mid] <= offset) {
+                lo = mid;
+            } else {
+                hi = mid - 1;
+            }
+        }
+        return { line: lo + 1, column: offset - this.lineStarts[lo] + 1 };
     }
 
-    addName(name) {
+    /**
+     * Records the position of the given node under the key, unless a
+     * position was already recorded.
+     * @param {Map<string,{line:number,column:number}>} positions
+     * @param {string} key
+     * @param {Node|undefined} node
+     */
+    putPosition(positions, key, node) {
+        if (positions.has(key)) {
+            return;
+        }
+        const pos = this.positionOf(node);
+        if (pos) {
+            positions.set(key, pos);
+        }
+    }
+
+    /**
+     * Records the position of a definition.
+     * @param {string} qName
+     * @param {Node|undefined} node
+     */
+    putDefinitionPosition(qName, node) {
+        this.putPosition(this.definitionPositions, qName, node);
+    }
+
+    addName(name, node) {
         switch (name) {
             case "-":
             case "->":
@@ -222,6 +353,7 @@ class ScalaFile {
             return;
         }
         this.names.add(name);
+        this.putPosition(this.namePositions, name, node);
     }
 
     /**
@@ -232,7 +364,9 @@ class ScalaFile {
             this.console.log('Parsing', this.filename);
         }
         const buffer = fs.readFileSync(this.filename);
-        const tree = parseSource(buffer.toString());
+        const source = buffer.toString();
+        this.lineStarts = lineStarts(source);
+        const tree = this.parseSource(source);
         // this.printNode(tree);
         if (tree.error) {
             this.console.log('Parse error:', this.filename);
@@ -247,7 +381,11 @@ class ScalaFile {
                 return false
             }
             if (enableNestedImports && node.type === 'Import') {
-                this.visitImport(node);
+                this.visitImport(node, isNested(stack));
+                return false;
+            }
+            if (node.type === 'Export') {
+                this.visitExport(node);
                 return false;
             }
 
@@ -257,7 +395,7 @@ class ScalaFile {
                     const type = this.stackTypeName(node, stack);
                     name = `${name}<${type}>`;
                 }
-                this.addName(name);
+                this.addName(name, node);
             }
             return true;
         });
@@ -265,6 +403,32 @@ class ScalaFile {
         this.visitNode(tree);
     }
 
+    /**
+     * parseSource parses the given source text.  If a dialect was not
+     * requested, the source is parsed as Scala 2.13 first; if that fails it is
+     * parsed again as Scala 3.  When both fail, the Scala 2.13 error is
+     * returned.
+     * @param {string} source
+     * @returns {!Object} The parse tree (or an object having an error).
+     */
+    parseSource(source) {
+        if (this.dialect) {
+            return parseSource(source, { dialect: this.dialect });
+        }
+        const tree = parseSource(source);
+        if (!tree.error) {
+            return tree;
+        }
+        const tree3 = parseSource(source, { dialect: scala3Dialect });
+        if (tree3.error) {
+            return tree;
+        }
+        if (debug) {
+            this.console.log('Parsed as', scala3Dialect, this.filename);
+        }
+        return tree3;
+    }
+
     /**
      * currentScope returns the top of the scope stack.
      * @returns {!Scope}
@@ -393,6 +557,24 @@ class ScalaFile {
             case 'Defn.Type':
                 this.visitDefnType(node);
                 break;
+            case 'Defn.Def':
+                this.visitDefnDef(node);
+                break;
+            case 'Defn.Enum':
+                this.visitDefnEnum(node);
+                break;
+            case 'Defn.Given':
+                this.visitDefnGiven(node);
+                break;
+            case 'Defn.GivenAlias':
+                this.visitDefnGivenAlias(node);
+                break;
+            case 'Defn.ExtensionGroup':
+                this.visitDefnExtensionGroup(node);
+                break;
+            case 'Export':
+                this.visitExport(node);
+                break;
             case 'Template':
                 this.visitTemplate(node);
                 break;
@@ -435,6 +617,7 @@ class ScalaFile {
         const name = this.parseName(node.name);
         this.topObjects.add(this.packageQualifiedName(name));
         this.packages.add(this.packageQualifiedName(name));
+        this.putDefinitionPosition(this.packageQualifiedName(name), node.name);
 
         this.pkgs.push(name);
         this.visitNode(node.templ);
@@ -445,30 +628,87 @@ class ScalaFile {
         this.visitStats(node.stats);
     }
 
-    visitImport(node) {
-        node.importers.forEach(importer => this.visitImporter(importer));
+    /**
+     * @param {Node} node
+     * @param {boolean|undefined} nested true if the import is not at the
+     * top-level of the file.
+     */
+    visitImport(node, nested) {
+        node.importers.forEach(importer => this.visitImporter(importer, nested));
+    }
+
+    /**
+     * Records an import selector.  The first occurrence of a selector wins.
+     * @param {{imp:string,name:(string|undefined),alias:(string|undefined),hidden:(boolean|undefined),nested:(boolean|undefined)}} selector
+     */
+    addSelector(selector) {
+        const key = [selector.imp, selector.alias || ''].join('|');
+        if (this.selectors.has(key)) {
+            return;
+        }
+        const obj = { imp: selector.imp };
+        if (selector.name) {
+            obj.name = selector.name;
+        }
+        if (selector.alias) {
+            obj.alias = selector.alias;
+        }
+        if (selector.hidden) {
+            obj.hidden = true;
+        }
+        if (selector.nested) {
+            obj.nested = true;
+        }
+        this.selectors.set(key, obj);
     }
 
-    visitImporter(node) {
+    visitImporter(node, nested) {
         const ref = this.parseName(node.ref);
         const scope = this.currentScope();
         node.importees.forEach(importee => {
+            const addImport = (imp, sym) => {
+                scope.addImport(imp, sym);
+                this.putPosition(this.importPositions, imp, importee);
+                if (nested) {
+                    this.addSelector({ imp, name: sym, nested });
+                }
+            };
             switch (importee.type) {
                 case 'Importee.Name':
-                    scope.addImport([ref, importee.name.value].join('.'), importee.name.value)
+                    // 'import a.*' parsed with a Scala 2 dialect
+                    if (importee.name.value === '*') {
+                        addImport([ref, '_'].join('.'))
+                        break;
+                    }
+                    addImport([ref, importee.name.value].join('.'), importee.name.value)
                     break;
-                case 'Importee.Rename':
-                    scope.addImport([ref, importee.name.value].join('.'), importee.name.value)
+                case 'Importee.Given':
+                case 'Importee.GivenAll':
+                    // 'import a.given' or 'import a.{given Foo}': the given
+                    // instances are members of the qualifier.
+                    addImport(ref)
                     break;
+                case 'Importee.Rename': {
+                    // 'import a.{B => C}': the local name 'C' refers to 'a.B'
+                    const imp = [ref, importee.name.value].join('.');
+                    const alias = importee.rename.value;
+                    scope.addImport(imp, alias);
+                    this.putPosition(this.importPositions, imp, importee);
+                    this.addSelector({ imp, name: importee.name.value, alias, nested });
+                    break;
+                }
                 case 'Importee.Unimport':
-                    // an unimport is specifically excluded from the scala
-                    // import symbol table, but since it still implies an
-                    // interaction with the package we go ahead and index it
-                    // here.
-                    scope.addImport([ref, importee.name.value].join('.'))
+                    // 'import a.{D => _}': the name is hidden, and must not
+                    // produce a dependency.
+                    this.addSelector({
+                        imp: [ref, importee.name.value].join('.'),
+                        name: importee.name.value,
+                        hidden: true,
+                        nested,
+                    });
                     break;
                 case 'Importee.Wildcard':
-                    scope.addImport([ref, '_'].join('.'))
+                    addImport([ref, '_'].join('.'))
                     break;
                 default:
                     this.console.log('unhandled importee type', importee.type);
@@ -476,17 +716,84 @@ class ScalaFile {
         });
     }
 
+    /**
+     * visitExport records the names in an export clause.  Export clauses are
+     * only captured when the qualifier is (likely) a fully-qualified name;
+     * 'export impl.*' for a local member 'impl' is not an import.
+     * @param {Node} node
+     */
+    visitExport(node) {
+        node.importers.forEach(importer => {
+            const ref = this.parseName(importer.ref);
+            if (!ref || ref.indexOf('.') === -1) {
+                return;
+            }
+            importer.importees.forEach(importee => {
+                const addExport = (name) => {
+                    this.exports.add(name);
+                    this.putPosition(this.importPositions, name, importee);
+                };
+                switch (importee.type) {
+                    case 'Importee.Name':
+                    case 'Importee.Rename':
+                        if (importee.name.value === '*') {
+                            addExport([ref, '_'].join('.'));
+                        } else {
+                            addExport([ref, importee.name.value].join('.'));
+                        }
+                        break;
+                    case 'Importee.Wildcard':
+                        addExport([ref, '_'].join('.'));
+                        break;
+                    case 'Importee.Given':
+                    case 'Importee.GivenAll':
+                        addExport(ref);
+                        break;
+                }
+            });
+        });
+    }
+
     visitDefnObject(node) {
         const name = this.parseName(node.name);
         const qName = this.packageQualifiedName(name);
         this.topObjects.add(qName);
+        this.putDefinitionPosition(qName, node.name);
         this.parseExtends('object', qName, node);
+        if (this.hasMainMethod(node)) {
+            this.mainObjects.add(qName);
+        }
+    }
+
+    /**
+     * hasMainMethod returns true if the template of the given object defines
+     * a method of the form 'def main(args: Array[String])'.
+     * @param {Node} node
+     * @returns {boolean}
+     */
+    hasMainMethod(node) {
+        const templ = node.templ;
+        if (!templ) {
+            return false;
+        }
+        const stats = templ.stats || (templ.body && templ.body.stats) || [];
+        for (const stat of stats) {
+            if (stat.type !== 'Defn.Def' || !stat.name || stat.name.value !== 'main') {
+                continue;
+            }
+            const params = defParams(stat);
+            if (params.length === 1 && params[0].decltpe && this.parseName(params[0].decltpe) === 'Array') {
+                return true;
+            }
+        }
+        return false;
     }
 
     visitDefnClass(node) {
         const name = this.parseName(node.name);
         const qName = this.packageQualifiedName(name);
         this.topClasses.add(qName);
+        this.putDefinitionPosition(qName, node.name);
         this.parseExtends('class', qName, node);
         this.visitStats(node.stats);
     }
@@ -495,6 +802,7 @@ class ScalaFile {
         const name = this.parseName(node.name);
         const qName = this.packageQualifiedName(name);
         this.topTraits.add(qName);
+        this.putDefinitionPosition(qName, node.name);
         this.parseExtends('trait', qName, node);
         this.visitStats(node.stats);
     }
@@ -503,19 +811,77 @@ class ScalaFile {
         // TODO(pcj): what are the reasonable vars to record?
         if (Array.isArray(node.pats) && node.pats.length && node.pats[0].type == "Pat.Var" && node.pats[0].name) {
             const name = this.parseName(node.pats[0].name);
-            this.topVals.add(this.packageQualifiedName(name));
+            const qName = this.packageQualifiedName(name);
+            this.topVals.add(qName);
+            this.putDefinitionPosition(qName, node.pats[0].name);
         }
     }
 
     visitDefnType(node) {
         const name = this.parseName(node.name);
-        this.topTypes.add(this.packageQualifiedName(name));
+        const qName = this.packageQualifiedName(name);
+        this.topTypes.add(qName);
+        this.putDefinitionPosition(qName, node.name);
+    }
+
+    visitDefnDef(node) {
+        const name = this.parseName(node.name);
+        if (name) {
+            const qName = this.packageQualifiedName(name);
+            this.topDefs.add(qName);
+            this.putDefinitionPosition(qName, node.name);
+        }
+    }
+
+    visitDefnEnum(node) {
+        const name = this.parseName(node.name);
+        const qName = this.packageQualifiedName(name);
+        this.topEnums.add(qName);
+        this.putDefinitionPosition(qName, node.name);
+        this.parseExtends('enum', qName, node);
+    }
+
+    visitDefnGiven(node) {
+        // anonymous givens have a synthetic name that is not useful here
+        const name = node.name && this.parseName(node.name);
+        if (!name) {
+            return;
+        }
+        const qName = this.packageQualifiedName(name);
+        this.topGivens.add(qName);
+        this.putDefinitionPosition(qName, node.name);
+        this.parseExtends('given', qName, node);
+    }
+
+    visitDefnGivenAlias(node) {
+        const name = node.name && this.parseName(node.name);
+        if (name) {
+            const qName = this.packageQualifiedName(name);
+            this.topGivens.add(qName);
+            this.putDefinitionPosition(qName, node.name);
+        }
+    }
+
+    visitDefnExtensionGroup(node) {
+        const body = node.body;
+        if (!body) {
+            return;
+        }
+        if (body.type === 'Defn.Def') {
+            this.visitDefnDef(body);
+            return;
+        }
+        for (const stat of body.stats || []) {
+            if (stat.type === 'Defn.Def') {
+                this.visitDefnDef(stat);
+            }
+        }
     }
 
     parseExtends(type, qName, node) {
         const key = `${type} ${qName}`;
         if (node.templ) {
-            for (const init of node.templ.inits) {
+            for (const init of node.templ.inits || []) {
                 // this.printNode(init);
                 if (init.tpe) {
                     const tpe = this.parseName(init.tpe);
@@ -583,7 +949,27 @@ class ScalaFile {
         maybeAssignList(this.topVals, 'vals');
         maybeAssignList(this.topTypes, 'types');
         maybeAssignList(this.names, 'names');
+        maybeAssignList(this.mainObjects, 'mainObjects');
+        maybeAssignList(this.topEnums, 'enums');
+        maybeAssignList(this.topGivens, 'givens');
+        maybeAssignList(this.topDefs, 'defs');
+        maybeAssignList(this.exports, 'exports');
         maybeAssignMap(this.extendsMap, 'extends');
+        if (this.selectors.size) {
+            obj.importSelectors = Array.from(this.selectors.values()).sort((a, b) => {
+                if (a.imp !== b.imp) {
+                    return a.imp < b.imp ? -1 : 1;
+                }
+                const aa = a.alias || '';
+                const ba = b.alias || '';
+                return aa < ba ? -1 : aa > ba ? 1 : 0;
+            });
+        }
+        if (request.wantPositions) {
+            maybeAssignMap(this.importPositions, 'importPositions');
+            maybeAssignMap(this.definitionPositions, 'definitionPositions');
+            maybeAssignMap(this.namePositions, 'namePositions');
+        }
 
         return obj;
     }
@@ -657,6 +1043,46 @@ class ScalaFile {
 
 }
 
+/**
+ * defParams returns the list of parameters of a method definition that has a
+ * single parameter clause.  Handles both the 'paramss' and the (4.10+)
+ * 'paramClauseGroups' tree shapes.
+ *
+ * @param {Node} node A Defn.Def node
+ * @returns {!Array<Node>}
+ */
+function defParams(node) {
+    if (Array.isArray(node.paramss)) {
+        return node.paramss.length === 1 ? node.paramss[0] : [];
+    }
+    if (Array.isArray(node.paramClauseGroups) && node.paramClauseGroups.length === 1) {
+        const clauses = node.paramClauseGroups[0].paramClauses || [];
+        if (clauses.length === 1) {
+            return clauses[0].values || [];
+        }
+    }
+    return [];
+}
+
+/**
+ * isNested returns true if the given stack of enclosing nodes has any node
+ * that is not a source file or package clause.
+ * @param {Array<Node>} stack
+ * @returns {boolean}
+ */
+function isNested(stack) {
+    for (const node of stack) {
+        switch (node.type) {
+            case 'Source':
+            case 'Pkg':
+            case 'Pkg.Body':
+                continue;
+        }
+        return true;
+    }
+    return false;
+}
+
 /**
  * parseFile parses a single file.
  * 
@@ -666,7 +1092,7 @@ class ScalaFile {
  */
 function parseFile(request, filename) {
     try {
-        const file = new ScalaFile(filename);
+        const file = new ScalaFile(filename, request.dialect);
         file.parse();
         return file.toFile(request);
     } catch (e) {
@@ -750,8 +1176,83 @@ const requestHandler = (req, res) => {
     });
 }
 
+// grpcParsePath is the method path of the Parser.Parse rpc.
+const grpcParsePath = '/build.stack.gazelle.scala.parse.Parser/Parse';
+
+/**
+ * Send a grpc response having the given (optional) message and status.
+ * @param {!http2.ServerHttp2Stream} stream
+ * @param {?Object} message
+ * @param {number} code The grpc status code
+ * @param {string=} errorMessage
+ */
+function sendGrpcResponse(stream, message, code, errorMessage) {
+    stream.respond({
+        ':status': 200,
+        'content-type': 'application/grpc+json',
+    }, { waitForTrailers: true });
+    stream.on('wantTrailers', () => {
+        const trailers = { 'grpc-status': String(code) };
+        if (errorMessage) {
+            trailers['grpc-message'] = encodeURIComponent(errorMessage);
+        }
+        stream.sendTrailers(trailers);
+    });
+    if (message) {
+        const body = Buffer.from(JSON.stringify(message));
+        const frame = Buffer.alloc(5 + body.length);
+        frame.writeUInt8(0, 0);
+        frame.writeUInt32BE(body.length, 1);
+        body.copy(frame, 5);
+        stream.end(frame);
+    } else {
+        stream.end();
+    }
+}
+
+/**
+ * grpcStreamHandler serves the Parser service over h2c.  Messages are encoded
+ * as JSON (content-type 'application/grpc+json'), matching the 'json' codec of
+ * the go client.  Errors of individual files are reported in the 'error' field
+ * of the file; a request that fails as a whole is reported with the INTERNAL
+ * status code.
+ * @param {!http2.ServerHttp2Stream} stream
+ * @param {!Object} headers
+ */
+const grpcStreamHandler = (stream, headers) => {
+    if (headers[':path'] !== grpcParsePath) {
+        sendGrpcResponse(stream, null, 12 /* UNIMPLEMENTED */, `unknown method: ${headers[':path']}`);
+        return;
+    }
+    const contentType = headers['content-type'] || '';
+    if (!contentType.startsWith('application/grpc+json')) {
+        sendGrpcResponse(stream, null, 3 /* INVALID_ARGUMENT */, `unsupported content-type: ${contentType} (only application/grpc+json is supported)`);
+        return;
+    }
+
+    const chunks = [];
+    stream.on('data', (chunk) => chunks.push(chunk));
+    stream.on('end', async () => {
+        const data = Buffer.concat(chunks);
+        if (data.length < 5 || data.readUInt8(0) !== 0) {
+            sendGrpcResponse(stream, null, 3 /* INVALID_ARGUMENT */, 'expected a single uncompressed message');
+            return;
+        }
+        const length = data.readUInt32BE(1);
+        try {
+            const request = JSON.parse(data.subarray(5, 5 + length).toString());
+            const result = await processJSONRequest(request);
+            sendGrpcResponse(stream, result, 0);
+        } catch (err) {
+            sendGrpcResponse(stream, null, 13 /* INTERNAL */, err.message);
+        }
+    });
+}
+
 if (isMainThread) {
-    const server = http.createServer(requestHandler)
+    const server = process.env.TRANSPORT === 'grpc'
+        ? http2.createServer().on('stream', grpcStreamHandler)
+        : http.createServer(requestHandler);
     const port = process.env.PORT || 3000;
     server.listen(port, (err) => {
         if (err) {
@@ -767,6 +1268,21 @@ if (isMainThread) {
     parentPort.postMessage(result);
 }
 
+/**
+ * lineStarts returns the offsets of the start of each line in the given text.
+ * @param {string} text
+ * @returns {Array<number>}
+ */
+function lineStarts(text) {
+    const starts = [0];
+    for (let i = 0; i < text.length; i++) {
+        if (text.charCodeAt(i) === 10 /* '\n' */) {
+            starts.push(i + 1);
+        }
+    }
+    return starts;
+}
+
 /**
  * Determine if the given string starts with a lowercase letter.
  *
@@ -118,6 +118,10 @@ object Main extends LazyLogging {
 							"String",
 							"Unit",
 						},
+						MainObjects: []string{"example.Main"},
+						ImportSelectors: []*sppb.ImportSelector{
+							{Imp: "corp.common.core.reports.DotFormatReport", Name: "DotFormatReport", Nested: true},
+						},
 					},
 				},
 			},
@@ -201,6 +205,9 @@ object Palette {
 							"Seq",
 							"Seq.tabulate",
 						},
+						ImportSelectors: []*sppb.ImportSelector{
+							{Imp: "scala.util.Random.nextInt", Name: "nextInt", Alias: "rint", Nested: true},
+						},
 					},
 				},
 			},
@@ -247,6 +254,9 @@ object Main {
 							"String",
 							"Unit",
 						},
+						ImportSelectors: []*sppb.ImportSelector{
+							{Imp: "MainContext._", Nested: true},
+						},
 					},
 				},
 			},
@@ -427,6 +437,9 @@ object PeerLink extends LazyLogging {
 							"Timer",
 							"Unit",
 						},
+						ImportSelectors: []*sppb.ImportSelector{
+							{Imp: "scala.jdk.CollectionConverters._", Nested: true},
+						},
 					},
 				},
 			},
@@ -555,6 +568,150 @@ case class TradingAccountUser(
 				},
 			},
 		},
+		"import selectors": {
+			files: []testtools.FileSpec{
+				{
+					Path: "F.scala",
+					Content: `
+package a
+
+import b.{C => D, E => _, _}
+
+class F extends D
+`,
+				},
+			},
+			want: sppb.ParseResponse{
+				Files: []*sppb.File{
+					{
+						Filename: "F.scala",
+						Packages: []string{"a"},
+						Classes:  []string{"a.F"},
+						Imports:  []string{"b.C", "b._"},
+						Extends: map[string]*sppb.ClassList{
+							"class a.F": {
+								Classes: []string{"b.C"},
+							},
+						},
+						Names: []string{"D", "F"},
+						ImportSelectors: []*sppb.ImportSelector{
+							{Imp: "b.C", Name: "C", Alias: "D"},
+							{Imp: "b.E", Name: "E", Hidden: true},
+						},
+					},
+				},
+			},
+		},
+		"chained package clauses": {
+			files: []testtools.FileSpec{
+				{
+					Path: "F.scala",
+					Content: `
+package a.b
+package c
+
+import d.E
+
+object F extends E
+`,
+				},
+			},
+			want: sppb.ParseResponse{
+				Files: []*sppb.File{
+					{
+						Filename: "F.scala",
+						Packages: []string{"a.b", "a.b.c"},
+						Objects:  []string{"a.b.c.F"},
+						Imports:  []string{"d.E"},
+						Extends: map[string]*sppb.ClassList{
+							"object a.b.c.F": {
+								Classes: []string{"d.E"},
+							},
+						},
+						Names: []string{"E", "F"},
+					},
+				},
+			},
+		},
+		"positions": {
+			in: sppb.ParseRequest{WantPositions: true},
+			files: []testtools.FileSpec{
+				{
+					Path: "FooTest.scala",
+					Content: `
+package foo
+
+import org.scalatest.FlatSpec
+
+class FooTest extends FlatSpec
+`,
+				},
+			},
+			want: sppb.ParseResponse{
+				Files: []*sppb.File{
+					{
+						Filename: "FooTest.scala",
+						Packages: []string{"foo"},
+						Classes:  []string{"foo.FooTest"},
+						Imports:  []string{"org.scalatest.FlatSpec"},
+						Extends: map[string]*sppb.ClassList{
+							"class foo.FooTest": {
+								Classes: []string{"org.scalatest.FlatSpec"},
+							},
+						},
+						Names: []string{"FlatSpec", "FooTest"},
+						ImportPositions: map[string]*sppb.Position{
+							"org.scalatest.FlatSpec": {Line: 4, Column: 22},
+						},
+						DefinitionPositions: map[string]*sppb.Position{
+							"foo.FooTest": {Line: 6, Column: 7},
+						},
+						NamePositions: map[string]*sppb.Position{
+							"FlatSpec": {Line: 6, Column: 23},
+							"FooTest":  {Line: 6, Column: 7},
+						},
+					},
+				},
+			},
+		},
+		"scala3": {
+			files: []testtools.FileSpec{
+				{
+					Path: "Main.scala",
+					Content: `
+package com.example
+
+import com.example.codecs.{given, *}
+
+enum Color:
+  case Red, Green
+
+given defaultName: String = "world"
+
+def greet(name: String): String = name
+
+extension (c: Color)
+  def hex: String = "#fff"
+
+export com.example.codecs.Codec
+`,
+				},
+			},
+			want: sppb.ParseResponse{
+				Files: []*sppb.File{
+					{
+						Filename: "Main.scala",
+						Packages: []string{"com.example"},
+						Imports:  []string{"com.example.codecs", "com.example.codecs._"},
+						Names:    []string{"Color", "Green", "Red", "String"},
+						Enums:    []string{"com.example.Color"},
+						Givens:   []string{"com.example.defaultName"},
+						Defs:     []string{"com.example.greet", "com.example.hex"},
+						Exports:  []string{"com.example.codecs.Codec"},
+					},
+				},
+			},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			tmpDir, err := bazel.NewTmpDir("")
@@ -583,6 +740,8 @@ case class TradingAccountUser(
 				sppb.ParseResponse{},
 				sppb.File{},
 				sppb.ClassList{},
+				sppb.Position{},
+				sppb.ImportSelector{},
 			)); diff != "" {
 				t.Errorf(".Parse (-want +got):\n%s", diff)
 			}
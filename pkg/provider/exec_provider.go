@@ -0,0 +1,330 @@
+package provider
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/bazelbuild/buildtools/build"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pvpb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/provider"
+	"github.com/stackb/scala-gazelle/pkg/protobuf"
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+)
+
+const (
+	// ExecFormatJSON is the exec protocol where requests and responses are
+	// protojson messages, one per line.
+	ExecFormatJSON = "json"
+	// ExecFormatProto is the exec protocol where requests and responses are
+	// varint length-delimited binary protobuf messages.
+	ExecFormatProto = "proto"
+)
+
+// execShutdownTimeout is how long the process is given to exit after the
+// shutdown request before it is killed.
+const execShutdownTimeout = 5 * time.Second
+
+// ExecProvider is a provider of symbols that delegates to an external command.
+// The command is started when the provider is enabled and speaks the protocol
+// described by build.stack.gazelle.scala.provider.Request: it streams the
+// symbols in response to the initialize request, then answers can_provide
+// queries until the shutdown request.  Messages are either protojson
+// terminated by a newline (format 'json') or varint length-delimited binary
+// protobuf (format 'proto').  Stderr is passed through.
+type ExecProvider struct {
+	name    string
+	format  string
+	command string
+	args    []string
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	// canProvide memoizes answers by dep and from label
+	canProvide map[canProvideKey]bool
+}
+
+// canProvideKey is the key of a memoized can_provide answer.  The from label
+// is part of the key as it is sent to the command (and a relative dep means
+// something different in every package).
+type canProvideKey struct {
+	dep, from label.Label
+}
+
+// NewExecProvider constructs a new provider having the given name that runs
+// the command with the given arguments.
+func NewExecProvider(name, format, command string, args ...string) *ExecProvider {
+	return &ExecProvider{
+		name:       name,
+		format:     format,
+		command:    command,
+		args:       args,
+		canProvide: make(map[canProvideKey]bool),
+	}
+}
+
+// ParseExecProviderSpec parses a spec of the form 'NAME[:FORMAT]=COMMAND
+// [ARG...]'.  The format defaults to json.
+func ParseExecProviderSpec(spec string) (*ExecProvider, error) {
+	name, commandLine, ok := strings.Cut(spec, "=")
+	if !ok {
+		return nil, fmt.Errorf("invalid exec symbol provider %q: want NAME[:FORMAT]=COMMAND [ARG...]", spec)
+	}
+	format := ExecFormatJSON
+	if n, f, ok := strings.Cut(name, ":"); ok {
+		name, format = n, f
+	}
+	if name == "" {
+		return nil, fmt.Errorf("invalid exec symbol provider %q: name must not be empty", spec)
+	}
+	switch format {
+	case ExecFormatJSON, ExecFormatProto:
+	default:
+		return nil, fmt.Errorf("invalid exec symbol provider %q: unknown format %q (want %s or %s)", spec, format, ExecFormatJSON, ExecFormatProto)
+	}
+	fields := strings.Fields(commandLine)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("invalid exec symbol provider %q: command must not be empty", spec)
+	}
+	return NewExecProvider(name, format, fields[0], fields[1:]...), nil
+}
+
+// Name implements part of the resolver.SymbolProvider interface.
+func (p *ExecProvider) Name() string {
+	return p.name
+}
+
+// RegisterFlags implements part of the resolver.SymbolProvider interface.
+func (p *ExecProvider) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {
+}
+
+// CheckFlags implements part of the resolver.SymbolProvider interface.  The
+// command is started in the repository root and the symbols it supplies are
+// put into the scope.
+func (p *ExecProvider) CheckFlags(fs *flag.FlagSet, c *config.Config, scope resolver.Scope) error {
+	if err := p.start(c.RepoRoot); err != nil {
+		return p.errorf("%w", err)
+	}
+	if err := p.initialize(c.RepoRoot, scope); err != nil {
+		p.stop()
+		return p.errorf("%w", err)
+	}
+	return nil
+}
+
+func (p *ExecProvider) start(dir string) error {
+	cmd := exec.Command(p.command, p.args...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", p.command, err)
+	}
+
+	p.cmd = cmd
+	p.stdin = stdin
+	p.stdout = bufio.NewReader(stdout)
+	return nil
+}
+
+func (p *ExecProvider) initialize(repoRoot string, scope resolver.Scope) error {
+	if err := p.write(&pvpb.Request{Request: &pvpb.Request_Initialize{Initialize: &pvpb.InitializeRequest{
+		Name:     p.name,
+		RepoRoot: repoRoot,
+	}}}); err != nil {
+		return err
+	}
+
+	for {
+		response, err := p.read()
+		if err != nil {
+			return fmt.Errorf("initialize: %w", err)
+		}
+		switch t := response.Response.(type) {
+		case *pvpb.Response_Symbol:
+			symbol, err := p.newSymbol(t.Symbol)
+			if err != nil {
+				return fmt.Errorf("initialize: %w", err)
+			}
+			if err := scope.PutSymbol(symbol); err != nil {
+				return fmt.Errorf("initialize: %w", err)
+			}
+		case *pvpb.Response_Initialize:
+			return nil
+		default:
+			return fmt.Errorf("initialize: unexpected response %v", response)
+		}
+	}
+}
+
+func (p *ExecProvider) newSymbol(sym *pvpb.Symbol) (*resolver.Symbol, error) {
+	if sym.Name == "" {
+		return nil, fmt.Errorf("symbol name must not be empty")
+	}
+	from := label.NoLabel
+	if sym.Label != "" {
+		lbl, err := label.Parse(sym.Label)
+		if err != nil {
+			return nil, fmt.Errorf("symbol %q: %w", sym.Name, err)
+		}
+		if lbl.Relative {
+			return nil, fmt.Errorf("symbol %q: label must be absolute: %q", sym.Name, sym.Label)
+		}
+		from = lbl
+	}
+	return resolver.NewSymbol(sym.Type, sym.Name, p.name, from), nil
+}
+
+// CanProvide implements part of the resolver.SymbolProvider interface.  A
+// failure of the command is fatal.
+func (p *ExecProvider) CanProvide(dep *resolver.ImportLabel, expr build.Expr, knownRule func(from label.Label) (*rule.Rule, bool), from label.Label) bool {
+	// if the command is not running, checkflags was never called and we can
+	// infer that this provider is not enabled
+	if p.cmd == nil {
+		return false
+	}
+
+	key := canProvideKey{dep: dep.Label, from: from}
+	if answer, ok := p.canProvide[key]; ok {
+		return answer
+	}
+
+	answer, err := p.query(dep.Label.String(), from.String())
+	if err != nil {
+		log.Fatal(p.errorf("can_provide %s: %w", dep.Label, err))
+	}
+	p.canProvide[key] = answer
+	return answer
+}
+
+func (p *ExecProvider) query(dep, from string) (bool, error) {
+	if err := p.write(&pvpb.Request{Request: &pvpb.Request_CanProvide{CanProvide: &pvpb.CanProvideRequest{
+		Label: dep,
+		From:  from,
+	}}}); err != nil {
+		return false, err
+	}
+	response, err := p.read()
+	if err != nil {
+		return false, err
+	}
+	t, ok := response.Response.(*pvpb.Response_CanProvide)
+	if !ok {
+		return false, fmt.Errorf("unexpected response %v", response)
+	}
+	return t.CanProvide.CanProvide, nil
+}
+
+// OnResolve implements part of the resolver.SymbolProvider interface.
+func (p *ExecProvider) OnResolve() error {
+	return nil
+}
+
+// OnEnd implements part of the resolver.SymbolProvider interface.  The
+// command is asked to shut down.
+func (p *ExecProvider) OnEnd() error {
+	if p.cmd == nil {
+		return nil
+	}
+	defer p.stop()
+
+	if err := p.write(&pvpb.Request{Request: &pvpb.Request_Shutdown{Shutdown: &pvpb.ShutdownRequest{}}}); err != nil {
+		return p.errorf("shutdown: %w", err)
+	}
+	response, err := p.read()
+	if err != nil {
+		return p.errorf("shutdown: %w", err)
+	}
+	if _, ok := response.Response.(*pvpb.Response_Shutdown); !ok {
+		return p.errorf("shutdown: unexpected response %v", response)
+	}
+	return nil
+}
+
+// stop closes stdin and waits for the process to exit, killing it if it does
+// not exit in time.  Wait closes the stdout pipe, so it must not be called
+// before all responses have been read.
+func (p *ExecProvider) stop() {
+	if p.cmd == nil {
+		return
+	}
+	p.stdin.Close()
+
+	exited := make(chan struct{})
+	go func() {
+		p.cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(execShutdownTimeout):
+		p.cmd.Process.Kill()
+		<-exited
+	}
+	p.cmd = nil
+}
+
+func (p *ExecProvider) write(request *pvpb.Request) error {
+	switch p.format {
+	case ExecFormatProto:
+		if err := protobuf.WriteDelimitedTo(request, p.stdin); err != nil {
+			return fmt.Errorf("writing request: %w", err)
+		}
+	default:
+		data, err := protojson.Marshal(request)
+		if err != nil {
+			return fmt.Errorf("marshaling request: %w", err)
+		}
+		if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("writing request: %w", err)
+		}
+	}
+	return nil
+}
+
+// read reads the next response.  A response having the error field set is
+// returned as an error.
+func (p *ExecProvider) read() (*pvpb.Response, error) {
+	var response pvpb.Response
+	switch p.format {
+	case ExecFormatProto:
+		if err := protobuf.ReadDelimitedFrom(&response, p.stdout); err != nil {
+			return nil, fmt.Errorf("reading response: %w", err)
+		}
+	default:
+		line, err := p.stdout.ReadBytes('\n')
+		if err != nil {
+			return nil, fmt.Errorf("reading response: %w", err)
+		}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(line, &response); err != nil {
+			return nil, fmt.Errorf("unmarshaling response: %w", err)
+		}
+	}
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+	return &response, nil
+}
+
+func (p *ExecProvider) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("exec symbol provider %q: %w", p.name, fmt.Errorf(format, args...))
+}
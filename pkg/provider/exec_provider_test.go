@@ -0,0 +1,223 @@
+package provider_test
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+	pvpb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/provider"
+	"github.com/stackb/scala-gazelle/pkg/protobuf"
+	"github.com/stackb/scala-gazelle/pkg/provider"
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+)
+
+// execProviderHelperEnv is set to the message format when the test binary is
+// run as an exec symbol provider command.
+const execProviderHelperEnv = "SCALA_GAZELLE_EXEC_PROVIDER_HELPER_FORMAT"
+
+// execProviderHelperErrorEnv makes the helper answer the named request
+// ('initialize') with an error.
+const execProviderHelperErrorEnv = "SCALA_GAZELLE_EXEC_PROVIDER_HELPER_ERROR"
+
+// TestExecProviderHelperProcess is not a real test; it implements the exec
+// symbol provider protocol when the test binary is started by an
+// ExecProvider.  It supplies two symbols and can provide labels in the
+// '@artifacts' repository, as well as ':vendored' from the 'vendor' package.
+func TestExecProviderHelperProcess(t *testing.T) {
+	format := os.Getenv(execProviderHelperEnv)
+	if format == "" {
+		return
+	}
+	failOn := os.Getenv(execProviderHelperErrorEnv)
+
+	stdin := bufio.NewReader(os.Stdin)
+	write := func(response *pvpb.Response) {
+		if format == provider.ExecFormatJSON {
+			data, _ := protojson.Marshal(response)
+			fmt.Fprintf(os.Stdout, "%s\n", data)
+		} else {
+			protobuf.WriteDelimitedTo(response, os.Stdout)
+		}
+	}
+
+	for {
+		var request pvpb.Request
+		if format == provider.ExecFormatJSON {
+			line, err := stdin.ReadBytes('\n')
+			if err != nil {
+				os.Exit(0)
+			}
+			if err := protojson.Unmarshal(line, &request); err != nil {
+				os.Exit(2)
+			}
+		} else {
+			if err := protobuf.ReadDelimitedFrom(&request, stdin); err != nil {
+				os.Exit(0)
+			}
+		}
+
+		switch t := request.Request.(type) {
+		case *pvpb.Request_Initialize:
+			if failOn == "initialize" {
+				write(&pvpb.Response{Error: "no artifact store for " + t.Initialize.Name})
+				continue
+			}
+			write(&pvpb.Response{Response: &pvpb.Response_Symbol{Symbol: &pvpb.Symbol{
+				Type:  sppb.ImportType_CLASS,
+				Name:  "com.example.Client",
+				Label: "@artifacts//:client",
+			}}})
+			write(&pvpb.Response{Response: &pvpb.Response_Symbol{Symbol: &pvpb.Symbol{
+				Type: sppb.ImportType_PACKAGE,
+				Name: "com.example.platform",
+			}}})
+			write(&pvpb.Response{Response: &pvpb.Response_Initialize{Initialize: &pvpb.InitializeResponse{}}})
+		case *pvpb.Request_CanProvide:
+			write(&pvpb.Response{Response: &pvpb.Response_CanProvide{CanProvide: &pvpb.CanProvideResponse{
+				CanProvide: strings.HasPrefix(t.CanProvide.Label, "@artifacts//") ||
+					(t.CanProvide.Label == ":vendored" && strings.HasPrefix(t.CanProvide.From, "//vendor:")),
+			}}})
+		case *pvpb.Request_Shutdown:
+			write(&pvpb.Response{Response: &pvpb.Response_Shutdown{Shutdown: &pvpb.ShutdownResponse{}}})
+			os.Exit(0)
+		}
+	}
+}
+
+func newHelperExecProvider(t *testing.T, format, failOn string) *provider.ExecProvider {
+	t.Setenv(execProviderHelperEnv, format)
+	t.Setenv(execProviderHelperErrorEnv, failOn)
+	return provider.NewExecProvider("artifacts", format, os.Args[0], "-test.run=^TestExecProviderHelperProcess$")
+}
+
+func TestExecProvider(t *testing.T) {
+	for _, format := range []string{provider.ExecFormatJSON, provider.ExecFormatProto} {
+		t.Run(format, func(t *testing.T) {
+			p := newHelperExecProvider(t, format, "")
+			scope := resolver.NewTrieScope()
+
+			if err := p.CheckFlags(flag.NewFlagSet("", flag.ContinueOnError), &config.Config{}, scope); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*resolver.Symbol
+			for _, name := range []string{"com.example.Client", "com.example.platform"} {
+				if sym, ok := scope.GetSymbol(name); ok {
+					got = append(got, sym)
+				}
+			}
+			want := []*resolver.Symbol{
+				{Type: sppb.ImportType_CLASS, Name: "com.example.Client", Label: label.New("artifacts", "", "client"), Provider: "artifacts"},
+				{Type: sppb.ImportType_PACKAGE, Name: "com.example.platform", Label: label.NoLabel, Provider: "artifacts"},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("symbols (-want +got):\n%s", diff)
+			}
+
+			from := label.New("", "a", "a")
+			vendored := label.Label{Name: "vendored", Relative: true}
+			for _, tc := range []struct {
+				dep  label.Label
+				from label.Label
+				want bool
+			}{
+				{label.New("artifacts", "", "client"), from, true},
+				{label.New("maven", "", "junit_junit"), from, false},
+				// answered from memo
+				{label.New("artifacts", "", "client"), from, true},
+				{vendored, label.New("", "vendor", "lib"), true},
+				// not answered from the memo of another package
+				{vendored, from, false},
+			} {
+				dep := &resolver.ImportLabel{Label: tc.dep}
+				if got := p.CanProvide(dep, nil, nil, tc.from); got != tc.want {
+					t.Errorf("CanProvide(%s, %s): want %t, got %t", tc.dep, tc.from, tc.want, got)
+				}
+			}
+
+			if err := p.OnEnd(); err != nil {
+				t.Fatal(err)
+			}
+			dep := &resolver.ImportLabel{Label: label.New("artifacts", "", "other")}
+			if p.CanProvide(dep, nil, nil, from) {
+				t.Error("CanProvide after OnEnd: want false")
+			}
+		})
+	}
+}
+
+func TestExecProviderInitializeError(t *testing.T) {
+	p := newHelperExecProvider(t, provider.ExecFormatJSON, "initialize")
+	err := p.CheckFlags(flag.NewFlagSet("", flag.ContinueOnError), &config.Config{}, resolver.NewTrieScope())
+	want := `exec symbol provider "artifacts": initialize: no artifact store for artifacts`
+	if err == nil || err.Error() != want {
+		t.Fatalf("error: want %q, got %v", want, err)
+	}
+}
+
+func TestExecProviderCommandNotFound(t *testing.T) {
+	p := provider.NewExecProvider("artifacts", provider.ExecFormatJSON, "/nonexistent/command")
+	err := p.CheckFlags(flag.NewFlagSet("", flag.ContinueOnError), &config.Config{}, resolver.NewTrieScope())
+	want := `exec symbol provider "artifacts": starting /nonexistent/command`
+	if err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Fatalf("error: want prefix %q, got %v", want, err)
+	}
+}
+
+func TestParseExecProviderSpec(t *testing.T) {
+	for name, tc := range map[string]struct {
+		spec    string
+		want    string
+		wantErr string
+	}{
+		"json by default": {
+			spec: "artifacts=tools/index.py --store=prod",
+			want: "artifacts",
+		},
+		"proto": {
+			spec: "artifacts:proto=tools/index",
+			want: "artifacts",
+		},
+		"missing command": {
+			spec:    "artifacts",
+			wantErr: `invalid exec symbol provider "artifacts": want NAME[:FORMAT]=COMMAND [ARG...]`,
+		},
+		"empty command": {
+			spec:    "artifacts= ",
+			wantErr: `invalid exec symbol provider "artifacts= ": command must not be empty`,
+		},
+		"empty name": {
+			spec:    "=tools/index",
+			wantErr: `invalid exec symbol provider "=tools/index": name must not be empty`,
+		},
+		"unknown format": {
+			spec:    "artifacts:xml=tools/index",
+			wantErr: `invalid exec symbol provider "artifacts:xml=tools/index": unknown format "xml" (want json or proto)`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := provider.ParseExecProviderSpec(tc.spec)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("error: want %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got.Name()); diff != "" {
+				t.Errorf("name (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -133,15 +133,30 @@ func (cr *SemanticdbProvider) CanProvide(dep *resolver.ImportLabel, expr build.E
 }
 
 // ParseScalaRule implements scalarule.Parser
-func (r *SemanticdbProvider) ParseScalaRule(kind string, from label.Label, dir string, srcs ...string) (*sppb.Rule, error) {
-	rule, err := r.delegate.ParseScalaRule(kind, from, dir, srcs...)
-	if err != nil {
-		return nil, fmt.Errorf("semanticdb: %v", err)
-	}
-	for _, file := range rule.Files {
-		r.visitFile(from.Pkg, file)
+func (r *SemanticdbProvider) ParseScalaRule(kind string, from label.Label, dialect, dir string, srcs ...string) (*sppb.Rule, error) {
+	result := r.ParseScalaRules([]*parser.RuleRequest{{
+		Kind:    kind,
+		From:    from,
+		Dialect: dialect,
+		Dir:     dir,
+		Srcs:    srcs,
+	}})[0]
+	return result.Rule, result.Err
+}
+
+// ParseScalaRules implements parser.RulesParser
+func (r *SemanticdbProvider) ParseScalaRules(requests []*parser.RuleRequest) []*parser.RuleResult {
+	results := parser.ParseScalaRules(r.delegate, requests)
+	for i, result := range results {
+		if result.Err != nil {
+			results[i] = &parser.RuleResult{Err: fmt.Errorf("semanticdb: %v", result.Err)}
+			continue
+		}
+		for _, file := range result.Rule.Files {
+			r.visitFile(requests[i].From.Pkg, file)
+		}
 	}
-	return rule, nil
+	return results
 }
 
 // LoadScalaRule loads the given state.
@@ -153,6 +168,7 @@ func (r *SemanticdbProvider) visitFile(pkg string, file *sppb.File) error {
 	uri := path.Join(pkg, file.Filename)
 	if f, ok := r.files[uri]; ok {
 		file.SemanticImports = f.SemanticImports
+		file.SemanticExports = f.SemanticExports
 	}
 	return nil
 }
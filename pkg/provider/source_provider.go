@@ -7,6 +7,8 @@ import (
 	"log"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/bazelbuild/bazel-gazelle/config"
@@ -25,16 +27,26 @@ import (
 
 const SCALA_GAZELLE_ALLOW_RUNTIME_PARSING = procutil.EnvVar("SCALA_GAZELLE_ALLOW_RUNTIME_PARSING")
 
-const scalaFilesetFileFlagName = "scala_fileset_file"
+const (
+	scalaFilesetFileFlagName    = "scala_fileset_file"
+	scalaParserPoolSizeFlagName = "scala_parser_pool_size"
+	scalaParserBackendFlagName  = "scala_parser_backend"
+	scalaParserFallbackFlagName = "scala_parser_fallback"
+	javaParserBackendFlagName   = "java_parser_backend"
+)
 
 type progressFunc func(msg string)
 
 // NewSourceProvider constructs a new NewSourceProvider.
 func NewSourceProvider(logger zerolog.Logger, progress progressFunc) *SourceProvider {
 	return &SourceProvider{
-		logger:     logger,
-		progress:   progress,
-		scalaFiles: make(map[string]*sppb.File),
+		logger:        logger,
+		progress:      progress,
+		backends:      parser.NewGlobalBackends(),
+		lexerParser:   parser.NewLexerParser(),
+		scalaFiles:    make(map[string]*sppb.File),
+		degradedFiles: make(map[string]bool),
+		cachedRules:   make(map[label.Label]bool),
 	}
 }
 
@@ -48,11 +60,51 @@ type SourceProvider struct {
 	logger zerolog.Logger
 	// progress function
 	progress progressFunc
+	// flags and config are the arguments of RegisterFlags, retained to check
+	// the flags of the parser backends lazily.
+	flags  *flag.FlagSet
+	config *config.Config
+	// backends is the list of parser backends known to this provider.  Each
+	// provider has its own instances, as the backends hold their flag values.
+	backends []parser.Backend
 	// scope is the target we provide symbols to
 	scope resolver.Scope
-	// parser is an instance of the scala source parser.  It is initialized
+	// mu guards the lazy start of the parsers and progress reporting, as the
+	// files of rules are parsed concurrently.
+	mu sync.Mutex
+	// parser is a pool of scala source parsers.  It is initialized lazily.
+	parser *parser.ParserPool
+	// parserPoolSize is the number of parser processes in the pool.
+	parserPoolSize int
+	// parserBackendName is the name of the selected parser backend.
+	parserBackendName string
+	// parserBackend is the selected parser backend.
+	parserBackend parser.Backend
+	// parserStartErr is the error of a failed attempt to start the parser
+	// pool; the pool is not started again.
+	parserStartErr error
+	// parserFallback enables the lexer parser for files the parser backend
+	// fails on.
+	parserFallback bool
+	// lexerParser is the fallback parser.
+	lexerParser *parser.LexerParser
+	// degradedFiles is the set of (workspace relative) files whose symbols
+	// came from the lexer parser as a fallback.
+	degradedFiles map[string]bool
+	// cachedRules is the set of rules whose symbols were loaded from the
+	// cache and have not been visited during this run.
+	cachedRules map[label.Label]bool
+	// parserStats is a snapshot of the parser pool statistics taken when the
+	// pool is stopped.
+	parserStats []parser.WorkerStats
+	// javaParser is the parser for .java source files.  It is initialized
 	// lazily.
-	parser *parser.ScalametaParser
+	javaParser *parser.ParserPool
+	// javaParserBackendName is the name of the parser backend for .java
+	// files.
+	javaParserBackendName string
+	// javaParserBackend is the selected parser backend for .java files.
+	javaParserBackend parser.Backend
 	// scalaFilesetFilename is an optional path to a parse.Fileset that provides
 	// pre-parsed scala files.
 	scalaFilesetFilename string
@@ -68,10 +120,25 @@ func (r *SourceProvider) Name() string {
 // RegisterFlags implements part of the resolver.SymbolProvider interface.
 func (r *SourceProvider) RegisterFlags(flags *flag.FlagSet, cmd string, c *config.Config) {
 	flags.StringVar(&r.scalaFilesetFilename, scalaFilesetFileFlagName, "", "optional path to an imports file where resolved imports should be written (.json or .pb)")
+	flags.IntVar(&r.parserPoolSize, scalaParserPoolSizeFlagName, 1, "number of parser workers; the rules of a package (and the files of a rule) are parsed concurrently across the workers")
+	flags.StringVar(&r.parserBackendName, scalaParserBackendFlagName, "scalameta", "name of the parser backend used to parse scala files")
+	flags.BoolVar(&r.parserFallback, scalaParserFallbackFlagName, true, "if the parser backend fails on a file, parse it with the lexer parser to provide its symbols (the file is marked as degraded and the scala_parse_error_policy still applies)")
+	flags.StringVar(&r.javaParserBackendName, javaParserBackendFlagName, "java", "name of the parser backend used to parse java files")
+	for _, backend := range r.backends {
+		backend.RegisterFlags(flags, cmd, c)
+	}
+	r.flags = flags
+	r.config = c
 }
 
 // CheckFlags implements part of the resolver.SymbolProvider interface.
 func (r *SourceProvider) CheckFlags(flags *flag.FlagSet, c *config.Config, scope resolver.Scope) error {
+	if r.parserPoolSize < 1 {
+		return fmt.Errorf("-%s must be at least 1 (got %d)", scalaParserPoolSizeFlagName, r.parserPoolSize)
+	}
+	if err := r.resolveParserBackends(flags, c); err != nil {
+		return err
+	}
 	if r.scalaFilesetFilename != "" {
 		filename := r.scalaFilesetFilename
 		if !filepath.IsAbs(filename) {
@@ -90,11 +157,90 @@ func (r *SourceProvider) CheckFlags(flags *flag.FlagSet, c *config.Config, scope
 // OnResolve implements part of the resolver.SymbolProvider interface.
 func (r *SourceProvider) OnResolve() error {
 	if r.parser != nil {
+		r.parserStats = r.parser.Stats()
 		r.parser.Stop()
+		r.parser = nil
+	}
+	if r.javaParser != nil {
+		r.javaParser.Stop()
+		r.javaParser = nil
+	}
+	return nil
+}
+
+// ParserStats returns the per-worker statistics of the parser pool, or nil if
+// no files were parsed.
+func (r *SourceProvider) ParserStats() []parser.WorkerStats {
+	if r.parser != nil {
+		return r.parser.Stats()
+	}
+	return r.parserStats
+}
+
+// ParserVersion returns the version of the selected parser backend, or the
+// empty string if the backend is not known.
+func (r *SourceProvider) ParserVersion() string {
+	if err := r.resolveParserBackends(r.flags, r.config); err != nil {
+		return ""
+	}
+	return r.parserBackend.Version()
+}
+
+// resolveParserBackends selects the parser backends named by the
+// -scala_parser_backend and -java_parser_backend flags and checks their flags.
+// It is called by CheckFlags, and lazily before parsing: files are parsed
+// even when the provider is not enabled (in which case CheckFlags is not
+// called).
+func (r *SourceProvider) resolveParserBackends(flags *flag.FlagSet, c *config.Config) error {
+	if r.parserBackend != nil {
+		return nil
 	}
+	backend, err := r.lookupParserBackend(scalaParserBackendFlagName, r.parserBackendName)
+	if err != nil {
+		return err
+	}
+	if err := backend.CheckFlags(flags, c); err != nil {
+		return err
+	}
+	javaBackend, err := r.lookupParserBackend(javaParserBackendFlagName, r.javaParserBackendName)
+	if err != nil {
+		return err
+	}
+	if javaBackend != backend {
+		if err := javaBackend.CheckFlags(flags, c); err != nil {
+			return err
+		}
+	}
+	r.parserBackend = backend
+	r.javaParserBackend = javaBackend
 	return nil
 }
 
+// lookupParserBackend returns the named parser backend.  The flagName is used
+// in the error message if the backend is not known.
+func (r *SourceProvider) lookupParserBackend(flagName, name string) (parser.Backend, error) {
+	names := make([]string, 0, len(r.backends))
+	for _, backend := range r.backends {
+		if backend.Name() == name {
+			return backend, nil
+		}
+		names = append(names, backend.Name())
+	}
+	return nil, fmt.Errorf("-%s: unknown parser backend %q (available: %s)", flagName, name, strings.Join(names, ", "))
+}
+
+// DegradedFiles returns a sorted list of the files whose symbols came from the
+// lexer parser because the parser backend failed on them.  Files are not
+// reported when the lexer backend itself is selected.
+func (r *SourceProvider) DegradedFiles() []string {
+	files := make([]string, 0, len(r.degradedFiles))
+	for filename := range r.degradedFiles {
+		files = append(files, filename)
+	}
+	sort.Strings(files)
+	return files
+}
+
 // OnEnd implements part of the resolver.SymbolProvider interface.
 func (r *SourceProvider) OnEnd() error {
 	return nil
@@ -111,23 +257,44 @@ func (cr *SourceProvider) CanProvide(dep *resolver.ImportLabel, expr build.Expr,
 			return true
 		}
 	}
+	// or to a cached rule in a package that was not visited
+	if cr.cachedRules[dep.Label] {
+		return true
+	}
+	if dep.Label.Relative && cr.cachedRules[dep.Label.Abs(from.Repo, from.Pkg)] {
+		return true
+	}
 	return false
 }
 
 // ensureParserStarted begins the parser process if it hasn't already.
 func (r *SourceProvider) ensureParserStarted() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.parserStartErr != nil {
+		return r.parserStartErr
+	}
 	if r.parser == nil {
 		if !procutil.LookupBoolEnv(SCALA_GAZELLE_ALLOW_RUNTIME_PARSING, true) {
 			r.logger.Panic().Msg("runtime parsing is disabled")
 		}
-		r.parser = parser.NewScalametaParser(parser.WithLogger(r.logger.With().Str("parser", "runtime").Logger()))
+		if err := r.resolveParserBackends(r.flags, r.config); err != nil {
+			return err
+		}
+		logger := r.logger.With().Str("parser", "runtime").Logger()
+		pool := parser.NewParserPool(func(id int) parser.PoolWorker {
+			return r.parserBackend.NewWorker(id, logger.With().Int("worker", id).Logger())
+		}, parser.WithPoolSize(r.parserPoolSize), parser.WithPoolLogger(logger))
 
 		now := time.Now()
-		r.logger.Printf("[%s] starting parser...", r.Name())
+		r.logger.Printf("[%s] starting %d %s parser(s)...", r.Name(), pool.Size(), r.parserBackend.Name())
 
-		if err := r.parser.Start(); err != nil {
-			return fmt.Errorf("starting parser: %w", err)
+		if err := pool.Start(); err != nil {
+			r.parserStartErr = fmt.Errorf("starting parser: %w", err)
+			return r.parserStartErr
 		}
+		r.parser = pool
 
 		r.logger.Printf("[%s] parser started in %v", r.Name(), time.Since(now))
 	}
@@ -135,25 +302,89 @@ func (r *SourceProvider) ensureParserStarted() error {
 	return nil
 }
 
+// ensureJavaParserStarted starts the java parser if it hasn't already.
+func (r *SourceProvider) ensureJavaParserStarted() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.javaParser != nil {
+		return nil
+	}
+	if err := r.resolveParserBackends(r.flags, r.config); err != nil {
+		return err
+	}
+	logger := r.logger.With().Str("parser", "java").Logger()
+	pool := parser.NewParserPool(func(id int) parser.PoolWorker {
+		return r.javaParserBackend.NewWorker(id, logger.With().Int("worker", id).Logger())
+	}, parser.WithPoolLogger(logger))
+	if err := pool.Start(); err != nil {
+		return fmt.Errorf("starting java parser: %w", err)
+	}
+	r.javaParser = pool
+	return nil
+}
+
 // ParseScalaRule implements scalarule.Parser
-func (r *SourceProvider) ParseScalaRule(kind string, from label.Label, dir string, srcs ...string) (*sppb.Rule, error) {
-	if len(srcs) == 0 {
-		return nil, nil
+func (r *SourceProvider) ParseScalaRule(kind string, from label.Label, dialect, dir string, srcs ...string) (*sppb.Rule, error) {
+	result := r.ParseScalaRules([]*parser.RuleRequest{{
+		Kind:    kind,
+		From:    from,
+		Dialect: dialect,
+		Dir:     dir,
+		Srcs:    srcs,
+	}})[0]
+	return result.Rule, result.Err
+}
+
+// ParseScalaRules implements parser.RulesParser.  The files of the rules are
+// parsed concurrently, such that the parser pool spreads the rules across its
+// workers.  The symbols of the rules are then loaded in request order.
+func (r *SourceProvider) ParseScalaRules(requests []*parser.RuleRequest) []*parser.RuleResult {
+	results := make([]*parser.RuleResult, len(requests))
+	files := make([][]*sppb.File, len(requests))
+
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		if len(req.Srcs) == 0 {
+			results[i] = &parser.RuleResult{}
+			continue
+		}
+		sort.Strings(req.Srcs)
+		wg.Add(1)
+		go func(i int, req *parser.RuleRequest) {
+			defer wg.Done()
+			parsed, err := r.parseFiles(req.Dir, req.Srcs, req.From, req.Kind, req.Dialect)
+			if err != nil {
+				results[i] = &parser.RuleResult{Err: err}
+				return
+			}
+			files[i] = parsed
+		}(i, req)
 	}
-	sort.Strings(srcs)
+	wg.Wait()
 
-	files, err := r.parseFiles(dir, srcs, from, kind)
-	if err != nil {
-		return nil, err
+	for i, req := range requests {
+		if results[i] != nil {
+			continue
+		}
+		rule, err := r.loadParsedRule(req.Kind, req.From, files[i])
+		results[i] = &parser.RuleResult{Rule: rule, Err: err}
 	}
+	return results
+}
+
+// loadParsedRule provides the symbols of the given parsed files of a rule and
+// returns the rule.
+func (r *SourceProvider) loadParsedRule(kind string, from label.Label, files []*sppb.File) (*sppb.Rule, error) {
 	sort.Slice(files, func(i, j int) bool {
 		a := files[i]
 		b := files[j]
 		return a.Filename < b.Filename
 	})
 
+	delete(r.cachedRules, from)
 	for _, file := range files {
-		if err := r.loadScalaFile(from, kind, file); err != nil {
+		if err := r.loadScalaFile(from, kind, file, false); err != nil {
 			return nil, err
 		}
 	}
@@ -165,7 +396,7 @@ func (r *SourceProvider) ParseScalaRule(kind string, from label.Label, dir strin
 	}, nil
 }
 
-func (r *SourceProvider) parseFiles(dir string, srcs []string, from label.Label, kind string) ([]*sppb.File, error) {
+func (r *SourceProvider) parseFiles(dir string, srcs []string, from label.Label, kind, dialect string) ([]*sppb.File, error) {
 	// haveFiles is the list of haveFiles we already have from pre-computed scalaFiles
 	var haveFiles []*sppb.File
 
@@ -187,39 +418,59 @@ func (r *SourceProvider) parseFiles(dir string, srcs []string, from label.Label,
 
 	t1 := time.Now()
 
-	if err := r.ensureParserStarted(); err != nil {
-		return nil, err
-	}
-
 	r.logger.Debug().Msgf("⭕ need to parse: %v", needFilenames)
 
-	filenames := make([]string, 0, len(needFilenames))
+	// java files are parsed by the java parser backend; the remainder by the
+	// scala parser backend.
+	var javaFilenames, scalaFilenames []string
 	for abs := range needFilenames {
-		filenames = append(filenames, abs)
+		if filepath.Ext(abs) == ".java" {
+			javaFilenames = append(javaFilenames, abs)
+		} else {
+			scalaFilenames = append(scalaFilenames, abs)
+		}
 	}
-	sort.Strings(filenames)
-	response, err := r.parser.Parse(context.Background(), &sppb.ParseRequest{
-		Filenames: filenames,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("parse error: %v", err)
+	sort.Strings(javaFilenames)
+	sort.Strings(scalaFilenames)
+
+	var parsed []*sppb.File
+	if len(scalaFilenames) > 0 {
+		files, err := r.parseScalaFiles(scalaFilenames, dialect)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, files...)
 	}
-	if response.Error != "" {
-		return nil, fmt.Errorf("parser error: %s", response.Error)
+	if len(javaFilenames) > 0 {
+		if err := r.ensureJavaParserStarted(); err != nil {
+			return nil, err
+		}
+		response, err := r.javaParser.Parse(context.Background(), &sppb.ParseRequest{
+			Filenames:     javaFilenames,
+			WantPositions: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("java parse error: %v", err)
+		}
+		parsed = append(parsed, response.Files...)
 	}
 
 	t2 := time.Since(t1).Round(1 * time.Millisecond)
 	if true {
 		log.Printf("Parsed %s%%%s (%d files, %v)", from, kind, len(needFilenames), t2)
 	}
+	if r.progress != nil {
+		r.mu.Lock()
+		r.progress(fmt.Sprintf("%s (%d files, %v)%s", from, len(needFilenames), t2, formatParserStats(r.ParserStats())))
+		r.mu.Unlock()
+	}
 
-	// check for errors and remove dir prefixes.  haveFiles (files thaat come
-	// pre-parsed) are workspace-relative.  Ensure that the files we just parsed
-	// are also workspace-relative such that they sort similarly.
-	for _, file := range response.Files {
-		if file.Error != "" {
-			return nil, fmt.Errorf("%s parse error: %s", file.Filename, file.Error)
-		}
+	// remove dir prefixes.  haveFiles (files thaat come pre-parsed) are
+	// workspace-relative.  Ensure that the files we just parsed are also
+	// workspace-relative such that they sort similarly.  Files that failed to
+	// parse are returned with the File.Error field set; how they are handled
+	// is up to the scala_parse_error_policy.
+	for _, file := range parsed {
 		rel, ok := needFilenames[file.Filename]
 		if !ok {
 			panic("failed to map parsed file (having absolute path) back to relative path: this is a bug: " + file.Filename)
@@ -227,42 +478,173 @@ func (r *SourceProvider) parseFiles(dir string, srcs []string, from label.Label,
 		file.Filename = rel
 	}
 
-	return append(haveFiles, response.Files...), nil
+	return append(haveFiles, parsed...), nil
+}
+
+// parseScalaFiles parses the given (absolute) scala filenames with the parser
+// backend, using the given dialect (detected if empty).  If fallback is
+// enabled, files the backend fails on (or all files, if the backend itself
+// fails) are parsed with the lexer parser instead.  Such files keep the error
+// of the backend, such that the scala_parse_error_policy applies to them: the
+// lexer result provides their symbols, but the imports may be incomplete.
+func (r *SourceProvider) parseScalaFiles(filenames []string, dialect string) ([]*sppb.File, error) {
+	request := &sppb.ParseRequest{
+		Filenames:     filenames,
+		WantPositions: true,
+		Dialect:       dialect,
+	}
+
+	response, err := r.parseWithBackend(request)
+	if err != nil {
+		if !r.parserFallback {
+			return nil, err
+		}
+		log.Printf("WARNING: %v (falling back to the lexer parser for %d file(s))", err, len(filenames))
+		backendErr := err
+		response, err = r.lexerParser.Parse(context.Background(), request)
+		if err != nil {
+			return nil, fmt.Errorf("lexer parse error: %v", err)
+		}
+		for _, file := range response.Files {
+			if file.Error == "" {
+				file.Error = backendErr.Error()
+			}
+		}
+		return response.Files, nil
+	}
+
+	if !r.parserFallback {
+		return response.Files, nil
+	}
+	for i, file := range response.Files {
+		if file.Error == "" {
+			continue
+		}
+		fallback, err := r.lexerParser.Parse(context.Background(), &sppb.ParseRequest{
+			Filenames:     []string{file.Filename},
+			WantPositions: true,
+		})
+		if err != nil || len(fallback.Files) != 1 || fallback.Files[0].Error != "" {
+			// keep the original error
+			continue
+		}
+		log.Printf("WARNING: %s parse error: %s (falling back to the lexer parser)", file.Filename, file.Error)
+		fallback.Files[0].Error = file.Error
+		response.Files[i] = fallback.Files[0]
+	}
+	return response.Files, nil
+}
+
+// parseWithBackend parses the given request with the parser pool.
+func (r *SourceProvider) parseWithBackend(request *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	if err := r.ensureParserStarted(); err != nil {
+		return nil, err
+	}
+	response, err := r.parser.Parse(context.Background(), request)
+	if err != nil {
+		return nil, fmt.Errorf("parse error: %v", err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("parser error: %s", response.Error)
+	}
+	return response, nil
 }
 
 // LoadScalaRule loads the given rule state.
 func (r *SourceProvider) LoadScalaRule(from label.Label, rule *sppb.Rule) error {
+	delete(r.cachedRules, from)
+	for _, file := range rule.Files {
+		if err := r.loadScalaFile(from, rule.Kind, file, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadCachedScalaRule loads the given rule state from the cache.  The symbols
+// are marked as cached, such that they are superseded by the symbols of the
+// rule if it is visited during this run.
+func (r *SourceProvider) LoadCachedScalaRule(from label.Label, rule *sppb.Rule) error {
+	r.cachedRules[from] = true
 	for _, file := range rule.Files {
-		if err := r.loadScalaFile(from, rule.Kind, file); err != nil {
+		if err := r.loadScalaFile(from, rule.Kind, file, true); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (r *SourceProvider) loadScalaFile(from label.Label, kind string, file *sppb.File) error {
+// PruneCachedRules removes the cached rules for which the given function
+// returns true.  This is used to forget cached rules in packages that were
+// visited during this run, as their symbols are no longer valid.
+func (r *SourceProvider) PruneCachedRules(remove func(from label.Label) bool) {
+	for from := range r.cachedRules {
+		if remove(from) {
+			delete(r.cachedRules, from)
+		}
+	}
+}
+
+// CachedRules returns a sorted list of the rules whose symbols were loaded
+// from the cache and that were not visited during this run.
+func (r *SourceProvider) CachedRules() []label.Label {
+	rules := make([]label.Label, 0, len(r.cachedRules))
+	for from := range r.cachedRules {
+		rules = append(rules, from)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].String() < rules[j].String()
+	})
+	return rules
+}
+
+func (r *SourceProvider) loadScalaFile(from label.Label, kind string, file *sppb.File, cached bool) error {
 	r.logger.Debug().Msgf("loading symbols from %s: %+v", file.Filename, file)
 
+	// files of cached rules are only reported as degraded when visited.
+	if !cached {
+		if file.Degraded && r.parserBackendName != "lexer" {
+			r.degradedFiles[file.Filename] = true
+		} else {
+			delete(r.degradedFiles, file.Filename)
+		}
+	}
+
 	for _, imp := range file.Classes {
-		r.putSymbol(from, kind, imp, sppb.ImportType_CLASS)
+		r.putSymbol(from, kind, imp, sppb.ImportType_CLASS, cached)
 	}
 	for _, imp := range file.Objects {
-		r.putSymbol(from, kind, imp, sppb.ImportType_OBJECT)
+		r.putSymbol(from, kind, imp, sppb.ImportType_OBJECT, cached)
 	}
 	for _, imp := range file.Traits {
-		r.putSymbol(from, kind, imp, sppb.ImportType_TRAIT)
+		r.putSymbol(from, kind, imp, sppb.ImportType_TRAIT, cached)
 	}
 	for _, imp := range file.Types {
-		r.putSymbol(from, kind, imp, sppb.ImportType_TYPE)
+		r.putSymbol(from, kind, imp, sppb.ImportType_TYPE, cached)
 	}
 	for _, imp := range file.Vals {
-		r.putSymbol(from, kind, imp, sppb.ImportType_VALUE)
+		r.putSymbol(from, kind, imp, sppb.ImportType_VALUE, cached)
+	}
+	for _, imp := range file.Enums {
+		r.putSymbol(from, kind, imp, sppb.ImportType_ENUM, cached)
+	}
+	for _, imp := range file.Givens {
+		r.putSymbol(from, kind, imp, sppb.ImportType_GIVEN, cached)
+	}
+	for _, imp := range file.Defs {
+		r.putSymbol(from, kind, imp, sppb.ImportType_DEF, cached)
 	}
 	return nil
 }
 
-func (r *SourceProvider) putSymbol(from label.Label, kind, imp string, impType sppb.ImportType) {
+func (r *SourceProvider) putSymbol(from label.Label, kind, imp string, impType sppb.ImportType, cached bool) {
+	// the scope is not set if the provider is not enabled; files are still
+	// parsed, but the provider does not provide their symbols.
+	if r.scope == nil {
+		return
+	}
 	sym := resolver.NewSymbol(impType, imp, kind, from)
+	sym.Cached = cached
 	r.logger.Debug().Msgf("adding symbol to scope: %v", sym)
 	r.scope.PutSymbol(sym)
 }
@@ -283,3 +665,16 @@ func (r *SourceProvider) parseScalaFileset(filename string) error {
 
 	return nil
 }
+
+// formatParserStats returns a compact summary of the per-worker parse times
+// when there is more than one worker.
+func formatParserStats(stats []parser.WorkerStats) string {
+	if len(stats) < 2 {
+		return ""
+	}
+	parts := make([]string, len(stats))
+	for i, s := range stats {
+		parts[i] = fmt.Sprintf("#%d %v", s.ID, s.Elapsed.Round(time.Millisecond))
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
@@ -1,20 +1,27 @@
 package provider_test
 
 import (
+	"context"
 	"flag"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/bazelbuild/bazel-gazelle/config"
 	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/testtools"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/rs/zerolog"
 
 	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
 	"github.com/stackb/scala-gazelle/pkg/collections"
+	"github.com/stackb/scala-gazelle/pkg/parser"
 	"github.com/stackb/scala-gazelle/pkg/protobuf"
 	"github.com/stackb/scala-gazelle/pkg/provider"
 	"github.com/stackb/scala-gazelle/pkg/resolver"
@@ -64,7 +71,7 @@ func TestScalaSourceProviderParseScalaRule(t *testing.T) {
 		t.Run(src, func(t *testing.T) {
 			goldenFile := filepath.Join(dir, src+".golden.json")
 			from := label.Label{Pkg: rel, Name: src}
-			got, err := provider.ParseScalaRule("scala_library", from, dir, src)
+			got, err := provider.ParseScalaRule("scala_library", from, "", dir, src)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -96,3 +103,485 @@ func TestScalaSourceProviderParseScalaRule(t *testing.T) {
 		})
 	}
 }
+
+// fakeBackend is a parser backend whose workers report each file as defining
+// the class 'fake.<Basename>' (and the type 'dialect.<Dialect>', if the
+// request names a dialect).  Files named 'Bad.scala' fail to parse.
+type fakeBackend struct{}
+
+func (b *fakeBackend) Name() string { return "fake" }
+
+func (b *fakeBackend) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {}
+
+func (b *fakeBackend) CheckFlags(fs *flag.FlagSet, c *config.Config) error { return nil }
+
+func (b *fakeBackend) Version() string { return "fake-1" }
+
+func (b *fakeBackend) NewWorker(id int, logger zerolog.Logger) parser.PoolWorker {
+	return &fakeWorker{}
+}
+
+type fakeWorker struct{ running bool }
+
+func (w *fakeWorker) Start() error    { w.running = true; return nil }
+func (w *fakeWorker) Stop()           { w.running = false }
+func (w *fakeWorker) IsRunning() bool { return w.running }
+
+func (w *fakeWorker) Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	response := &sppb.ParseResponse{}
+	for _, filename := range in.Filenames {
+		base := filepath.Base(filename)
+		if base == "Bad.scala" {
+			response.Files = append(response.Files, &sppb.File{
+				Filename: filename,
+				Error:    "syntax error",
+			})
+			continue
+		}
+		file := &sppb.File{
+			Filename: filename,
+			Classes:  []string{"fake." + strings.TrimSuffix(base, filepath.Ext(base))},
+		}
+		if in.Dialect != "" {
+			file.Types = []string{"dialect." + in.Dialect}
+		}
+		response.Files = append(response.Files, file)
+	}
+	return response, nil
+}
+
+// barrierBackend is a fake backend whose workers only answer once
+// barrierSize requests are in flight.  Otherwise, the files fail to parse
+// (after a timeout).
+type barrierBackend struct{ fakeBackend }
+
+const barrierSize = 2
+
+var barrier = struct {
+	sync.Mutex
+	waiting int
+	ready   chan struct{}
+}{ready: make(chan struct{})}
+
+func (b *barrierBackend) Name() string { return "barrier" }
+
+func (b *barrierBackend) NewWorker(id int, logger zerolog.Logger) parser.PoolWorker {
+	return &barrierWorker{}
+}
+
+type barrierWorker struct{ fakeWorker }
+
+func (w *barrierWorker) Parse(ctx context.Context, in *sppb.ParseRequest) (*sppb.ParseResponse, error) {
+	barrier.Lock()
+	barrier.waiting++
+	if barrier.waiting == barrierSize {
+		close(barrier.ready)
+	}
+	barrier.Unlock()
+
+	select {
+	case <-barrier.ready:
+		return w.fakeWorker.Parse(ctx, in)
+	case <-time.After(10 * time.Second):
+		response := &sppb.ParseResponse{}
+		for _, filename := range in.Filenames {
+			response.Files = append(response.Files, &sppb.File{
+				Filename: filename,
+				Error:    "not parsed concurrently",
+			})
+		}
+		return response, nil
+	}
+}
+
+func init() {
+	parser.GlobalBackendRegistry().PutBackend("fake", func() parser.Backend {
+		return &fakeBackend{}
+	})
+	parser.GlobalBackendRegistry().PutBackend("barrier", func() parser.Backend {
+		return &barrierBackend{}
+	})
+}
+
+func TestSourceProviderParserBackend(t *testing.T) {
+	for name, tc := range map[string]struct {
+		args    []string
+		dialect string
+		srcs    []string
+		files   []testtools.FileSpec
+		wantErr string
+		want    *sppb.Rule
+	}{
+		"unknown backend": {
+			args:    []string{"-scala_parser_backend=nope"},
+			wantErr: `-scala_parser_backend: unknown parser backend "nope" (available: barrier, command, fake, java, lexer, scalameta)`,
+		},
+		"unknown java backend": {
+			args:    []string{"-java_parser_backend=nope"},
+			wantErr: `-java_parser_backend: unknown parser backend "nope" (available: barrier, command, fake, java, lexer, scalameta)`,
+		},
+		"selected backend flags are checked": {
+			args:    []string{"-scala_parser_backend=command"},
+			wantErr: "-scala_parser_command is required for the command parser backend",
+		},
+		"fake backend": {
+			args: []string{"-scala_parser_backend=fake"},
+			want: &sppb.Rule{
+				Label: "//src:lib",
+				Kind:  "scala_library",
+				Files: []*sppb.File{
+					{Filename: "src/A.scala", Classes: []string{"fake.A"}},
+					{Filename: "src/B.scala", Classes: []string{"fake.B"}},
+				},
+			},
+		},
+		"dialect": {
+			args:    []string{"-scala_parser_backend=fake"},
+			dialect: "Scala3",
+			want: &sppb.Rule{
+				Label: "//src:lib",
+				Kind:  "scala_library",
+				Files: []*sppb.File{
+					{Filename: "src/A.scala", Classes: []string{"fake.A"}, Types: []string{"dialect.Scala3"}},
+					{Filename: "src/B.scala", Classes: []string{"fake.B"}, Types: []string{"dialect.Scala3"}},
+				},
+			},
+		},
+		"java files use the java backend": {
+			args: []string{"-scala_parser_backend=fake"},
+			srcs: []string{"C.java", "A.scala"},
+			files: []testtools.FileSpec{
+				{Path: "src/C.java", Content: "package com.foo;\n\npublic class C {}\n"},
+			},
+			want: &sppb.Rule{
+				Label: "//src:lib",
+				Kind:  "scala_library",
+				Files: []*sppb.File{
+					{Filename: "src/A.scala", Classes: []string{"fake.A"}},
+					{
+						Filename: "src/C.java",
+						Packages: []string{"com.foo"},
+						Classes:  []string{"com.foo.C"},
+						DefinitionPositions: map[string]*sppb.Position{
+							"com.foo.C": {Line: 3, Column: 14},
+						},
+					},
+				},
+			},
+		},
+		"java backend flag": {
+			args: []string{"-scala_parser_backend=lexer", "-java_parser_backend=fake"},
+			srcs: []string{"C.java"},
+			want: &sppb.Rule{
+				Label: "//src:lib",
+				Kind:  "scala_library",
+				Files: []*sppb.File{
+					{Filename: "src/C.java", Classes: []string{"fake.C"}},
+				},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, cleanup := testtools.CreateFiles(t, tc.files)
+			defer cleanup()
+
+			scope := resolver.NewTrieScope()
+			p := provider.NewSourceProvider(zerolog.New(io.Discard), func(msg string) {})
+
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			c := &config.Config{WorkDir: dir}
+			p.RegisterFlags(fs, "update", c)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			srcs := tc.srcs
+			if srcs == nil {
+				srcs = []string{"B.scala", "A.scala"}
+			}
+			var gotErr string
+			if err := p.CheckFlags(fs, c, scope); err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Fatalf("error (-want +got):\n%s", diff)
+			}
+			if tc.want == nil {
+				return
+			}
+			defer p.OnResolve()
+
+			from := label.Label{Pkg: "src", Name: "lib"}
+			got, err := p.ParseScalaRule("scala_library", from, tc.dialect, filepath.Join(dir, "src"), srcs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(
+				sppb.Rule{},
+				sppb.File{},
+				sppb.Position{},
+			)); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestSourceProviderParseScalaRules asserts that the rules are dispatched to
+// the workers of the pool concurrently.
+func TestSourceProviderParseScalaRules(t *testing.T) {
+	p := provider.NewSourceProvider(zerolog.New(io.Discard), func(msg string) {})
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	p.RegisterFlags(fs, "update", &config.Config{})
+	if err := fs.Parse([]string{
+		"-scala_parser_backend=barrier",
+		"-scala_parser_pool_size=2",
+		"-scala_parser_fallback=false",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer p.OnResolve()
+
+	results := p.ParseScalaRules([]*parser.RuleRequest{
+		{Kind: "scala_library", From: label.Label{Pkg: "src", Name: "a"}, Dir: "/src", Srcs: []string{"A.scala"}},
+		{Kind: "scala_library", From: label.Label{Pkg: "src", Name: "b"}, Dir: "/src", Srcs: []string{"B.scala"}},
+	})
+
+	want := []*parser.RuleResult{
+		{
+			Rule: &sppb.Rule{
+				Label: "//src:a",
+				Kind:  "scala_library",
+				Files: []*sppb.File{
+					{Filename: "src/A.scala", Classes: []string{"fake.A"}},
+				},
+			},
+		},
+		{
+			Rule: &sppb.Rule{
+				Label: "//src:b",
+				Kind:  "scala_library",
+				Files: []*sppb.File{
+					{Filename: "src/B.scala", Classes: []string{"fake.B"}},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, results, cmpopts.IgnoreUnexported(
+		sppb.Rule{},
+		sppb.File{},
+	)); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+}
+
+// TestSourceProviderParserBackendNotChecked asserts that files can be parsed
+// when CheckFlags was not called (the 'source' provider is not enabled).
+func TestSourceProviderParserBackendNotChecked(t *testing.T) {
+	p := provider.NewSourceProvider(zerolog.New(io.Discard), func(msg string) {})
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	p.RegisterFlags(fs, "update", &config.Config{})
+	if err := fs.Parse([]string{"-scala_parser_backend=fake"}); err != nil {
+		t.Fatal(err)
+	}
+	defer p.OnResolve()
+
+	if got := p.ParserVersion(); got != "fake-1" {
+		t.Errorf("parser version: want %q, got %q", "fake-1", got)
+	}
+	got, err := p.ParseScalaRule("scala_library", label.Label{Pkg: "src", Name: "lib"}, "", "/src", "A.scala")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &sppb.Rule{
+		Label: "//src:lib",
+		Kind:  "scala_library",
+		Files: []*sppb.File{
+			{Filename: "src/A.scala", Classes: []string{"fake.A"}},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(
+		sppb.Rule{},
+		sppb.File{},
+	)); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+}
+
+// TestSourceProviderParserBackendFlags asserts that the flags of the parser
+// backends are not shared between provider instances.
+func TestSourceProviderParserBackendFlags(t *testing.T) {
+	var got []string
+	for _, command := range []string{"/bin/a", "/bin/b"} {
+		p := provider.NewSourceProvider(zerolog.New(io.Discard), func(msg string) {})
+		fs := flag.NewFlagSet("", flag.ContinueOnError)
+		c := &config.Config{}
+		p.RegisterFlags(fs, "update", c)
+		if err := fs.Parse([]string{"-scala_parser_backend=command", "-scala_parser_command=" + command}); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.CheckFlags(fs, c, resolver.NewTrieScope()); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, p.ParserVersion())
+	}
+	want := []string{"command proto /bin/a", "command proto /bin/b"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parser versions (-want +got):\n%s", diff)
+	}
+}
+
+func TestSourceProviderParserFallback(t *testing.T) {
+	for name, tc := range map[string]struct {
+		args         []string
+		want         *sppb.Rule
+		wantDegraded []string
+	}{
+		"fallback": {
+			args: []string{"-scala_parser_backend=fake"},
+			want: &sppb.Rule{
+				Label: "//src:lib",
+				Kind:  "scala_library",
+				Files: []*sppb.File{
+					{Filename: "src/A.scala", Classes: []string{"fake.A"}},
+					{
+						Filename: "src/Bad.scala",
+						Packages: []string{"com.foo"},
+						Imports:  []string{"com.bar.Baz"},
+						Classes:  []string{"com.foo.Bad"},
+						ImportPositions: map[string]*sppb.Position{
+							"com.bar.Baz": {Line: 2, Column: 16},
+						},
+						DefinitionPositions: map[string]*sppb.Position{
+							"com.foo.Bad": {Line: 3, Column: 7},
+						},
+						Error:    "syntax error",
+						Degraded: true,
+					},
+				},
+			},
+			wantDegraded: []string{"src/Bad.scala"},
+		},
+		"backend fails to start": {
+			args: []string{"-scala_parser_backend=command", "-scala_parser_command=/nonexistent/parser"},
+			want: &sppb.Rule{
+				Label: "//src:lib",
+				Kind:  "scala_library",
+				Files: []*sppb.File{
+					{
+						Filename: "src/A.scala",
+						Packages: []string{"com.foo"},
+						Objects:  []string{"com.foo.A"},
+						DefinitionPositions: map[string]*sppb.Position{
+							"com.foo.A": {Line: 2, Column: 8},
+						},
+						Error:    "starting parser: starting parser worker 0: starting parser command /nonexistent/parser: fork/exec /nonexistent/parser: no such file or directory",
+						Degraded: true,
+					},
+					{
+						Filename: "src/Bad.scala",
+						Packages: []string{"com.foo"},
+						Imports:  []string{"com.bar.Baz"},
+						Classes:  []string{"com.foo.Bad"},
+						ImportPositions: map[string]*sppb.Position{
+							"com.bar.Baz": {Line: 2, Column: 16},
+						},
+						DefinitionPositions: map[string]*sppb.Position{
+							"com.foo.Bad": {Line: 3, Column: 7},
+						},
+						Error:    "starting parser: starting parser worker 0: starting parser command /nonexistent/parser: fork/exec /nonexistent/parser: no such file or directory",
+						Degraded: true,
+					},
+				},
+			},
+			wantDegraded: []string{"src/A.scala", "src/Bad.scala"},
+		},
+		"fallback disabled": {
+			args: []string{"-scala_parser_backend=fake", "-scala_parser_fallback=false"},
+			want: &sppb.Rule{
+				Label: "//src:lib",
+				Kind:  "scala_library",
+				Files: []*sppb.File{
+					{
+						Filename: "src/A.scala",
+						Classes:  []string{"fake.A"},
+					},
+					{
+						Filename: "src/Bad.scala",
+						Error:    "syntax error",
+					},
+				},
+			},
+		},
+		"lexer backend": {
+			args: []string{"-scala_parser_backend=lexer"},
+			want: &sppb.Rule{
+				Label: "//src:lib",
+				Kind:  "scala_library",
+				Files: []*sppb.File{
+					{
+						Filename: "src/A.scala",
+						Packages: []string{"com.foo"},
+						Objects:  []string{"com.foo.A"},
+						DefinitionPositions: map[string]*sppb.Position{
+							"com.foo.A": {Line: 2, Column: 8},
+						},
+						Degraded: true,
+					},
+					{
+						Filename: "src/Bad.scala",
+						Packages: []string{"com.foo"},
+						Imports:  []string{"com.bar.Baz"},
+						Classes:  []string{"com.foo.Bad"},
+						ImportPositions: map[string]*sppb.Position{
+							"com.bar.Baz": {Line: 2, Column: 16},
+						},
+						DefinitionPositions: map[string]*sppb.Position{
+							"com.foo.Bad": {Line: 3, Column: 7},
+						},
+						Degraded: true,
+					},
+				},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, cleanup := testtools.CreateFiles(t, []testtools.FileSpec{
+				{Path: "src/A.scala", Content: "package com.foo\nobject A\n"},
+				{Path: "src/Bad.scala", Content: "package com.foo\nimport com.bar.Baz\nclass Bad {\n"},
+			})
+			defer cleanup()
+
+			scope := resolver.NewTrieScope()
+			p := provider.NewSourceProvider(zerolog.New(io.Discard), func(msg string) {})
+
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			c := &config.Config{WorkDir: dir}
+			p.RegisterFlags(fs, "update", c)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.CheckFlags(fs, c, scope); err != nil {
+				t.Fatal(err)
+			}
+			defer p.OnResolve()
+
+			from := label.Label{Pkg: "src", Name: "lib"}
+			got, err := p.ParseScalaRule("scala_library", from, "", filepath.Join(dir, "src"), "A.scala", "Bad.scala")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(
+				sppb.Rule{},
+				sppb.File{},
+				sppb.Position{},
+			)); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantDegraded, p.DegradedFiles(), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("degraded files (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
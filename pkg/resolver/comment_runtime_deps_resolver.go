@@ -0,0 +1,97 @@
+package resolver
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+func init() {
+	rr := &CommentRuntimeDepsResolver{}
+	GlobalRuntimeDepsResolverRegistry().PutRuntimeDepsResolver(rr.Name(), rr)
+}
+
+// runtimeDepCommentPrefix is the in-source comment directive that names a
+// runtime dependency, e.g. '// gazelle:runtime_dep com.foo.Impl'.
+const runtimeDepCommentPrefix = "gazelle:runtime_dep"
+
+// CommentRuntimeDepsResolver implements a strategy where runtime dependencies
+// are declared in the source files of the rule using a comment like:
+//
+//	// gazelle:runtime_dep com.foo.Impl com.foo.OtherImpl
+//
+// This is useful for classes that are only loaded via reflection.
+type CommentRuntimeDepsResolver struct {
+}
+
+// Name implements part of the resolver.RuntimeDepsResolver interface.
+func (s *CommentRuntimeDepsResolver) Name() string {
+	return "runtime_dep_comment"
+}
+
+// RegisterFlags implements part of the resolver.RuntimeDepsResolver interface.
+func (s *CommentRuntimeDepsResolver) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {
+}
+
+// CheckFlags implements part of the resolver.RuntimeDepsResolver interface.
+func (s *CommentRuntimeDepsResolver) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	return nil
+}
+
+// ResolveRuntimeDeps implements part of the resolver.RuntimeDepsResolver
+// interface.  Only .scala and .java files are scanned.
+func (s *CommentRuntimeDepsResolver) ResolveRuntimeDeps(r *rule.Rule, dir string, filenames []string, imports ImportMap) error {
+	for _, filename := range filenames {
+		switch filepath.Ext(filename) {
+		case ".scala", ".java":
+		default:
+			continue
+		}
+		names, err := readRuntimeDepComments(filepath.Join(dir, filename))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		for _, name := range names {
+			imports.Put(NewRuntimeImport(name, filename))
+		}
+	}
+	return nil
+}
+
+// readRuntimeDepComments returns the list of names declared in runtime_dep
+// comments of the given file.
+func readRuntimeDepComments(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "//") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "//"))
+		if !strings.HasPrefix(line, runtimeDepCommentPrefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(line, runtimeDepCommentPrefix)
+		if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			continue // some other directive, e.g. 'gazelle:runtime_deps'
+		}
+		names = append(names, strings.Fields(rest)...)
+	}
+	return names, scanner.Err()
+}
@@ -0,0 +1,73 @@
+package resolver_test
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/bazelbuild/bazel-gazelle/testtools"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+)
+
+func TestCommentRuntimeDepsResolver(t *testing.T) {
+	for name, tc := range map[string]struct {
+		files     []testtools.FileSpec
+		filenames []string
+		want      []string
+	}{
+		"degenerate": {
+			want: []string{},
+		},
+		"comments": {
+			files: []testtools.FileSpec{
+				{
+					Path: "Main.scala",
+					Content: `package com.foo
+
+// gazelle:runtime_dep com.foo.impl.Driver
+//gazelle:runtime_dep com.foo.impl.A com.foo.impl.B
+// gazelle:runtime_deps com.foo.NotADirective
+object Main {
+  val s = "// gazelle:runtime_dep com.foo.InAString"
+}
+`,
+				},
+				{
+					Path:    "Plugin.java",
+					Content: "// gazelle:runtime_dep com.foo.impl.Plugin\n",
+				},
+				{
+					Path:    "README.md",
+					Content: "// gazelle:runtime_dep com.foo.NotASource\n",
+				},
+			},
+			filenames: []string{"Main.scala", "Plugin.java", "README.md", "Missing.scala"},
+			want: []string{
+				"✅ com.foo.impl.Driver<> (RUNTIME of Main.scala)",
+				"✅ com.foo.impl.A<> (RUNTIME of Main.scala)",
+				"✅ com.foo.impl.B<> (RUNTIME of Main.scala)",
+				"✅ com.foo.impl.Plugin<> (RUNTIME of Plugin.java)",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, cleanup := testtools.CreateFiles(t, tc.files)
+			defer cleanup()
+
+			imports := resolver.NewImportMap()
+			rr := &resolver.CommentRuntimeDepsResolver{}
+			if err := rr.ResolveRuntimeDeps(rule.NewRule("scala_library", "lib"), dir, tc.filenames, imports); err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0, len(imports.Keys()))
+			for _, imp := range imports.Values() {
+				got = append(got, imp.String())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
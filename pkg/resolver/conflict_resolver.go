@@ -4,6 +4,7 @@ import (
 	"flag"
 
 	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
 	"github.com/bazelbuild/bazel-gazelle/rule"
 	"github.com/rs/zerolog"
 )
@@ -24,3 +25,13 @@ type ConflictResolver interface {
 	// with conflicts to resolve.
 	ResolveConflict(universe Universe, r *rule.Rule, imports ImportMap, imp *Import, symbol *Symbol) (*Symbol, bool)
 }
+
+// LabelConflictResolver is an optional interface for a ConflictResolver
+// implementation.  This is a mechanism for resolvers whose strategy depends on
+// the label of the rule being resolved.  When implemented, it is called instead
+// of ResolveConflict.
+type LabelConflictResolver interface {
+	// ResolveConflictFrom is like ResolveConflict, with the additional label of
+	// the rule that imports the symbol.
+	ResolveConflictFrom(universe Universe, r *rule.Rule, from label.Label, imports ImportMap, imp *Import, symbol *Symbol) (*Symbol, bool)
+}
@@ -0,0 +1,119 @@
+package resolver
+
+import (
+	"flag"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	cr := &ExistingDepsConflictResolver{}
+	GlobalConflictResolverRegistry().PutConflictResolver(cr.Name(), cr)
+}
+
+// ExistingDepsConflictResolver implements a strategy where the candidate that
+// is already present in the 'deps' of the rule is preferred.  The rationale is
+// that a conflict that has been settled by hand (or by a previous run) should
+// stay settled.
+type ExistingDepsConflictResolver struct {
+}
+
+// Name implements part of the resolver.ConflictResolver interface.
+func (s *ExistingDepsConflictResolver) Name() string {
+	return "existing_deps"
+}
+
+// RegisterFlags implements part of the resolver.ConflictResolver interface.
+func (s *ExistingDepsConflictResolver) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config, logger zerolog.Logger) {
+}
+
+// CheckFlags implements part of the resolver.ConflictResolver interface.
+func (s *ExistingDepsConflictResolver) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	return nil
+}
+
+// ResolveConflict implements part of the resolver.ConflictResolver interface.
+// As the label of the rule is not known, relative labels in the 'deps' are
+// ignored.
+func (s *ExistingDepsConflictResolver) ResolveConflict(universe Universe, r *rule.Rule, imports ImportMap, imp *Import, symbol *Symbol) (*Symbol, bool) {
+	return s.ResolveConflictFrom(universe, r, label.NoLabel, imports, imp, symbol)
+}
+
+// ResolveConflictFrom implements the resolver.LabelConflictResolver interface.
+// This implementation chooses the symbol whose label is already listed in the
+// 'deps' of the rule, including entries marked with '# keep'.  Relative labels
+// (":foo") are taken relative to the package of the rule.  If no candidate is
+// listed, or more than one is, the conflict is left to the next resolver.
+func (s *ExistingDepsConflictResolver) ResolveConflictFrom(universe Universe, r *rule.Rule, from label.Label, imports ImportMap, imp *Import, symbol *Symbol) (*Symbol, bool) {
+	deps := existingDepLabels(r)
+	if len(deps) == 0 {
+		return nil, false
+	}
+
+	candidates := append([]*Symbol{symbol}, symbol.Conflicts...)
+
+	var found *Symbol
+	for _, dep := range deps {
+		if dep.Relative {
+			if from == label.NoLabel {
+				continue
+			}
+			dep = dep.Abs(from.Repo, from.Pkg)
+		}
+		for _, sym := range candidates {
+			if !existingDepMatches(dep, sym.Label) {
+				continue
+			}
+			if found != nil && found.Label != sym.Label {
+				return nil, false
+			}
+			found = sym
+		}
+	}
+
+	return found, found != nil
+}
+
+// existingDepMatches reports whether the (absolute) dep label refers to the
+// candidate label.
+func existingDepMatches(dep, candidate label.Label) bool {
+	if candidate == label.NoLabel {
+		return false
+	}
+	return dep.Repo == candidate.Repo && dep.Pkg == candidate.Pkg && dep.Name == candidate.Name
+}
+
+// existingDepLabels returns the labels listed in the 'deps' attribute of the
+// rule.  Both plain strings and scala_dep("...") calls are recognized.
+func existingDepLabels(r *rule.Rule) []label.Label {
+	if r == nil {
+		return nil
+	}
+	list, ok := r.Attr("deps").(*build.ListExpr)
+	if !ok {
+		return nil
+	}
+	var deps []label.Label
+	for _, expr := range list.List {
+		if call, ok := expr.(*build.CallExpr); ok {
+			if ident, ok := call.X.(*build.Ident); !ok || ident.Name != "scala_dep" || len(call.List) == 0 {
+				continue
+			}
+			expr = call.List[0]
+		}
+		str, ok := expr.(*build.StringExpr)
+		if !ok {
+			continue
+		}
+		dep, err := label.Parse(str.Value)
+		if err != nil {
+			continue
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
@@ -0,0 +1,157 @@
+package resolver_test
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+	"github.com/stackb/scala-gazelle/pkg/resolver/mocks"
+)
+
+func TestExistingDepsConflictResolver(t *testing.T) {
+	conflicted := resolver.Symbol{
+		Name:  "org.json4s",
+		Label: label.Label{Repo: "maven", Name: "org_json4s_json4s_core_2_13"},
+		Conflicts: []*resolver.Symbol{
+			{
+				Name:  "org.json4s",
+				Label: label.Label{Repo: "maven", Name: "org_json4s_json4s_ast_2_13"},
+			},
+		},
+	}
+	local := resolver.Symbol{
+		Name:  "foo.Bar",
+		Label: label.Label{Pkg: "foo", Name: "bar"},
+		Conflicts: []*resolver.Symbol{
+			{
+				Name:  "foo.Bar",
+				Label: label.Label{Pkg: "baz", Name: "bar_lib"},
+			},
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		symbol resolver.Symbol
+		from   label.Label
+		deps   []build.Expr
+		want   label.Label
+		wantOk bool
+	}{
+		"degenerate": {
+			symbol: conflicted,
+		},
+		"no matching dep": {
+			symbol: conflicted,
+			deps:   []build.Expr{str("@maven//:com_google_guava_guava")},
+		},
+		"selects symbol present in deps": {
+			symbol: conflicted,
+			deps:   []build.Expr{str("@maven//:org_json4s_json4s_core_2_13")},
+			want:   label.Label{Repo: "maven", Name: "org_json4s_json4s_core_2_13"},
+			wantOk: true,
+		},
+		"selects conflict present in deps": {
+			symbol: conflicted,
+			deps:   []build.Expr{str("@maven//:org_json4s_json4s_ast_2_13")},
+			want:   label.Label{Repo: "maven", Name: "org_json4s_json4s_ast_2_13"},
+			wantOk: true,
+		},
+		"selects conflict marked keep": {
+			symbol: conflicted,
+			deps: []build.Expr{
+				keep(str("@maven//:org_json4s_json4s_ast_2_13")),
+			},
+			want:   label.Label{Repo: "maven", Name: "org_json4s_json4s_ast_2_13"},
+			wantOk: true,
+		},
+		"selects conflict in scala_dep": {
+			symbol: conflicted,
+			deps: []build.Expr{
+				&build.CallExpr{
+					X:    &build.Ident{Name: "scala_dep"},
+					List: []build.Expr{str("@maven//:org_json4s_json4s_ast_2_13")},
+				},
+			},
+			want:   label.Label{Repo: "maven", Name: "org_json4s_json4s_ast_2_13"},
+			wantOk: true,
+		},
+		"does not select when more than one candidate present": {
+			symbol: conflicted,
+			deps: []build.Expr{
+				str("@maven//:org_json4s_json4s_core_2_13"),
+				str("@maven//:org_json4s_json4s_ast_2_13"),
+			},
+		},
+		"selects absolute local label": {
+			symbol: local,
+			deps:   []build.Expr{str("//baz:bar_lib")},
+			want:   label.Label{Pkg: "baz", Name: "bar_lib"},
+			wantOk: true,
+		},
+		"selects relative label in the package of the rule": {
+			symbol: local,
+			from:   label.Label{Pkg: "baz", Name: "lib"},
+			deps:   []build.Expr{str(":bar_lib")},
+			want:   label.Label{Pkg: "baz", Name: "bar_lib"},
+			wantOk: true,
+		},
+		"does not select relative label in another package": {
+			symbol: local,
+			from:   label.Label{Pkg: "foo", Name: "lib"},
+			deps:   []build.Expr{str(":bar_lib")},
+		},
+		"does not select relative label without the label of the rule": {
+			symbol: local,
+			deps:   []build.Expr{str(":bar_lib")},
+		},
+		"selects relative label having the same name as another candidate": {
+			symbol: resolver.Symbol{
+				Name:  "foo.Bar",
+				Label: label.Label{Pkg: "foo", Name: "lib"},
+				Conflicts: []*resolver.Symbol{
+					{
+						Name:  "foo.Bar",
+						Label: label.Label{Pkg: "baz", Name: "lib"},
+					},
+				},
+			},
+			from:   label.Label{Pkg: "baz", Name: "app"},
+			deps:   []build.Expr{str(":lib")},
+			want:   label.Label{Pkg: "baz", Name: "lib"},
+			wantOk: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			universe := mocks.NewUniverse(t)
+			r := rule.NewRule("scala_library", "lib")
+			if tc.deps != nil {
+				r.SetAttr("deps", &build.ListExpr{List: tc.deps})
+			}
+			cr := resolver.ExistingDepsConflictResolver{}
+			symbol := tc.symbol
+			gotSymbol, gotOk := cr.ResolveConflictFrom(universe, r, tc.from, nil, &resolver.Import{}, &symbol)
+			if diff := cmp.Diff(tc.wantOk, gotOk); diff != "" {
+				t.Errorf("ok (-want +got):\n%s", diff)
+			}
+			got := label.NoLabel
+			if gotSymbol != nil {
+				got = gotSymbol.Label
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func str(value string) *build.StringExpr {
+	return &build.StringExpr{Value: value}
+}
+
+func keep(expr build.Expr) build.Expr {
+	expr.Comment().Suffix = append(expr.Comment().Suffix, build.Comment{Token: "# keep"})
+	return expr
+}
@@ -0,0 +1,49 @@
+package resolver
+
+import (
+	"fmt"
+	"sort"
+)
+
+var globalRuntimeDepsResolvers = make(globalRuntimeDepsResolverMap)
+
+// GlobalRuntimeDepsResolverRegistry returns a default runtime deps resolver
+// registry.  Third-party gazelle extensions can append to this list and
+// configure their own implementations.
+func GlobalRuntimeDepsResolverRegistry() RuntimeDepsResolverRegistry {
+	return globalRuntimeDepsResolvers
+}
+
+type globalRuntimeDepsResolverMap map[string]RuntimeDepsResolver
+
+// GetRuntimeDepsResolver implements part of the
+// resolver.RuntimeDepsResolverRegistry interface.
+func (r globalRuntimeDepsResolverMap) GetRuntimeDepsResolver(name string) (RuntimeDepsResolver, bool) {
+	resolver, ok := r[name]
+	return resolver, ok
+}
+
+// PutRuntimeDepsResolver implements part of the
+// resolver.RuntimeDepsResolverRegistry interface.
+func (r globalRuntimeDepsResolverMap) PutRuntimeDepsResolver(name string, resolver RuntimeDepsResolver) error {
+	if _, ok := r[name]; ok {
+		return fmt.Errorf("duplicate RuntimeDepsResolver %q", name)
+	}
+	r[name] = resolver
+	return nil
+}
+
+// GlobalRuntimeDepsResolvers returns a sorted list of known runtime deps
+// resolvers.
+func GlobalRuntimeDepsResolvers() []RuntimeDepsResolver {
+	keys := make([]string, 0, len(globalRuntimeDepsResolvers))
+	for k := range globalRuntimeDepsResolvers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	resolvers := make([]RuntimeDepsResolver, len(keys))
+	for i, k := range keys {
+		resolvers[i] = globalRuntimeDepsResolvers[k]
+	}
+	return resolvers
+}
@@ -25,14 +25,17 @@ type Import struct {
 	Symbol *Symbol
 	// Error is assiged if there is a resolution error.
 	Error error
+	// Position is the location of the import in the source file, if known.
+	Position *sppb.Position
 }
 
 // NewDirectImport creates a new direct import from the given file.
 func NewDirectImport(imp string, source *sppb.File) *Import {
 	return &Import{
-		Kind:   sppb.ImportKind_DIRECT,
-		Imp:    imp,
-		Source: source,
+		Kind:     sppb.ImportKind_DIRECT,
+		Imp:      imp,
+		Source:   source,
+		Position: source.GetImportPositions()[imp],
 	}
 }
 
@@ -49,11 +52,12 @@ func NewSemanticImport(imp string, source *sppb.File) *Import {
 // name, and symbol.  The 'name' is the token that resolved in the file scope.
 func NewResolvedNameImport(imp string, source *sppb.File, name string, symbol *Symbol) *Import {
 	return &Import{
-		Kind:   sppb.ImportKind_RESOLVED_NAME,
-		Imp:    imp,
-		Source: source,
-		Src:    name,
-		Symbol: symbol,
+		Kind:     sppb.ImportKind_RESOLVED_NAME,
+		Imp:      imp,
+		Source:   source,
+		Src:      name,
+		Symbol:   symbol,
+		Position: source.GetNamePositions()[name],
 	}
 }
 
@@ -92,11 +96,25 @@ func NewImplicitImport(imp, src string) *Import {
 // NewExtendsImport creates a new extends import from the given requiring type.
 func NewExtendsImport(imp string, source *sppb.File, src string, symbol *Symbol) *Import {
 	return &Import{
-		Kind:   sppb.ImportKind_EXTENDS,
-		Imp:    imp,
-		Source: source,
-		Src:    src,
-		Symbol: symbol,
+		Kind:     sppb.ImportKind_EXTENDS,
+		Imp:      imp,
+		Source:   source,
+		Src:      src,
+		Symbol:   symbol,
+		Position: source.GetDefinitionPositions()[src],
+	}
+}
+
+// NewExportImport creates a new import for a name in an export clause of the
+// given file.  The 'src' is the name as written in the export clause.
+func NewExportImport(imp string, source *sppb.File, src string, symbol *Symbol) *Import {
+	return &Import{
+		Kind:     sppb.ImportKind_EXPORT,
+		Imp:      imp,
+		Source:   source,
+		Src:      src,
+		Symbol:   symbol,
+		Position: source.GetImportPositions()[src],
 	}
 }
 
@@ -108,6 +126,36 @@ func NewMainClassImport(imp string) *Import {
 	}
 }
 
+// NewRuntimeImport creates a new runtime import named in the given src
+// filename.
+func NewRuntimeImport(imp, src string) *Import {
+	return &Import{
+		Kind: sppb.ImportKind_RUNTIME,
+		Imp:  imp,
+		Src:  src,
+	}
+}
+
+// Location returns the source location of the import in the form
+// 'path/File.scala:42:8'.  If the position is not known only the filename is
+// returned; if the source file is not known the empty string is returned.
+func (imp *Import) Location() string {
+	return Location(imp.Source, imp.Position)
+}
+
+// Location formats the given position in the file as
+// 'path/File.scala:42:8'.  If the position is nil only the filename is
+// returned; if the file is nil the empty string is returned.
+func Location(file *sppb.File, pos *sppb.Position) string {
+	if file == nil {
+		return ""
+	}
+	if pos == nil {
+		return file.Filename
+	}
+	return fmt.Sprintf("%s:%d:%d", file.Filename, pos.Line, pos.Column)
+}
+
 func (imp *Import) Comment() build.Comment {
 	return build.Comment{Token: "# " + imp.String()}
 }
@@ -147,6 +195,10 @@ func (imp *Import) String() string {
 		parts = append(parts, fmt.Sprintf("(%v of %s via %q)", imp.Kind, filepath.Base(imp.Source.Filename), imp.Src))
 	case sppb.ImportKind_TRANSITIVE:
 		parts = append(parts, fmt.Sprintf("(%v of %s)", imp.Kind, imp.Src))
+	case sppb.ImportKind_EXPORT:
+		parts = append(parts, fmt.Sprintf("(%v of %s via %q)", imp.Kind, filepath.Base(imp.Source.Filename), imp.Src))
+	case sppb.ImportKind_RUNTIME:
+		parts = append(parts, fmt.Sprintf("(%v of %s)", imp.Kind, filepath.Base(imp.Src)))
 	default:
 		parts = append(parts, fmt.Sprintf("(%v)", imp.Kind))
 	}
@@ -0,0 +1,92 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/google/go-cmp/cmp"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+func TestImportLocation(t *testing.T) {
+	file := &sppb.File{
+		Filename: "com/foo/A.scala",
+		ImportPositions: map[string]*sppb.Position{
+			"com.foo.Bar": {Line: 3, Column: 8},
+		},
+		DefinitionPositions: map[string]*sppb.Position{
+			"com.foo.ClassA": {Line: 5, Column: 7},
+		},
+		NamePositions: map[string]*sppb.Position{
+			"Baz": {Line: 6, Column: 12},
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		imp  *Import
+		want string
+	}{
+		"degenerate": {
+			imp:  &Import{},
+			want: "",
+		},
+		"implicit import has no location": {
+			imp:  NewImplicitImport("com.foo.Bar", "com.foo.Baz"),
+			want: "",
+		},
+		"position not known": {
+			imp:  NewDirectImport("com.foo.Qux", file),
+			want: "com/foo/A.scala",
+		},
+		"direct import": {
+			imp:  NewDirectImport("com.foo.Bar", file),
+			want: "com/foo/A.scala:3:8",
+		},
+		"extends import": {
+			imp:  NewExtendsImport("akka.actor.Actor", file, "com.foo.ClassA", nil),
+			want: "com/foo/A.scala:5:7",
+		},
+		"resolved name import": {
+			imp:  NewResolvedNameImport("com.foo.Baz", file, "Baz", nil),
+			want: "com/foo/A.scala:6:12",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := tc.imp.Location()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSymbolConfictMessageLocation(t *testing.T) {
+	file := &sppb.File{
+		Filename: "com/foo/A.scala",
+		ImportPositions: map[string]*sppb.Position{
+			"com.foo.Bar": {Line: 3, Column: 8},
+		},
+	}
+	symbol := &Symbol{
+		Type:  sppb.ImportType_CLASS,
+		Name:  "com.foo.Bar",
+		Label: label.Label{Pkg: "a", Name: "a"},
+	}
+	symbol.Conflict(&Symbol{
+		Type:  sppb.ImportType_CLASS,
+		Name:  "com.foo.Bar",
+		Label: label.Label{Pkg: "b", Name: "b"},
+	})
+	imp := NewDirectImport("com.foo.Bar", file)
+	imp.Symbol = symbol
+
+	got := SymbolConfictMessage(symbol, imp, label.Label{Pkg: "com/foo", Name: "foo"})
+	want := `com/foo/A.scala:3:8: [//com/foo]: Ambiguous resolve of CLASS "com.foo.Bar" (symbol is provided by 2 labels) [✅ com.foo.Bar<CLASS> //a<> (DIRECT of A.scala)]
+ - Possible action: add a resolve directive to //com/foo:BUILD.bazel:
+     # gazelle:resolve scala scala com.foo.Bar //b:
+     # gazelle:resolve scala scala com.foo.Bar //a:`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+}
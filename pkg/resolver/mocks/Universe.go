@@ -128,6 +128,36 @@ func (_m *Universe) GetKnownRule(from label.Label) (*rule.Rule, bool) {
 	return r0, r1
 }
 
+// GetRuntimeDepsResolver provides a mock function with given fields: name
+func (_m *Universe) GetRuntimeDepsResolver(name string) (resolver.RuntimeDepsResolver, bool) {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRuntimeDepsResolver")
+	}
+
+	var r0 resolver.RuntimeDepsResolver
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(string) (resolver.RuntimeDepsResolver, bool)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) resolver.RuntimeDepsResolver); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(resolver.RuntimeDepsResolver)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
 // GetScope provides a mock function with given fields: name
 func (_m *Universe) GetScope(name string) (resolver.Scope, bool) {
 	ret := _m.Called(name)
@@ -262,6 +292,24 @@ func (_m *Universe) PutKnownRule(from label.Label, r *rule.Rule) error {
 	return r0
 }
 
+// PutRuntimeDepsResolver provides a mock function with given fields: name, r
+func (_m *Universe) PutRuntimeDepsResolver(name string, r resolver.RuntimeDepsResolver) error {
+	ret := _m.Called(name, r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutRuntimeDepsResolver")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, resolver.RuntimeDepsResolver) error); ok {
+		r0 = rf(name, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // PutSymbol provides a mock function with given fields: known
 func (_m *Universe) PutSymbol(known *resolver.Symbol) error {
 	ret := _m.Called(known)
@@ -0,0 +1,121 @@
+package resolver
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	cr := &ProximityConflictResolver{}
+	GlobalConflictResolverRegistry().PutConflictResolver(cr.Name(), cr)
+}
+
+// ProximityConflictResolver implements a strategy where the candidate closest
+// to the importing rule in the package tree is preferred.  This is intended for
+// first-party split packages, where the nearest provider is usually the right
+// one.
+type ProximityConflictResolver struct {
+}
+
+// Name implements part of the resolver.ConflictResolver interface.
+func (s *ProximityConflictResolver) Name() string {
+	return "proximity"
+}
+
+// RegisterFlags implements part of the resolver.ConflictResolver interface.
+func (s *ProximityConflictResolver) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config, logger zerolog.Logger) {
+}
+
+// CheckFlags implements part of the resolver.ConflictResolver interface.
+func (s *ProximityConflictResolver) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	return nil
+}
+
+// ResolveConflict implements part of the resolver.ConflictResolver interface.
+// This implementation never resolves the conflict since the label of the
+// importing rule is not known; see ResolveConflictFrom.
+func (s *ProximityConflictResolver) ResolveConflict(universe Universe, r *rule.Rule, imports ImportMap, imp *Import, symbol *Symbol) (*Symbol, bool) {
+	return nil, false
+}
+
+// ResolveConflictFrom implements the resolver.LabelConflictResolver interface.
+// Candidates in the same repository as 'from' are ranked by their distance to
+// it in the package tree: the same package first, then packages that branch
+// off beside it, then its ancestors, widening one level at a time.  The
+// conflict is resolved only if there is a unique best candidate.
+func (s *ProximityConflictResolver) ResolveConflictFrom(universe Universe, r *rule.Rule, from label.Label, imports ImportMap, imp *Import, symbol *Symbol) (*Symbol, bool) {
+	var best *Symbol
+	var bestDistance packageDistance
+	var tie bool
+
+	for _, sym := range append([]*Symbol{symbol}, symbol.Conflicts...) {
+		if sym.Label == label.NoLabel || sym.Label.Repo != from.Repo {
+			continue
+		}
+		distance := newPackageDistance(from.Pkg, sym.Label.Pkg)
+		if best == nil || distance.less(bestDistance) {
+			best, bestDistance, tie = sym, distance, false
+			continue
+		}
+		if !bestDistance.less(distance) && sym.Label != best.Label {
+			tie = true
+		}
+	}
+
+	if best == nil || tie {
+		return nil, false
+	}
+	return best, true
+}
+
+// packageDistance represents the path from one package to another via their
+// closest common ancestor.
+type packageDistance struct {
+	// up is the number of segments from the source package to the common
+	// ancestor.
+	up int
+	// down is the number of segments from the common ancestor to the target
+	// package.
+	down int
+}
+
+func newPackageDistance(from, to string) packageDistance {
+	a := splitPackage(from)
+	b := splitPackage(to)
+	var common int
+	for common < len(a) && common < len(b) && a[common] == b[common] {
+		common++
+	}
+	return packageDistance{up: len(a) - common, down: len(b) - common}
+}
+
+// isAncestor reports whether the target package is a proper ancestor of the
+// source package.
+func (d packageDistance) isAncestor() bool {
+	return d.up > 0 && d.down == 0
+}
+
+// less reports whether d is closer than other.  Fewer steps up wins; at the
+// same level a package below the common ancestor is preferred over the ancestor
+// itself, then fewer steps down wins.
+func (d packageDistance) less(other packageDistance) bool {
+	if d.up != other.up {
+		return d.up < other.up
+	}
+	if d.isAncestor() != other.isAncestor() {
+		return !d.isAncestor()
+	}
+	return d.down < other.down
+}
+
+func splitPackage(pkg string) []string {
+	if pkg == "" {
+		return nil
+	}
+	return strings.Split(pkg, "/")
+}
@@ -0,0 +1,110 @@
+package resolver_test
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+	"github.com/stackb/scala-gazelle/pkg/resolver/mocks"
+)
+
+func TestProximityConflictResolver(t *testing.T) {
+	for name, tc := range map[string]struct {
+		from   string
+		labels []string
+		want   label.Label
+		wantOk bool
+	}{
+		"degenerate": {
+			from:   "//a/b:lib",
+			labels: []string{"//x:x"},
+			want:   label.Label{Pkg: "x", Name: "x"},
+			wantOk: true,
+		},
+		"prefers same package": {
+			from:   "//a/b:lib",
+			labels: []string{"//a:a", "//a/b:b", "//a/c:c"},
+			want:   label.Label{Pkg: "a/b", Name: "b"},
+			wantOk: true,
+		},
+		"prefers child over sibling": {
+			from:   "//a/b:lib",
+			labels: []string{"//a/c:c", "//a/b/d:d"},
+			want:   label.Label{Pkg: "a/b/d", Name: "d"},
+			wantOk: true,
+		},
+		"prefers sibling over ancestor": {
+			from:   "//a/b:lib",
+			labels: []string{"//a:a", "//a/c:c"},
+			want:   label.Label{Pkg: "a/c", Name: "c"},
+			wantOk: true,
+		},
+		"prefers ancestor over cousin": {
+			from:   "//a/b:lib",
+			labels: []string{"//x/y:y", "//a:a"},
+			want:   label.Label{Pkg: "a", Name: "a"},
+			wantOk: true,
+		},
+		"prefers nearer sibling": {
+			from:   "//a/b:lib",
+			labels: []string{"//a/c/d:d", "//a/c:c"},
+			want:   label.Label{Pkg: "a/c", Name: "c"},
+			wantOk: true,
+		},
+		"root package is an ancestor": {
+			from:   "//a:lib",
+			labels: []string{"//:root", "//b:b"},
+			want:   label.Label{Pkg: "b", Name: "b"},
+			wantOk: true,
+		},
+		"tie is unresolved": {
+			from:   "//a/b:lib",
+			labels: []string{"//a/c:c", "//a/d:d"},
+		},
+		"tie in same package is unresolved": {
+			from:   "//a:lib",
+			labels: []string{"//a:x", "//a:y"},
+		},
+		"ignores external repositories": {
+			from:   "//a/b:lib",
+			labels: []string{"@maven//:a_b", "//x:x"},
+			want:   label.Label{Pkg: "x", Name: "x"},
+			wantOk: true,
+		},
+		"no candidates in same repository": {
+			from:   "//a/b:lib",
+			labels: []string{"@maven//:a_b", "@maven//:a_c"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			universe := mocks.NewUniverse(t)
+			from := mustParseLabel(t, tc.from)
+			symbol := &resolver.Symbol{Name: "a.Foo"}
+			for i, lbl := range tc.labels {
+				sym := &resolver.Symbol{Name: "a.Foo", Label: mustParseLabel(t, lbl)}
+				if i == 0 {
+					symbol = sym
+				} else {
+					symbol.Conflicts = append(symbol.Conflicts, sym)
+				}
+			}
+			cr := resolver.ProximityConflictResolver{}
+			if _, ok := cr.ResolveConflict(universe, &rule.Rule{}, nil, &resolver.Import{}, symbol); ok {
+				t.Error("ResolveConflict: want not ok without label")
+			}
+			gotSymbol, gotOk := cr.ResolveConflictFrom(universe, &rule.Rule{}, from, nil, &resolver.Import{}, symbol)
+			if diff := cmp.Diff(tc.wantOk, gotOk); diff != "" {
+				t.Errorf("ok (-want +got):\n%s", diff)
+			}
+			got := label.NoLabel
+			if gotSymbol != nil {
+				got = gotSymbol.Label
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
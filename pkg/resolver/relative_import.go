@@ -0,0 +1,45 @@
+package resolver
+
+import "strings"
+
+// ResolveRelativeImport attempts to resolve the given (possibly relative)
+// import against each of the given prefixes, in order.  For example, the
+// import 'concurrent.duration._' with prefix 'scala' resolves to
+// 'scala.concurrent.duration._' if that package is known to the scope.  The
+// first candidate that is known in the scope is returned.
+//
+// A candidate is only considered known if the match extends beyond the
+// prefix; a symbol for the prefix itself (e.g. a package symbol) does not
+// count.
+func ResolveRelativeImport(scope Scope, imp string, prefixes []string) (string, bool) {
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			continue
+		}
+		candidate := prefix + "." + imp
+		if isKnownRelativeImport(scope, prefix, candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+func isKnownRelativeImport(scope Scope, prefix, candidate string) bool {
+	name := candidate
+	if wimp, ok := IsWildcardImport(candidate); ok {
+		if _, ok := scope.GetScope(wimp); ok {
+			return true
+		}
+		name = wimp
+	}
+	sym, ok := scope.GetSymbol(name)
+	if !ok {
+		return false
+	}
+	if sym.Name == name {
+		return true
+	}
+	// the symbol is a prefix of the name (e.g. a nested member of an
+	// object); it must be longer than the prefix we are trying.
+	return len(sym.Name) > len(prefix) && strings.HasPrefix(name, sym.Name+".")
+}
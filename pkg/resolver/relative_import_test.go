@@ -0,0 +1,79 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/google/go-cmp/cmp"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+func TestResolveRelativeImport(t *testing.T) {
+	for name, tc := range map[string]struct {
+		symbols  []*Symbol
+		imp      string
+		prefixes []string
+		want     string
+		wantOK   bool
+	}{
+		"degenerate": {},
+		"no prefixes": {
+			symbols: []*Symbol{
+				makeSymbol(sppb.ImportType_CLASS, "scala.concurrent.duration.Duration", label.NoLabel),
+			},
+			imp: "concurrent.duration._",
+		},
+		"wildcard resolves against prefix": {
+			symbols: []*Symbol{
+				makeSymbol(sppb.ImportType_CLASS, "scala.concurrent.duration.Duration", label.NoLabel),
+			},
+			imp:      "concurrent.duration._",
+			prefixes: []string{"com.foo", "scala"},
+			want:     "scala.concurrent.duration._",
+			wantOK:   true,
+		},
+		"first matching prefix wins": {
+			symbols: []*Symbol{
+				makeSymbol(sppb.ImportType_OBJECT, "com.foo.bar.Baz", label.NoLabel),
+				makeSymbol(sppb.ImportType_OBJECT, "com.bar.Baz", label.NoLabel),
+			},
+			imp:      "bar.Baz",
+			prefixes: []string{"com.foo", "com"},
+			want:     "com.foo.bar.Baz",
+			wantOK:   true,
+		},
+		"nested member of an object": {
+			symbols: []*Symbol{
+				makeSymbol(sppb.ImportType_OBJECT, "example.MainContext", label.NoLabel),
+			},
+			imp:      "MainContext.asys",
+			prefixes: []string{"example"},
+			want:     "example.MainContext.asys",
+			wantOK:   true,
+		},
+		"symbol for the prefix itself is not a match": {
+			symbols: []*Symbol{
+				makeSymbol(sppb.ImportType_PACKAGE, "com.foo", label.NoLabel),
+			},
+			imp:      "bar.Baz",
+			prefixes: []string{"com.foo"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			scope := NewTrieScope()
+			for _, sym := range tc.symbols {
+				if err := scope.PutSymbol(sym); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, gotOK := ResolveRelativeImport(scope, tc.imp, tc.prefixes)
+			if diff := cmp.Diff(tc.wantOK, gotOK); diff != "" {
+				t.Errorf("ok (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
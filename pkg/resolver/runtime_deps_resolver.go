@@ -0,0 +1,29 @@
+package resolver
+
+import (
+	"flag"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+// RuntimeDepsResolver implementations are capable of discovering symbols that
+// a rule needs at runtime but not at compile time, such as service
+// implementations loaded via java.util.ServiceLoader or classes loaded via
+// reflection.
+type RuntimeDepsResolver interface {
+	// Name is the canonical name for the resolver
+	Name() string
+	// RegisterFlags configures the flags.  RegisterFlags is called for all
+	// resolvers whether they are enabled or not.
+	RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config)
+	// CheckFlags asserts that the flags are correct.  CheckFlags is only called
+	// if the resolver is enabled.
+	CheckFlags(fs *flag.FlagSet, c *config.Config) error
+	// ResolveRuntimeDeps takes the context rule, the absolute path of the
+	// package directory, and the package-relative filenames named in the
+	// 'srcs' and 'resources' attributes of the rule.  The implementation
+	// should put an import into the given map for each symbol that is needed
+	// at runtime.
+	ResolveRuntimeDeps(r *rule.Rule, dir string, filenames []string, imports ImportMap) error
+}
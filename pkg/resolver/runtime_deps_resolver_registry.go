@@ -0,0 +1,13 @@
+package resolver
+
+// RuntimeDepsResolverRegistry is an index of known runtime deps resolvers keyed
+// by their name.
+type RuntimeDepsResolverRegistry interface {
+	// GetRuntimeDepsResolver returns the named resolver.  If not known `(nil,
+	// false)` is returned.
+	GetRuntimeDepsResolver(name string) (RuntimeDepsResolver, bool)
+
+	// PutRuntimeDepsResolver adds the given resolver to the registry.  It is
+	// an error to attempt duplicate registration of the same resolver twice.
+	PutRuntimeDepsResolver(name string, r RuntimeDepsResolver) error
+}
@@ -0,0 +1,98 @@
+package resolver
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+func init() {
+	rr := &ServiceLoaderRuntimeDepsResolver{}
+	GlobalRuntimeDepsResolverRegistry().PutRuntimeDepsResolver(rr.Name(), rr)
+}
+
+// serviceLoaderDir is the resource directory scanned by
+// java.util.ServiceLoader.
+const serviceLoaderDir = "META-INF/services/"
+
+// ServiceLoaderRuntimeDepsResolver implements a strategy where the
+// implementation classes named in META-INF/services provider-configuration
+// files are runtime dependencies.
+type ServiceLoaderRuntimeDepsResolver struct {
+}
+
+// Name implements part of the resolver.RuntimeDepsResolver interface.
+func (s *ServiceLoaderRuntimeDepsResolver) Name() string {
+	return "service_loader"
+}
+
+// RegisterFlags implements part of the resolver.RuntimeDepsResolver interface.
+func (s *ServiceLoaderRuntimeDepsResolver) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {
+}
+
+// CheckFlags implements part of the resolver.RuntimeDepsResolver interface.
+func (s *ServiceLoaderRuntimeDepsResolver) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	return nil
+}
+
+// ResolveRuntimeDeps implements part of the resolver.RuntimeDepsResolver
+// interface.  Each non-comment line of a file under META-INF/services is the
+// fully-qualified name of a service implementation.
+func (s *ServiceLoaderRuntimeDepsResolver) ResolveRuntimeDeps(r *rule.Rule, dir string, filenames []string, imports ImportMap) error {
+	for _, filename := range filenames {
+		if !isServiceLoaderFile(filename) {
+			continue
+		}
+		names, err := readServiceLoaderFile(filepath.Join(dir, filename))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // likely a label rather than a file
+			}
+			return err
+		}
+		for _, name := range names {
+			imports.Put(NewRuntimeImport(name, filename))
+		}
+	}
+	return nil
+}
+
+// isServiceLoaderFile tests whether the given filename is a
+// provider-configuration file.
+func isServiceLoaderFile(filename string) bool {
+	filename = filepath.ToSlash(filename)
+	if !strings.HasPrefix(filename, serviceLoaderDir) && !strings.Contains(filename, "/"+serviceLoaderDir) {
+		return false
+	}
+	return !strings.HasSuffix(filename, "/")
+}
+
+// readServiceLoaderFile returns the list of implementation class names in the
+// given provider-configuration file.  Comments start with '#'.
+func readServiceLoaderFile(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, scanner.Err()
+}
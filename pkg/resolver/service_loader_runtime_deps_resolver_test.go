@@ -0,0 +1,84 @@
+package resolver_test
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/bazelbuild/bazel-gazelle/testtools"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+)
+
+func TestServiceLoaderRuntimeDepsResolver(t *testing.T) {
+	for name, tc := range map[string]struct {
+		files     []testtools.FileSpec
+		filenames []string
+		want      []string
+		wantErr   string
+	}{
+		"degenerate": {
+			want: []string{},
+		},
+		"service files": {
+			files: []testtools.FileSpec{
+				{
+					Path: "src/main/resources/META-INF/services/com.foo.Service",
+					Content: `# implementations
+com.foo.impl.ServiceA
+  com.foo.impl.ServiceB # trailing comment
+
+`,
+				},
+				{
+					Path:    "META-INF/services/com.bar.Codec",
+					Content: "com.bar.JsonCodec\n",
+				},
+				{
+					Path:    "src/main/resources/application.conf",
+					Content: "com.foo.NotAService\n",
+				},
+			},
+			filenames: []string{
+				"src/main/resources/META-INF/services/com.foo.Service",
+				"META-INF/services/com.bar.Codec",
+				"src/main/resources/application.conf",
+				"Main.scala",
+			},
+			want: []string{
+				"✅ com.foo.impl.ServiceA<> (RUNTIME of com.foo.Service)",
+				"✅ com.foo.impl.ServiceB<> (RUNTIME of com.foo.Service)",
+				"✅ com.bar.JsonCodec<> (RUNTIME of com.bar.Codec)",
+			},
+		},
+		"missing files are skipped": {
+			filenames: []string{"META-INF/services/com.foo.Service"},
+			want:      []string{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, cleanup := testtools.CreateFiles(t, tc.files)
+			defer cleanup()
+
+			imports := resolver.NewImportMap()
+			rr := &resolver.ServiceLoaderRuntimeDepsResolver{}
+			err := rr.ResolveRuntimeDeps(rule.NewRule("scala_library", "lib"), dir, tc.filenames, imports)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Errorf("error (-want +got):\n%s", diff)
+			}
+
+			got := make([]string, 0, len(imports.Keys()))
+			for _, imp := range imports.Values() {
+				got = append(got, imp.String())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -25,6 +25,10 @@ type Symbol struct {
 	Label label.Label
 	// Provider is the name of the provider that supplied the symbol.
 	Provider string
+	// Cached is true if the symbol was loaded from the cache rather than
+	// from a rule visited during this run.  A symbol that is not cached
+	// supersedes a cached one.
+	Cached bool
 	// Conflicts is a list of symbols provided by another provider or label.
 	Conflicts []*Symbol
 	// Requires is a list of other symbols that are required by this one.
@@ -54,8 +58,11 @@ func (s *Symbol) Conflict(sym *Symbol) {
 			log.Printf("conflicting symbols %q: %s", s.Name, diff)
 		}
 	}
-	for _, conflict := range s.Conflicts {
+	for i, conflict := range s.Conflicts {
 		if sym.Label == conflict.Label {
+			if conflict.Cached && !sym.Cached {
+				s.Conflicts[i] = sym
+			}
 			return
 		}
 	}
@@ -72,7 +79,11 @@ func SymbolConfictMessage(symbol *Symbol, imp *Import, from label.Label) string
 		return ""
 	}
 	lines := make([]string, 0, len(symbol.Conflicts)+3)
-	lines = append(lines, fmt.Sprintf("[%v]: Ambiguous resolve of %v %q (symbol is provided by %d labels) [%s]", from, symbol.Type, symbol.Name, len(symbol.Conflicts)+1, imp))
+	message := fmt.Sprintf("[%v]: Ambiguous resolve of %v %q (symbol is provided by %d labels) [%s]", from, symbol.Type, symbol.Name, len(symbol.Conflicts)+1, imp)
+	if location := imp.Location(); location != "" {
+		message = location + ": " + message
+	}
+	lines = append(lines, message)
 	if symbol.Type == sppb.ImportType_PACKAGE || symbol.Type == sppb.ImportType_PROTO_PACKAGE {
 		lines = append(lines, " - Possible action: remove wildcard or package import")
 	}
@@ -96,11 +96,58 @@ func (r *TrieScope) Put(name string, symbol *Symbol) error {
 			current.Conflict(symbol)
 			return nil
 		}
+		// the conflicts of a cached symbol are retained when it is
+		// superseded, as they may come from rules that are not visited.
+		if current.Cached && !symbol.Cached {
+			symbol.Conflicts = append(symbol.Conflicts, current.Conflicts...)
+		}
 	}
 	r.trie.Put(name, symbol)
 	return nil
 }
 
+// Prune removes the symbols (and conflicts) for which the given function
+// returns true.  If a symbol is removed but some of its conflicts are not, the
+// first remaining conflict takes its place.  It returns the number of symbols
+// removed.
+func (r *TrieScope) Prune(remove func(*Symbol) bool) (removed int) {
+	var keys []string
+	var symbols []*Symbol
+	r.trie.Walk(func(key string, value interface{}) error {
+		keys = append(keys, key)
+		symbols = append(symbols, value.(*Symbol))
+		return nil
+	})
+
+	for i, symbol := range symbols {
+		var conflicts []*Symbol
+		for _, conflict := range symbol.Conflicts {
+			if remove(conflict) {
+				removed++
+			} else {
+				conflicts = append(conflicts, conflict)
+			}
+		}
+		symbol.Conflicts = conflicts
+		if !remove(symbol) {
+			continue
+		}
+		removed++
+		if len(conflicts) == 0 {
+			r.trie.Delete(keys[i])
+			continue
+		}
+		next := conflicts[0]
+		next.Conflicts = nil
+		if len(conflicts) > 1 {
+			next.Conflicts = conflicts[1:]
+		}
+		r.trie.Put(keys[i], next)
+	}
+
+	return
+}
+
 // String implements the fmt.Stringer interface.
 func (r *TrieScope) String() string {
 	return r.trie.String()
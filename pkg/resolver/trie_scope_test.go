@@ -165,6 +165,118 @@ type result struct {
 	Path    int
 }
 
+func TestTrieScopeCached(t *testing.T) {
+	a := label.Label{Pkg: "a", Name: "a"}
+	b := label.Label{Pkg: "b", Name: "b"}
+	c := label.Label{Pkg: "c", Name: "c"}
+
+	symbol := func(from label.Label, cached bool) *Symbol {
+		return &Symbol{
+			Type:     sppb.ImportType_CLASS,
+			Name:     "com.foo.Bar",
+			Label:    from,
+			Provider: "test",
+			Cached:   cached,
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		symbols []*Symbol
+		want    *Symbol
+	}{
+		"fresh supersedes cached of the same label": {
+			symbols: []*Symbol{symbol(a, true), symbol(a, false)},
+			want:    symbol(a, false),
+		},
+		"fresh supersedes cached conflict of the same label": {
+			symbols: []*Symbol{symbol(a, true), symbol(b, true), symbol(b, false)},
+			want: &Symbol{
+				Type:      sppb.ImportType_CLASS,
+				Name:      "com.foo.Bar",
+				Label:     a,
+				Provider:  "test",
+				Cached:    true,
+				Conflicts: []*Symbol{symbol(b, false)},
+			},
+		},
+		"conflicts of a superseded cached symbol are retained": {
+			symbols: []*Symbol{symbol(a, true), symbol(c, true), symbol(a, false)},
+			want: &Symbol{
+				Type:      sppb.ImportType_CLASS,
+				Name:      "com.foo.Bar",
+				Label:     a,
+				Provider:  "test",
+				Conflicts: []*Symbol{symbol(c, true)},
+			},
+		},
+		"cached does not supersede fresh": {
+			symbols: []*Symbol{symbol(a, false), symbol(b, true)},
+			want: &Symbol{
+				Type:      sppb.ImportType_CLASS,
+				Name:      "com.foo.Bar",
+				Label:     a,
+				Provider:  "test",
+				Conflicts: []*Symbol{symbol(b, true)},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			scope := NewTrieScope()
+			for _, known := range tc.symbols {
+				if err := scope.PutSymbol(known); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, _ := scope.GetSymbol("com.foo.Bar")
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTrieScopePrune(t *testing.T) {
+	a := label.Label{Pkg: "a", Name: "a"}
+	b := label.Label{Pkg: "b", Name: "b"}
+
+	symbol := func(name string, from label.Label, cached bool) *Symbol {
+		return &Symbol{
+			Type:     sppb.ImportType_CLASS,
+			Name:     name,
+			Label:    from,
+			Provider: "test",
+			Cached:   cached,
+		}
+	}
+
+	scope := NewTrieScope()
+	for _, sym := range []*Symbol{
+		symbol("com.foo.A", a, true),
+		symbol("com.foo.B", a, true),
+		symbol("com.foo.B", b, true),
+		symbol("com.foo.C", b, false),
+	} {
+		if err := scope.PutSymbol(sym); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed := scope.Prune(func(sym *Symbol) bool {
+		return sym.Cached && sym.Label == a
+	})
+	if removed != 2 {
+		t.Errorf("want 2 symbols removed, got %d", removed)
+	}
+
+	want := []*Symbol{
+		symbol("com.foo.B", b, true),
+		symbol("com.foo.C", b, false),
+	}
+	if diff := cmp.Diff(want, scope.Symbols()); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+}
+
 func TestImportSegmenter(t *testing.T) {
 	for name, tc := range map[string]struct {
 		want []result
@@ -7,6 +7,7 @@ type Universe interface {
 	KnownRuleRegistry
 	ConflictResolverRegistry
 	DepsCleanerRegistry
+	RuntimeDepsResolverRegistry
 	Scope
 	SymbolResolver
 }
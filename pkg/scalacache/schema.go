@@ -0,0 +1,183 @@
+// Package scalacache implements versioning and maintenance of the scala-gazelle
+// cache file (see build/stack/gazelle/scala/cache/cache.proto).
+package scalacache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+
+	scpb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/cache"
+)
+
+// SchemaVersion is the current version of the cache schema.  It must be
+// incremented (and a migration added) whenever the meaning of existing cache
+// fields changes.
+//
+// Version history:
+//
+//	0: unversioned.  Rule hashes do not include the parser version.
+//	1: rule hashes include the parser version.
+const SchemaVersion int32 = 1
+
+// migrations is the list of functions that migrate a cache from version i to
+// i+1.  Each returns a description of what was done, or the empty string if
+// nothing changed.
+var migrations = []func(c *scpb.Cache) string{
+	// 0 -> 1: rule hashes changed, the rules would never match.  Files are
+	// keyed by their own parser version and are kept.
+	func(c *scpb.Cache) string {
+		n := len(c.Rules)
+		if n == 0 {
+			return ""
+		}
+		c.Rules = nil
+		return fmt.Sprintf("rule hashes now include the parser version; evicted %d rule(s)", n)
+	},
+}
+
+// Migrate brings the given cache up to date with the current schema and
+// parser version.  Entries that cannot be migrated are evicted.  It returns a
+// list of log messages that describe the changes, which is empty if the cache
+// was already up to date.
+func Migrate(c *scpb.Cache, parserVersion string) []string {
+	var messages []string
+
+	if c.SchemaVersion > SchemaVersion {
+		messages = append(messages, fmt.Sprintf("schema version %d is newer than %d (written by a newer scala-gazelle?); evicted %d rule(s) and %d file(s)",
+			c.SchemaVersion, SchemaVersion, len(c.Rules), len(c.Files)))
+		c.Rules = nil
+		c.Files = nil
+	}
+	for v := c.SchemaVersion; v < SchemaVersion; v++ {
+		if msg := migrations[v](c); msg != "" {
+			messages = append(messages, fmt.Sprintf("migrated schema version %d -> %d: %s", v, v+1, msg))
+		}
+	}
+	c.SchemaVersion = SchemaVersion
+
+	if c.ParserVersion != parserVersion {
+		if c.ParserVersion != "" && (len(c.Rules) > 0 || len(c.Files) > 0) {
+			files := c.Files[:0]
+			for _, file := range c.Files {
+				if file.ParserVersion == parserVersion {
+					files = append(files, file)
+				}
+			}
+			messages = append(messages, fmt.Sprintf("parser version changed (%q -> %q); evicted %d rule(s) and %d file(s)",
+				c.ParserVersion, parserVersion, len(c.Rules), len(c.Files)-len(files)))
+			c.Rules = nil
+			c.Files = files
+		}
+		c.ParserVersion = parserVersion
+	}
+
+	return messages
+}
+
+// Validate checks the internal consistency of the given cache and returns a
+// list of problems, which is empty if the cache is valid.
+func Validate(c *scpb.Cache) []error {
+	var errs []error
+
+	if c.SchemaVersion != SchemaVersion {
+		errs = append(errs, fmt.Errorf("schema version is %d (want %d)", c.SchemaVersion, SchemaVersion))
+	}
+
+	labels := make(map[string]bool)
+	for i, rule := range c.Rules {
+		if _, err := label.Parse(rule.Label); err != nil {
+			errs = append(errs, fmt.Errorf("rule #%d: invalid label %q: %w", i, rule.Label, err))
+		}
+		if labels[rule.Label] {
+			errs = append(errs, fmt.Errorf("rule %s: duplicate label", rule.Label))
+		}
+		labels[rule.Label] = true
+		if rule.Sha256 == "" {
+			errs = append(errs, fmt.Errorf("rule %s: missing sha256", rule.Label))
+		}
+		if len(rule.Files) == 0 {
+			errs = append(errs, fmt.Errorf("rule %s: no files", rule.Label))
+		}
+	}
+
+	type fileKey struct{ sha256, parserVersion string }
+	keys := make(map[fileKey]bool)
+	for i, file := range c.Files {
+		if file.File == nil || file.File.Filename == "" {
+			errs = append(errs, fmt.Errorf("file #%d: missing filename", i))
+			continue
+		}
+		if file.Sha256 == "" {
+			errs = append(errs, fmt.Errorf("file %s: missing sha256", file.File.Filename))
+		}
+		if file.File.Error != "" {
+			errs = append(errs, fmt.Errorf("file %s: cached parse error", file.File.Filename))
+		}
+		key := fileKey{file.Sha256, file.ParserVersion}
+		if keys[key] {
+			errs = append(errs, fmt.Errorf("file %s: duplicate entry for sha256 %s (parser version %q)", file.File.Filename, file.Sha256, file.ParserVersion))
+		}
+		keys[key] = true
+	}
+
+	return errs
+}
+
+// Prune removes the rules and files of the given cache that refer to files
+// that do not exist in the given root directory.  It returns the number of
+// rules and files removed.
+func Prune(c *scpb.Cache, root string) (rules, files int) {
+	exists := func(filename string) bool {
+		_, err := os.Stat(filepath.Join(root, filename))
+		return err == nil
+	}
+
+	keptRules := c.Rules[:0]
+	for _, rule := range c.Rules {
+		keep := true
+		for _, file := range rule.Files {
+			if !exists(file.Filename) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			keptRules = append(keptRules, rule)
+		} else {
+			delete(c.RuleVisitTimes, rule.Label)
+			rules++
+		}
+	}
+	c.Rules = keptRules
+
+	keptFiles := c.Files[:0]
+	for _, file := range c.Files {
+		if file.File != nil && exists(file.File.Filename) {
+			keptFiles = append(keptFiles, file)
+		} else {
+			files++
+		}
+	}
+	c.Files = keptFiles
+
+	return
+}
+
+// ParserVersions returns a sorted list of the parser versions of the files
+// in the given cache, with the number of files for each.
+func ParserVersions(c *scpb.Cache) []string {
+	counts := make(map[string]int)
+	for _, file := range c.Files {
+		counts[file.ParserVersion]++
+	}
+	versions := make([]string, 0, len(counts))
+	for version, n := range counts {
+		versions = append(versions, fmt.Sprintf("%s (%d)", version, n))
+	}
+	sort.Strings(versions)
+	return versions
+}
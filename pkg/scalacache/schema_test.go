@@ -0,0 +1,202 @@
+package scalacache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/testtools"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	scpb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/cache"
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+)
+
+func TestMigrate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cache         *scpb.Cache
+		parserVersion string
+		want          *scpb.Cache
+		wantMessages  []string
+	}{
+		"empty": {
+			cache:         &scpb.Cache{},
+			parserVersion: "scalameta-1",
+			want:          &scpb.Cache{SchemaVersion: SchemaVersion, ParserVersion: "scalameta-1"},
+		},
+		"up to date": {
+			cache: &scpb.Cache{
+				SchemaVersion: SchemaVersion,
+				ParserVersion: "scalameta-1",
+				Rules:         []*sppb.Rule{{Label: "//a:a"}},
+			},
+			parserVersion: "scalameta-1",
+			want: &scpb.Cache{
+				SchemaVersion: SchemaVersion,
+				ParserVersion: "scalameta-1",
+				Rules:         []*sppb.Rule{{Label: "//a:a"}},
+			},
+		},
+		"unversioned": {
+			cache: &scpb.Cache{
+				PackageCount: 10,
+				Rules:        []*sppb.Rule{{Label: "//a:a"}, {Label: "//b:b"}},
+				Files:        []*scpb.ParsedFile{{Sha256: "1", ParserVersion: "scalameta-1"}},
+			},
+			parserVersion: "scalameta-1",
+			want: &scpb.Cache{
+				PackageCount:  10,
+				SchemaVersion: SchemaVersion,
+				ParserVersion: "scalameta-1",
+				Files:         []*scpb.ParsedFile{{Sha256: "1", ParserVersion: "scalameta-1"}},
+			},
+			wantMessages: []string{
+				"migrated schema version 0 -> 1: rule hashes now include the parser version; evicted 2 rule(s)",
+			},
+		},
+		"newer schema": {
+			cache: &scpb.Cache{
+				PackageCount:  10,
+				SchemaVersion: SchemaVersion + 1,
+				ParserVersion: "scalameta-1",
+				Rules:         []*sppb.Rule{{Label: "//a:a"}},
+				Files:         []*scpb.ParsedFile{{Sha256: "1", ParserVersion: "scalameta-1"}},
+			},
+			parserVersion: "scalameta-1",
+			want: &scpb.Cache{
+				PackageCount:  10,
+				SchemaVersion: SchemaVersion,
+				ParserVersion: "scalameta-1",
+			},
+			wantMessages: []string{
+				fmt.Sprintf("schema version %d is newer than %d (written by a newer scala-gazelle?); evicted 1 rule(s) and 1 file(s)", SchemaVersion+1, SchemaVersion),
+			},
+		},
+		"parser version changed": {
+			cache: &scpb.Cache{
+				SchemaVersion: SchemaVersion,
+				ParserVersion: "scalameta-1",
+				Rules:         []*sppb.Rule{{Label: "//a:a"}},
+				Files: []*scpb.ParsedFile{
+					{Sha256: "1", ParserVersion: "scalameta-1"},
+					{Sha256: "2", ParserVersion: "lexer-1"},
+				},
+			},
+			parserVersion: "lexer-1",
+			want: &scpb.Cache{
+				SchemaVersion: SchemaVersion,
+				ParserVersion: "lexer-1",
+				Files: []*scpb.ParsedFile{
+					{Sha256: "2", ParserVersion: "lexer-1"},
+				},
+			},
+			wantMessages: []string{
+				`parser version changed ("scalameta-1" -> "lexer-1"); evicted 1 rule(s) and 1 file(s)`,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := Migrate(tc.cache, tc.parserVersion)
+			if diff := cmp.Diff(tc.wantMessages, got); diff != "" {
+				t.Errorf("messages (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want, tc.cache, protocmp.Transform()); diff != "" {
+				t.Errorf("cache (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cache *scpb.Cache
+		want  []string
+	}{
+		"valid": {
+			cache: &scpb.Cache{
+				SchemaVersion: SchemaVersion,
+				Rules: []*sppb.Rule{
+					{Label: "//a:a", Sha256: "1", Files: []*sppb.File{{Filename: "a/A.scala"}}},
+				},
+				Files: []*scpb.ParsedFile{
+					{Sha256: "2", ParserVersion: "v1", File: &sppb.File{Filename: "a/A.scala"}},
+				},
+			},
+		},
+		"invalid": {
+			cache: &scpb.Cache{
+				Rules: []*sppb.Rule{
+					{Label: "//a:a", Sha256: "1", Files: []*sppb.File{{Filename: "a/A.scala"}}},
+					{Label: "//a:a"},
+					{Label: "//a:b:c", Sha256: "1", Files: []*sppb.File{{Filename: "a/A.scala"}}},
+				},
+				Files: []*scpb.ParsedFile{
+					{Sha256: "2", ParserVersion: "v1", File: &sppb.File{Filename: "a/A.scala"}},
+					{Sha256: "2", ParserVersion: "v1", File: &sppb.File{Filename: "b/A.scala"}},
+					{ParserVersion: "v1", File: &sppb.File{Filename: "a/B.scala", Error: "syntax error"}},
+					{Sha256: "3"},
+				},
+			},
+			want: []string{
+				"schema version is 0 (want 1)",
+				"rule //a:a: duplicate label",
+				"rule //a:a: missing sha256",
+				"rule //a:a: no files",
+				`rule #2: invalid label "//a:b:c": label parse error: name has invalid characters: "//a:b:c"`,
+				"file b/A.scala: duplicate entry for sha256 2 (parser version \"v1\")",
+				"file a/B.scala: missing sha256",
+				"file a/B.scala: cached parse error",
+				"file #3: missing filename",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, err := range Validate(tc.cache) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir, cleanup := testtools.CreateFiles(t, []testtools.FileSpec{
+		{Path: "a/A.scala"},
+		{Path: "b/B.scala"},
+	})
+	defer cleanup()
+
+	cache := &scpb.Cache{
+		Rules: []*sppb.Rule{
+			{Label: "//a:a", Files: []*sppb.File{{Filename: "a/A.scala"}}},
+			{Label: "//b:b", Files: []*sppb.File{{Filename: "b/B.scala"}, {Filename: "b/Missing.scala"}}},
+		},
+		RuleVisitTimes: map[string]int64{"//a:a": 1, "//b:b": 2},
+		Files: []*scpb.ParsedFile{
+			{Sha256: "1", File: &sppb.File{Filename: "a/A.scala"}},
+			{Sha256: "2", File: &sppb.File{Filename: "b/Missing.scala"}},
+			{Sha256: "3"},
+		},
+	}
+
+	rules, files := Prune(cache, dir)
+	if rules != 1 || files != 2 {
+		t.Errorf("want 1 rule and 2 files pruned, got %d and %d", rules, files)
+	}
+
+	want := &scpb.Cache{
+		Rules: []*sppb.Rule{
+			{Label: "//a:a", Files: []*sppb.File{{Filename: "a/A.scala"}}},
+		},
+		RuleVisitTimes: map[string]int64{"//a:a": 1},
+		Files: []*scpb.ParsedFile{
+			{Sha256: "1", File: &sppb.File{Filename: "a/A.scala"}},
+		},
+	}
+	if diff := cmp.Diff(want, cache, protocmp.Transform()); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+}
@@ -3,6 +3,8 @@ package scalaconfig
 import (
 	"fmt"
 	"log"
+	"path"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -54,6 +56,70 @@ const (
 	// gazelle:scala_build_file_generate true
 	scalaGenerateBuildFilesDirective = "scala_generate_build_files"
 
+	// Set the filename patterns that identify test sources when generating
+	// rules for a package that has no existing rules.  Files that match are
+	// assigned to the generated test rule; all others are assigned to the
+	// generated library rule.  Defaults to '*Test.scala *Spec.scala
+	// *Suite.scala'.
+	//
+	// gazelle:scala_test_file_patterns *Test.scala *Spec.scala *Suite.scala
+	scalaTestFilePatternsDirective = "scala_test_file_patterns"
+
+	// Generate a binary rule for each top-level object in a library rule that
+	// is an application entrypoint (extends App, IOApp, ZIOAppDefault, or
+	// defines 'def main(args: Array[String])').  Defaults to false.
+	//
+	// gazelle:scala_generate_binaries true
+	scalaGenerateBinariesDirective = "scala_generate_binaries"
+
+	// Also export the dependencies of library rules whose symbols appear in
+	// the semanticdb signature of a public member.  Dependencies that provide
+	// a superclass (extends clause) are always exported.  Defaults to false.
+	//
+	// gazelle:scala_export_signatures true
+	scalaExportSignaturesDirective = "scala_export_signatures"
+
+	// Set how .scala files are grouped into generated rules.  'directory' (the
+	// default) generates rules for the files in the directory.  'package'
+	// generates rules for the files in the directory and all subdirectories
+	// that do not have their own BUILD file.  'file' generates one rule per
+	// file.
+	//
+	// gazelle:scala_granularity file|package|directory
+	scalaGranularityDirective = "scala_granularity"
+
+	// Set the name template of rules generated in 'file' granularity mode.
+	// Supported variables are %{basename} (the filename without the .scala
+	// extension) and %{dirname} (the name of the package directory).
+	// Defaults to '%{basename}_scala'.
+	//
+	// gazelle:scala_file_rule_name %{basename}_scala
+	scalaFileRuleNameDirective = "scala_file_rule_name"
+
+	// Set what happens to a rule when one of its files fails to parse.
+	// 'keep_existing' (the default) leaves the deps of the rule untouched.
+	// 'warn' logs a warning and resolves the deps from the files that did
+	// parse.  'fail' reports all failing files and exits non-zero before any
+	// BUILD file is changed.
+	//
+	// gazelle:scala_parse_error_policy fail|keep_existing|warn
+	scalaParseErrorPolicyDirective = "scala_parse_error_policy"
+
+	// Set the scalameta dialect used to parse the .scala files of rules (e.g.
+	// 'Scala213' or 'Scala3').  'auto' (the default) parses each file with the
+	// Scala 2.13 dialect first and with the Scala 3 dialect if that fails.
+	//
+	// gazelle:scala_dialect auto|Scala213|Scala3
+	scalaDialectDirective = "scala_dialect"
+
+	// Set how wildcard imports of packages are resolved.  'package' (the
+	// default) resolves the import to the package symbol.  'names' resolves
+	// the names used in the file against the symbols in the package, such that
+	// only the rules that provide the names actually used become deps.
+	//
+	// gazelle:scala_wildcard_imports names|package
+	scalaWildcardImportsDirective = "scala_wildcard_imports"
+
 	// Turn on the wildcard import fixer
 	//
 	// gazelle:scala_fix_wildcard_imports .scala examples.aeron.api.proto._
@@ -87,6 +153,12 @@ const (
 	// # gazelle:scala_deps_cleaner scala_proto_grpc_deps_cleaner
 	scalaDepsCleanerDirective = "scala_deps_cleaner"
 
+	// Turn on a runtime deps resolver.  When at least one is enabled, the
+	// 'runtime_deps' attribute of existing rules is managed.
+	//
+	// # gazelle:scala_runtime_deps_resolver service_loader runtime_dep_comment
+	scalaRuntimeDepsResolverDirective = "scala_runtime_deps_resolver"
+
 	// Declare an implicit import link.  If B "resolves with" A and A is a dependency, also include B.
 	//
 	// # gazelle:resolve_with scala akka.actor.ActorSystem com.typesafe.config.Config
@@ -146,6 +218,132 @@ const (
 	UnmanagedDepsPrivateAttrName = "_unmanaged_deps"
 )
 
+// DialectAuto is the value of the scala_dialect directive that selects
+// detection of the dialect.
+const DialectAuto = "auto"
+
+// DefaultFileRuleName is the name template used for rules generated in 'file'
+// granularity mode when the scala_file_rule_name directive is not set.
+const DefaultFileRuleName = "%{basename}_scala"
+
+// Granularity determines how .scala files are grouped into generated rules.
+type Granularity int
+
+const (
+	// GranularityDirectory generates rules for the files in a directory.
+	GranularityDirectory Granularity = 0
+	// GranularityPackage generates rules for the files in a directory and
+	// all subdirectories that are not themselves bazel packages.
+	GranularityPackage Granularity = 1
+	// GranularityFile generates one rule per file.
+	GranularityFile Granularity = 2
+)
+
+// String implements fmt.Stringer.
+func (g Granularity) String() string {
+	switch g {
+	case GranularityPackage:
+		return "package"
+	case GranularityFile:
+		return "file"
+	default:
+		return "directory"
+	}
+}
+
+// ParseGranularity parses the given string value.
+func ParseGranularity(value string) (Granularity, error) {
+	switch value {
+	case "directory":
+		return GranularityDirectory, nil
+	case "package":
+		return GranularityPackage, nil
+	case "file":
+		return GranularityFile, nil
+	default:
+		return GranularityDirectory, fmt.Errorf("unknown granularity %q (want one of file|package|directory)", value)
+	}
+}
+
+// ParseErrorPolicy determines how rules having files that fail to parse are
+// handled.
+type ParseErrorPolicy int
+
+const (
+	// ParseErrorPolicyKeepExisting leaves the deps of the rule untouched.
+	ParseErrorPolicyKeepExisting ParseErrorPolicy = 0
+	// ParseErrorPolicyWarn logs a warning and resolves the rule with the
+	// files that did parse.
+	ParseErrorPolicyWarn ParseErrorPolicy = 1
+	// ParseErrorPolicyFail reports the failing files and exits non-zero.
+	ParseErrorPolicyFail ParseErrorPolicy = 2
+)
+
+// String implements fmt.Stringer.
+func (p ParseErrorPolicy) String() string {
+	switch p {
+	case ParseErrorPolicyWarn:
+		return "warn"
+	case ParseErrorPolicyFail:
+		return "fail"
+	default:
+		return "keep_existing"
+	}
+}
+
+// ParseParseErrorPolicy parses the given string value.
+func ParseParseErrorPolicy(value string) (ParseErrorPolicy, error) {
+	switch value {
+	case "keep_existing":
+		return ParseErrorPolicyKeepExisting, nil
+	case "warn":
+		return ParseErrorPolicyWarn, nil
+	case "fail":
+		return ParseErrorPolicyFail, nil
+	default:
+		return ParseErrorPolicyKeepExisting, fmt.Errorf("unknown parse error policy %q (want one of fail|keep_existing|warn)", value)
+	}
+}
+
+// WildcardImportMode determines how wildcard imports of packages are
+// resolved.
+type WildcardImportMode int
+
+const (
+	// WildcardImportsPackage resolves a wildcard import to the package
+	// symbol.
+	WildcardImportsPackage WildcardImportMode = 0
+	// WildcardImportsNames resolves the names used in the file against the
+	// symbols in the package.
+	WildcardImportsNames WildcardImportMode = 1
+)
+
+// String implements fmt.Stringer.
+func (m WildcardImportMode) String() string {
+	switch m {
+	case WildcardImportsNames:
+		return "names"
+	default:
+		return "package"
+	}
+}
+
+// ParseWildcardImportMode parses the given string value.
+func ParseWildcardImportMode(value string) (WildcardImportMode, error) {
+	switch value {
+	case "package":
+		return WildcardImportsPackage, nil
+	case "names":
+		return WildcardImportsNames, nil
+	default:
+		return WildcardImportsPackage, fmt.Errorf("unknown wildcard import mode %q (want one of names|package)", value)
+	}
+}
+
+// DefaultTestFilePatterns is the list of filename patterns used to identify
+// test sources when the scala_test_file_patterns directive is not set.
+var DefaultTestFilePatterns = []string{"*Test.scala", "*Spec.scala", "*Suite.scala"}
+
 func DirectiveNames() []string {
 	return []string{
 		resolveConflictsDirective,
@@ -156,10 +354,19 @@ func DirectiveNames() []string {
 		scalaDebugDirective,
 		scalaLogLevelDirective,
 		scalaDepsCleanerDirective,
+		scalaRuntimeDepsResolverDirective,
 		scalaKeepUnmanagedDepsDirective,
 		scalaFixWildcardImportDirective,
 		scalaGenerateBuildFilesDirective,
+		scalaGenerateBinariesDirective,
+		scalaGranularityDirective,
+		scalaFileRuleNameDirective,
 		scalaRuleDirective,
+		scalaTestFilePatternsDirective,
+		scalaExportSignaturesDirective,
+		scalaParseErrorPolicyDirective,
+		scalaWildcardImportsDirective,
+		scalaDialectDirective,
 	}
 }
 
@@ -172,12 +379,22 @@ type Config struct {
 	implicitImports        []*implicitImportSpec
 	resolveFileSymbolNames []*resolveFileSymbolNameSpec
 	fixWildcardImportSpecs []*fixWildcardImportSpec
+	testFilePatterns       []string
+	granularity            Granularity
+	granularityRel         string
+	fileRuleName           string
+	dialect                string
+	parseErrorPolicy       ParseErrorPolicy
+	wildcardImports        WildcardImportMode
 	rules                  map[string]*scalarule.Config
 	labelNameRewrites      map[string]resolver.LabelNameRewriteSpec
 	annotations            map[debugAnnotation]interface{}
 	conflictResolvers      []resolver.ConflictResolver
 	depsCleaners           []resolver.DepsCleaner
+	runtimeDepsResolvers   []resolver.RuntimeDepsResolver
 	generateBuildFiles     bool
+	generateBinaries       bool
+	exportSignatures       bool
 	keepUnmanagedDeps      bool
 	sweepTransitiveDeps    bool
 	logger                 zerolog.Logger
@@ -226,8 +443,16 @@ func (c *Config) clone(config *config.Config, rel string) *Config {
 
 	clone.logLevel = c.logLevel
 	clone.generateBuildFiles = c.generateBuildFiles
+	clone.generateBinaries = c.generateBinaries
+	clone.exportSignatures = c.exportSignatures
 	clone.keepUnmanagedDeps = c.keepUnmanagedDeps
 	clone.sweepTransitiveDeps = c.sweepTransitiveDeps
+	clone.granularity = c.granularity
+	clone.granularityRel = c.granularityRel
+	clone.fileRuleName = c.fileRuleName
+	clone.dialect = c.dialect
+	clone.parseErrorPolicy = c.parseErrorPolicy
+	clone.wildcardImports = c.wildcardImports
 
 	for k, v := range c.annotations {
 		clone.annotations[k] = v
@@ -250,12 +475,18 @@ func (c *Config) clone(config *config.Config, rel string) *Config {
 	if c.depsCleaners != nil {
 		clone.depsCleaners = c.depsCleaners[:]
 	}
+	if c.runtimeDepsResolvers != nil {
+		clone.runtimeDepsResolvers = c.runtimeDepsResolvers[:]
+	}
 	if c.resolveFileSymbolNames != nil {
 		clone.resolveFileSymbolNames = c.resolveFileSymbolNames[:]
 	}
 	if c.fixWildcardImportSpecs != nil {
 		clone.fixWildcardImportSpecs = c.fixWildcardImportSpecs[:]
 	}
+	if c.testFilePatterns != nil {
+		clone.testFilePatterns = c.testFilePatterns[:]
+	}
 	return clone
 }
 
@@ -283,9 +514,15 @@ func (c *Config) shouldKeep(expr build.Expr, dep *resolver.ImportLabel, from lab
 	return false
 }
 
-func (c *Config) ResolveConflict(r *rule.Rule, imports resolver.ImportMap, imp *resolver.Import, symbol *resolver.Symbol) (*resolver.Symbol, bool) {
-	for _, resolver := range c.conflictResolvers {
-		if resolved, ok := resolver.ResolveConflict(c.universe, r, imports, imp, symbol); ok {
+func (c *Config) ResolveConflict(r *rule.Rule, from label.Label, imports resolver.ImportMap, imp *resolver.Import, symbol *resolver.Symbol) (*resolver.Symbol, bool) {
+	for _, cr := range c.conflictResolvers {
+		if lcr, ok := cr.(resolver.LabelConflictResolver); ok {
+			if resolved, ok := lcr.ResolveConflictFrom(c.universe, r, from, imports, imp, symbol); ok {
+				return resolved, true
+			}
+			continue
+		}
+		if resolved, ok := cr.ResolveConflict(c.universe, r, imports, imp, symbol); ok {
 			return resolved, true
 		}
 	}
@@ -335,6 +572,10 @@ func (c *Config) ParseDirectives(directives []rule.Directive) error {
 			if err := c.parseScalaDepsCleanerDirective(d); err != nil {
 				return err
 			}
+		case scalaRuntimeDepsResolverDirective:
+			if err := c.parseScalaRuntimeDepsResolverDirective(d); err != nil {
+				return err
+			}
 		case scalaLogLevelDirective:
 			if err := c.parseScalaLogLevelDirective(d); err != nil {
 				return err
@@ -347,6 +588,38 @@ func (c *Config) ParseDirectives(directives []rule.Directive) error {
 			if err := c.parseScalaGenerateBuildFilesDirective(d); err != nil {
 				return err
 			}
+		case scalaTestFilePatternsDirective:
+			if err := c.parseScalaTestFilePatternsDirective(d); err != nil {
+				return err
+			}
+		case scalaGenerateBinariesDirective:
+			if err := c.parseScalaGenerateBinariesDirective(d); err != nil {
+				return err
+			}
+		case scalaExportSignaturesDirective:
+			if err := c.parseScalaExportSignaturesDirective(d); err != nil {
+				return err
+			}
+		case scalaGranularityDirective:
+			if err := c.parseScalaGranularityDirective(d); err != nil {
+				return err
+			}
+		case scalaFileRuleNameDirective:
+			if err := c.parseScalaFileRuleNameDirective(d); err != nil {
+				return err
+			}
+		case scalaParseErrorPolicyDirective:
+			if err := c.parseScalaParseErrorPolicyDirective(d); err != nil {
+				return err
+			}
+		case scalaWildcardImportsDirective:
+			if err := c.parseScalaWildcardImportsDirective(d); err != nil {
+				return err
+			}
+		case scalaDialectDirective:
+			if err := c.parseScalaDialectDirective(d); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -498,6 +771,91 @@ func (c *Config) parseScalaGenerateBuildFilesDirective(d rule.Directive) error {
 	return nil
 }
 
+func (c *Config) parseScalaGenerateBinariesDirective(d rule.Directive) error {
+	val, err := strconv.ParseBool(d.Value)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %v", scalaGenerateBinariesDirective, err)
+	}
+	c.generateBinaries = val
+	return nil
+}
+
+func (c *Config) parseScalaExportSignaturesDirective(d rule.Directive) error {
+	val, err := strconv.ParseBool(d.Value)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %v", scalaExportSignaturesDirective, err)
+	}
+	c.exportSignatures = val
+	return nil
+}
+
+func (c *Config) parseScalaTestFilePatternsDirective(d rule.Directive) error {
+	patterns := strings.Fields(d.Value)
+	if len(patterns) == 0 {
+		return fmt.Errorf("invalid gazelle:%s directive: expected one or more filename patterns", scalaTestFilePatternsDirective)
+	}
+	for _, pattern := range patterns {
+		if !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("invalid gazelle:%s directive: bad pattern %q", scalaTestFilePatternsDirective, pattern)
+		}
+	}
+	c.testFilePatterns = patterns
+	return nil
+}
+
+func (c *Config) parseScalaGranularityDirective(d rule.Directive) error {
+	granularity, err := ParseGranularity(strings.TrimSpace(d.Value))
+	if err != nil {
+		return fmt.Errorf("invalid gazelle:%s directive: %w", scalaGranularityDirective, err)
+	}
+	c.granularity = granularity
+	c.granularityRel = c.rel
+	return nil
+}
+
+func (c *Config) parseScalaParseErrorPolicyDirective(d rule.Directive) error {
+	policy, err := ParseParseErrorPolicy(strings.TrimSpace(d.Value))
+	if err != nil {
+		return fmt.Errorf("invalid gazelle:%s directive: %w", scalaParseErrorPolicyDirective, err)
+	}
+	c.parseErrorPolicy = policy
+	return nil
+}
+
+func (c *Config) parseScalaWildcardImportsDirective(d rule.Directive) error {
+	mode, err := ParseWildcardImportMode(strings.TrimSpace(d.Value))
+	if err != nil {
+		return fmt.Errorf("invalid gazelle:%s directive: %w", scalaWildcardImportsDirective, err)
+	}
+	c.wildcardImports = mode
+	return nil
+}
+
+func (c *Config) parseScalaFileRuleNameDirective(d rule.Directive) error {
+	parts := strings.Fields(d.Value)
+	if len(parts) != 1 {
+		return fmt.Errorf("invalid gazelle:%s directive: expected [NAME_TEMPLATE], got %v", scalaFileRuleNameDirective, parts)
+	}
+	if !strings.Contains(parts[0], "%{basename}") {
+		return fmt.Errorf("invalid gazelle:%s directive: name template %q must contain %%{basename}", scalaFileRuleNameDirective, parts[0])
+	}
+	c.fileRuleName = parts[0]
+	return nil
+}
+
+func (c *Config) parseScalaDialectDirective(d rule.Directive) error {
+	parts := strings.Fields(d.Value)
+	if len(parts) != 1 {
+		return fmt.Errorf("invalid gazelle:%s directive: expected [auto|DIALECT], got %v", scalaDialectDirective, parts)
+	}
+	if parts[0] == DialectAuto {
+		c.dialect = ""
+	} else {
+		c.dialect = parts[0]
+	}
+	return nil
+}
+
 func (c *Config) parseScalaLogLevelDirective(d rule.Directive) error {
 	level, err := zerolog.ParseLevel(d.Value)
 	if err != nil {
@@ -532,6 +890,39 @@ func (c *Config) parseScalaDepsCleanerDirective(d rule.Directive) error {
 	return nil
 }
 
+func (c *Config) parseScalaRuntimeDepsResolverDirective(d rule.Directive) error {
+	for _, key := range strings.Fields(d.Value) {
+		intent := collections.ParseIntent(key)
+		if intent.Want {
+			rr, ok := c.universe.GetRuntimeDepsResolver(intent.Value)
+			if !ok {
+				return fmt.Errorf("invalid directive gazelle:%s: unknown scala runtime deps resolver %q", d.Key, intent.Value)
+			}
+			if !c.hasRuntimeDepsResolver(intent.Value) {
+				c.runtimeDepsResolvers = append(c.runtimeDepsResolvers, rr)
+			}
+		} else {
+			next := make([]resolver.RuntimeDepsResolver, 0, len(c.runtimeDepsResolvers))
+			for _, rr := range c.runtimeDepsResolvers {
+				if rr.Name() != intent.Value {
+					next = append(next, rr)
+				}
+			}
+			c.runtimeDepsResolvers = next
+		}
+	}
+	return nil
+}
+
+func (c *Config) hasRuntimeDepsResolver(name string) bool {
+	for _, rr := range c.runtimeDepsResolvers {
+		if rr.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) parseScalaAnnotation(d rule.Directive) error {
 	for _, key := range strings.Fields(d.Value) {
 		intent := collections.ParseIntent(key)
@@ -610,6 +1001,92 @@ func (c *Config) GenerateBuildFiles() bool {
 	return c.generateBuildFiles
 }
 
+// GenerateBinaries returns whether binary rules should be generated for
+// application entrypoints.
+func (c *Config) GenerateBinaries() bool {
+	return c.generateBinaries
+}
+
+// ShouldExportSignatures returns whether the types named in the public
+// signatures of library rules should be exported.
+func (c *Config) ShouldExportSignatures() bool {
+	return c.exportSignatures
+}
+
+// TestFilePatterns returns the list of filename patterns that identify test
+// sources.
+func (c *Config) TestFilePatterns() []string {
+	if c.testFilePatterns == nil {
+		return DefaultTestFilePatterns
+	}
+	return c.testFilePatterns
+}
+
+// Granularity returns the configured granularity of generated rules.
+func (c *Config) Granularity() Granularity {
+	return c.granularity
+}
+
+// ParseErrorPolicy returns the policy for rules having files that fail to
+// parse.
+func (c *Config) ParseErrorPolicy() ParseErrorPolicy {
+	return c.parseErrorPolicy
+}
+
+// WildcardImports returns how wildcard imports of packages are resolved.
+func (c *Config) WildcardImports() WildcardImportMode {
+	return c.wildcardImports
+}
+
+// SetParseErrorPolicy sets the policy for rules having files that fail to
+// parse.  It is used to apply the -scala_parse_error_policy flag to the root
+// config.
+func (c *Config) SetParseErrorPolicy(policy ParseErrorPolicy) {
+	c.parseErrorPolicy = policy
+}
+
+// GranularityRel returns the relative path of the package where the
+// granularity was configured.
+func (c *Config) GranularityRel() string {
+	return c.granularityRel
+}
+
+// Dialect returns the name of the scalameta dialect used to parse .scala
+// files, or the empty string if the dialect should be detected.
+func (c *Config) Dialect() string {
+	return c.dialect
+}
+
+// FileRuleName returns the name of the rule for the given filename in 'file'
+// granularity mode.
+func (c *Config) FileRuleName(filename string) string {
+	template := c.fileRuleName
+	if template == "" {
+		template = DefaultFileRuleName
+	}
+	base := path.Base(filename)
+	base = strings.TrimSuffix(base, path.Ext(base))
+	dirname := path.Base(c.rel)
+	if c.rel == "" {
+		dirname = filepath.Base(c.config.RepoRoot)
+	}
+	name := strings.ReplaceAll(template, "%{basename}", base)
+	name = strings.ReplaceAll(name, "%{dirname}", dirname)
+	return name
+}
+
+// IsTestFile tests whether the given filename matches one of the test file
+// patterns.  Patterns are matched against the base name of the file.
+func (c *Config) IsTestFile(filename string) bool {
+	base := path.Base(filename)
+	for _, pattern := range c.TestFilePatterns() {
+		if ok, _ := doublestar.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) depSuffixComment(imp *resolver.Import) *build.Comment {
 	if c.shouldAnnotateDepLabelOrigin() {
 		filename := "<filename unknown>"
@@ -709,6 +1186,29 @@ func (c *Config) Exports(exports resolver.ImportMap, r *rule.Rule, attrName stri
 	c.ruleAttrMergeDeps(exports, r, attrName, from)
 }
 
+func (c *Config) RuntimeDeps(imports resolver.ImportMap, r *rule.Rule, attrName string, from label.Label) {
+	c.ruleAttrMergeDeps(imports, r, attrName, from)
+}
+
+// ShouldManageRuntimeDeps returns whether the 'runtime_deps' attribute should
+// be managed (true if at least one runtime deps resolver is enabled).
+func (c *Config) ShouldManageRuntimeDeps() bool {
+	return len(c.runtimeDepsResolvers) > 0
+}
+
+// RuntimeImports collects the runtime imports of the given rule from all
+// enabled runtime deps resolvers.  The filenames are relative to the package
+// directory 'dir'.
+func (c *Config) RuntimeImports(r *rule.Rule, dir string, filenames []string) (resolver.ImportMap, error) {
+	imports := resolver.NewImportMap()
+	for _, rr := range c.runtimeDepsResolvers {
+		if err := rr.ResolveRuntimeDeps(r, dir, filenames, imports); err != nil {
+			return nil, fmt.Errorf("%s: %w", rr.Name(), err)
+		}
+	}
+	return imports, nil
+}
+
 func (c *Config) ruleAttrMergeDeps(
 	imports resolver.ImportMap,
 	r *rule.Rule,
@@ -1,6 +1,7 @@
 package scalaconfig
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"testing"
@@ -454,6 +455,373 @@ func TestScalaConfigParseFixWildcardImports(t *testing.T) {
 	}
 }
 
+func TestScalaConfigIsTestFile(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives []rule.Directive
+		filenames  []string
+		want       []bool
+		wantErr    error
+	}{
+		"degenerate": {
+			want: []bool{},
+		},
+		"default patterns": {
+			filenames: []string{"FooTest.scala", "FooSpec.scala", "FooSuite.scala", "Foo.scala", "src/FooTest.scala"},
+			want:      []bool{true, true, true, false, true},
+		},
+		"custom patterns": {
+			directives: []rule.Directive{
+				{Key: scalaTestFilePatternsDirective, Value: "*IT.scala Test*.scala"},
+			},
+			filenames: []string{"FooIT.scala", "TestFoo.scala", "FooTest.scala"},
+			want:      []bool{true, true, false},
+		},
+		"empty value": {
+			directives: []rule.Directive{
+				{Key: scalaTestFilePatternsDirective, Value: ""},
+			},
+			wantErr: fmt.Errorf("invalid gazelle:scala_test_file_patterns directive: expected one or more filename patterns"),
+		},
+		"bad pattern": {
+			directives: []rule.Directive{
+				{Key: scalaTestFilePatternsDirective, Value: "[*Test.scala"},
+			},
+			wantErr: fmt.Errorf(`invalid gazelle:scala_test_file_patterns directive: bad pattern "[*Test.scala"`),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sc, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "", tc.directives...)
+			if testutil.ExpectError(t, tc.wantErr, err) {
+				return
+			}
+			got := []bool{}
+			for _, filename := range tc.filenames {
+				got = append(got, sc.IsTestFile(filename))
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScalaConfigGranularity(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives []rule.Directive
+		want       Granularity
+		wantRel    string
+		wantErr    error
+	}{
+		"degenerate": {
+			want: GranularityDirectory,
+		},
+		"file": {
+			directives: []rule.Directive{
+				{Key: scalaGranularityDirective, Value: "file"},
+			},
+			want:    GranularityFile,
+			wantRel: "com/foo",
+		},
+		"package": {
+			directives: []rule.Directive{
+				{Key: scalaGranularityDirective, Value: "package"},
+			},
+			want:    GranularityPackage,
+			wantRel: "com/foo",
+		},
+		"unknown": {
+			directives: []rule.Directive{
+				{Key: scalaGranularityDirective, Value: "module"},
+			},
+			wantErr: fmt.Errorf(`invalid gazelle:scala_granularity directive: unknown granularity "module" (want one of file|package|directory)`),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sc, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "com/foo", tc.directives...)
+			if testutil.ExpectError(t, tc.wantErr, err) {
+				return
+			}
+			if diff := cmp.Diff(tc.want, sc.Granularity()); diff != "" {
+				t.Errorf("granularity (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantRel, sc.GranularityRel()); diff != "" {
+				t.Errorf("granularity rel (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScalaConfigParseErrorPolicy(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives []rule.Directive
+		want       ParseErrorPolicy
+		wantErr    error
+	}{
+		"degenerate": {
+			want: ParseErrorPolicyKeepExisting,
+		},
+		"warn": {
+			directives: []rule.Directive{
+				{Key: scalaParseErrorPolicyDirective, Value: "warn"},
+			},
+			want: ParseErrorPolicyWarn,
+		},
+		"fail": {
+			directives: []rule.Directive{
+				{Key: scalaParseErrorPolicyDirective, Value: "fail"},
+			},
+			want: ParseErrorPolicyFail,
+		},
+		"last one wins": {
+			directives: []rule.Directive{
+				{Key: scalaParseErrorPolicyDirective, Value: "fail"},
+				{Key: scalaParseErrorPolicyDirective, Value: "keep_existing"},
+			},
+			want: ParseErrorPolicyKeepExisting,
+		},
+		"unknown": {
+			directives: []rule.Directive{
+				{Key: scalaParseErrorPolicyDirective, Value: "ignore"},
+			},
+			wantErr: fmt.Errorf(`invalid gazelle:scala_parse_error_policy directive: unknown parse error policy "ignore" (want one of fail|keep_existing|warn)`),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sc, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "com/foo", tc.directives...)
+			if testutil.ExpectError(t, tc.wantErr, err) {
+				return
+			}
+			if diff := cmp.Diff(tc.want, sc.ParseErrorPolicy()); diff != "" {
+				t.Errorf("parse error policy (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScalaConfigWildcardImports(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives []rule.Directive
+		want       WildcardImportMode
+		wantErr    error
+	}{
+		"degenerate": {
+			want: WildcardImportsPackage,
+		},
+		"names": {
+			directives: []rule.Directive{
+				{Key: scalaWildcardImportsDirective, Value: "names"},
+			},
+			want: WildcardImportsNames,
+		},
+		"last one wins": {
+			directives: []rule.Directive{
+				{Key: scalaWildcardImportsDirective, Value: "names"},
+				{Key: scalaWildcardImportsDirective, Value: "package"},
+			},
+			want: WildcardImportsPackage,
+		},
+		"unknown": {
+			directives: []rule.Directive{
+				{Key: scalaWildcardImportsDirective, Value: "all"},
+			},
+			wantErr: fmt.Errorf(`invalid gazelle:scala_wildcard_imports directive: unknown wildcard import mode "all" (want one of names|package)`),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sc, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "com/foo", tc.directives...)
+			if testutil.ExpectError(t, tc.wantErr, err) {
+				return
+			}
+			if diff := cmp.Diff(tc.want, sc.WildcardImports()); diff != "" {
+				t.Errorf("wildcard imports (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScalaConfigShouldExportSignatures(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives []rule.Directive
+		want       bool
+		wantErr    error
+	}{
+		"degenerate": {
+			want: false,
+		},
+		"enabled": {
+			directives: []rule.Directive{
+				{Key: scalaExportSignaturesDirective, Value: "true"},
+			},
+			want: true,
+		},
+		"disabled": {
+			directives: []rule.Directive{
+				{Key: scalaExportSignaturesDirective, Value: "true"},
+				{Key: scalaExportSignaturesDirective, Value: "false"},
+			},
+			want: false,
+		},
+		"invalid": {
+			directives: []rule.Directive{
+				{Key: scalaExportSignaturesDirective, Value: "maybe"},
+			},
+			wantErr: fmt.Errorf(`parsing scala_export_signatures: strconv.ParseBool: parsing "maybe": invalid syntax`),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sc, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "", tc.directives...)
+			if testutil.ExpectError(t, tc.wantErr, err) {
+				return
+			}
+			if diff := cmp.Diff(tc.want, sc.ShouldExportSignatures()); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScalaConfigParseRuntimeDepsResolverDirective(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives []rule.Directive
+		want       []string
+		wantErr    error
+	}{
+		"degenerate": {
+			want: []string{},
+		},
+		"enable": {
+			directives: []rule.Directive{
+				{Key: scalaRuntimeDepsResolverDirective, Value: "+service_loader runtime_dep_comment"},
+			},
+			want: []string{"service_loader", "runtime_dep_comment"},
+		},
+		"enable is idempotent": {
+			directives: []rule.Directive{
+				{Key: scalaRuntimeDepsResolverDirective, Value: "service_loader"},
+				{Key: scalaRuntimeDepsResolverDirective, Value: "service_loader"},
+			},
+			want: []string{"service_loader"},
+		},
+		"disable": {
+			directives: []rule.Directive{
+				{Key: scalaRuntimeDepsResolverDirective, Value: "service_loader runtime_dep_comment"},
+				{Key: scalaRuntimeDepsResolverDirective, Value: "-service_loader"},
+			},
+			want: []string{"runtime_dep_comment"},
+		},
+		"unknown": {
+			directives: []rule.Directive{
+				{Key: scalaRuntimeDepsResolverDirective, Value: "reflection"},
+			},
+			wantErr: fmt.Errorf(`invalid directive gazelle:scala_runtime_deps_resolver: unknown scala runtime deps resolver "reflection"`),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			universe := mocks.NewUniverse(t)
+			universe.
+				On("GetRuntimeDepsResolver", mock.Anything).
+				Maybe().
+				Return(func(name string) (resolver.RuntimeDepsResolver, bool) {
+					return resolver.GlobalRuntimeDepsResolverRegistry().GetRuntimeDepsResolver(name)
+				})
+
+			sc, err := NewTestScalaConfig(t, universe, "", tc.directives...)
+			if testutil.ExpectError(t, tc.wantErr, err) {
+				return
+			}
+
+			got := []string{}
+			for _, rr := range sc.runtimeDepsResolvers {
+				got = append(got, rr.Name())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(len(tc.want) > 0, sc.ShouldManageRuntimeDeps()); diff != "" {
+				t.Errorf("should manage runtime deps (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScalaConfigFileRuleName(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives []rule.Directive
+		filename   string
+		want       string
+		wantErr    error
+	}{
+		"default template": {
+			filename: "Ids.scala",
+			want:     "Ids_scala",
+		},
+		"custom template": {
+			directives: []rule.Directive{
+				{Key: scalaFileRuleNameDirective, Value: "%{dirname}_%{basename}"},
+			},
+			filename: "src/Ids.scala",
+			want:     "foo_Ids",
+		},
+		"template without basename": {
+			directives: []rule.Directive{
+				{Key: scalaFileRuleNameDirective, Value: "%{dirname}"},
+			},
+			wantErr: errors.New(`invalid gazelle:scala_file_rule_name directive: name template "%{dirname}" must contain %{basename}`),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sc, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "com/foo", tc.directives...)
+			if testutil.ExpectError(t, tc.wantErr, err) {
+				return
+			}
+			if diff := cmp.Diff(tc.want, sc.FileRuleName(tc.filename)); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScalaConfigDialect(t *testing.T) {
+	for name, tc := range map[string]struct {
+		directives []rule.Directive
+		want       string
+		wantErr    error
+	}{
+		"degenerate": {
+			want: "",
+		},
+		"scala3": {
+			directives: []rule.Directive{
+				{Key: scalaDialectDirective, Value: "Scala3"},
+			},
+			want: "Scala3",
+		},
+		"auto": {
+			directives: []rule.Directive{
+				{Key: scalaDialectDirective, Value: "Scala3"},
+				{Key: scalaDialectDirective, Value: "auto"},
+			},
+			want: "",
+		},
+		"missing value": {
+			directives: []rule.Directive{
+				{Key: scalaDialectDirective, Value: ""},
+			},
+			wantErr: errors.New(`invalid gazelle:scala_dialect directive: expected [auto|DIALECT], got []`),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sc, err := NewTestScalaConfig(t, mocks.NewUniverse(t), "", tc.directives...)
+			if testutil.ExpectError(t, tc.wantErr, err) {
+				return
+			}
+			if diff := cmp.Diff(tc.want, sc.Dialect()); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestScalaConfigParseScalaAnnotate(t *testing.T) {
 	for name, tc := range map[string]struct {
 		directives []rule.Directive
@@ -9,3 +9,11 @@ func SemanticImports(in *spb.TextDocument) []string {
 	visitor.VisitTextDocument(in)
 	return visitor.SemanticImports()
 }
+
+// SemanticExports returns the list of types named in the signatures of the
+// public members of the given document.
+func SemanticExports(in *spb.TextDocument) []string {
+	visitor := NewTextDocumentVisitor()
+	visitor.VisitPublicSignatures(in)
+	return visitor.SemanticImports()
+}
@@ -77,6 +77,70 @@ func (v *TextDocumentVisitor) VisitTextDocument(node *spb.TextDocument) {
 	// TODO: occurrences? diagnostics? synthetics?
 }
 
+// VisitPublicSignatures visits the types named in the signatures of the public
+// members of the document: the parents of classes, traits and objects, the
+// parameter and return types of methods, the types of values, and the bounds
+// of type members.  Declarations are not visited recursively, as each member
+// is checked for visibility on its own.
+func (v *TextDocumentVisitor) VisitPublicSignatures(node *spb.TextDocument) {
+	index := make(map[string]*spb.SymbolInformation, len(node.Symbols))
+	for _, child := range node.Symbols {
+		index[child.Symbol] = child
+	}
+
+	for _, child := range node.Symbols {
+		if !isPublicMember(child) || child.Signature == nil {
+			continue
+		}
+		switch t := child.Signature.SealedValue.(type) {
+		case *spb.Signature_ClassSignature:
+			for _, parent := range t.ClassSignature.Parents {
+				v.VisitType(parent)
+			}
+		case *spb.Signature_MethodSignature:
+			for _, params := range t.MethodSignature.ParameterLists {
+				if params == nil {
+					continue
+				}
+				for _, symlink := range params.Symlinks {
+					if param, ok := index[symlink]; ok {
+						v.VisitSignature(param.Signature)
+					}
+				}
+				for _, param := range params.Hardlinks {
+					v.VisitSignature(param.Signature)
+				}
+			}
+			v.VisitType(t.MethodSignature.ReturnType)
+		case *spb.Signature_TypeSignature:
+			v.VisitType(t.TypeSignature.LowerBound)
+			v.VisitType(t.TypeSignature.UpperBound)
+		case *spb.Signature_ValueSignature:
+			v.VisitType(t.ValueSignature.Tpe)
+		}
+	}
+}
+
+// isPublicMember returns true if the symbol is a non-local member that has
+// public (or unspecified) access.
+func isPublicMember(node *spb.SymbolInformation) bool {
+	if strings.HasPrefix(node.Symbol, "local") {
+		return false
+	}
+	switch node.Kind {
+	case spb.SymbolInformation_LOCAL,
+		spb.SymbolInformation_PARAMETER,
+		spb.SymbolInformation_SELF_PARAMETER,
+		spb.SymbolInformation_TYPE_PARAMETER:
+		return false
+	}
+	if node.Access == nil || node.Access.SealedValue == nil {
+		return true
+	}
+	_, ok := node.Access.SealedValue.(*spb.Access_PublicAccess)
+	return ok
+}
+
 func (v *TextDocumentVisitor) VisitSymbolInformation(node *spb.SymbolInformation) {
 	if _, ok := v.symbols[node.Symbol]; ok {
 		return // already processed
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+
+	spb "github.com/stackb/scala-gazelle/scala/meta/semanticdb"
 )
 
 func TestToImport(t *testing.T) {
@@ -33,3 +35,87 @@ func TestToImport(t *testing.T) {
 		})
 	}
 }
+
+func TestSemanticExports(t *testing.T) {
+	typeRef := func(symbol string) *spb.Type {
+		return &spb.Type{SealedValue: &spb.Type_TypeRef{TypeRef: &spb.TypeRef{Symbol: symbol}}}
+	}
+	publicAccess := &spb.Access{SealedValue: &spb.Access_PublicAccess{PublicAccess: &spb.PublicAccess{}}}
+	privateAccess := &spb.Access{SealedValue: &spb.Access_PrivateAccess{PrivateAccess: &spb.PrivateAccess{}}}
+
+	for name, tc := range map[string]struct {
+		doc  *spb.TextDocument
+		want []string
+	}{
+		"degenerate": {
+			doc:  &spb.TextDocument{},
+			want: []string{},
+		},
+		"class parents": {
+			doc: &spb.TextDocument{
+				Symbols: []*spb.SymbolInformation{
+					{
+						Symbol: "a/Foo#",
+						Kind:   spb.SymbolInformation_CLASS,
+						Signature: &spb.Signature{SealedValue: &spb.Signature_ClassSignature{ClassSignature: &spb.ClassSignature{
+							Parents: []*spb.Type{typeRef("b/Bar#")},
+						}}},
+					},
+				},
+			},
+			want: []string{"b.Bar"},
+		},
+		"public method params and return type": {
+			doc: &spb.TextDocument{
+				Symbols: []*spb.SymbolInformation{
+					{
+						Symbol: "a/Foo#run().",
+						Kind:   spb.SymbolInformation_METHOD,
+						Access: publicAccess,
+						Signature: &spb.Signature{SealedValue: &spb.Signature_MethodSignature{MethodSignature: &spb.MethodSignature{
+							ParameterLists: []*spb.Scope{{Symlinks: []string{"a/Foo#run().(in)"}}},
+							ReturnType:     typeRef("c/Out#"),
+						}}},
+					},
+					{
+						Symbol: "a/Foo#run().(in)",
+						Kind:   spb.SymbolInformation_PARAMETER,
+						Signature: &spb.Signature{SealedValue: &spb.Signature_ValueSignature{ValueSignature: &spb.ValueSignature{
+							Tpe: typeRef("c/In#"),
+						}}},
+					},
+				},
+			},
+			want: []string{"c.In", "c.Out"},
+		},
+		"private members are skipped": {
+			doc: &spb.TextDocument{
+				Symbols: []*spb.SymbolInformation{
+					{
+						Symbol: "a/Foo#helper.",
+						Kind:   spb.SymbolInformation_FIELD,
+						Access: privateAccess,
+						Signature: &spb.Signature{SealedValue: &spb.Signature_ValueSignature{ValueSignature: &spb.ValueSignature{
+							Tpe: typeRef("d/Hidden#"),
+						}}},
+					},
+					{
+						Symbol: "local0",
+						Kind:   spb.SymbolInformation_LOCAL,
+						Signature: &spb.Signature{SealedValue: &spb.Signature_ValueSignature{ValueSignature: &spb.ValueSignature{
+							Tpe: typeRef("d/Local#"),
+						}}},
+					},
+				},
+			},
+			want: []string{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := SemanticExports(tc.doc)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
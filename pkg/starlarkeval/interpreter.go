@@ -42,6 +42,17 @@ func (i *Interpreter) GetGlobal(name string) starlark.Value {
 	return globals[name]
 }
 
+// SetGlobal predeclares the given value for subsequent calls to Exec.
+func (i *Interpreter) SetGlobal(name string, value starlark.Value) {
+	globals := *i.globals
+	globals[name] = value
+}
+
+// Call invokes the given function value with the positional arguments.
+func (i *Interpreter) Call(fn starlark.Value, args ...starlark.Value) (starlark.Value, error) {
+	return starlark.Call(i.thread, fn, starlark.Tuple(args), nil)
+}
+
 func (i *Interpreter) handleDepset(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	// always expect a single list argument for depsets
 	if len(args) != 1 {
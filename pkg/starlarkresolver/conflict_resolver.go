@@ -0,0 +1,87 @@
+package starlarkresolver
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/rs/zerolog"
+	"go.starlark.net/starlark"
+
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+)
+
+// ConflictResolver implements resolver.ConflictResolver by calling a starlark
+// function with the signature:
+//
+//	def implementation(rule, imp, symbols):
+//
+// The symbols are the candidates, the first one being the symbol that was
+// registered first.  The function returns the label of the chosen candidate,
+// or None to leave the conflict to the next resolver.
+type ConflictResolver struct {
+	script *Script
+	name   string
+	impl   starlark.Callable
+}
+
+// Name implements part of the resolver.ConflictResolver interface.
+func (s *ConflictResolver) Name() string {
+	return s.name
+}
+
+// RegisterFlags implements part of the resolver.ConflictResolver interface.
+func (s *ConflictResolver) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config, logger zerolog.Logger) {
+}
+
+// CheckFlags implements part of the resolver.ConflictResolver interface.
+func (s *ConflictResolver) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	return nil
+}
+
+// ResolveConflict implements part of the resolver.ConflictResolver interface.
+func (s *ConflictResolver) ResolveConflict(universe resolver.Universe, r *rule.Rule, imports resolver.ImportMap, imp *resolver.Import, symbol *resolver.Symbol) (*resolver.Symbol, bool) {
+	return s.ResolveConflictFrom(universe, r, label.NoLabel, imports, imp, symbol)
+}
+
+// ResolveConflictFrom implements the resolver.LabelConflictResolver interface.
+// A failure of the script is fatal.
+func (s *ConflictResolver) ResolveConflictFrom(universe resolver.Universe, r *rule.Rule, from label.Label, imports resolver.ImportMap, imp *resolver.Import, symbol *resolver.Symbol) (*resolver.Symbol, bool) {
+	resolved, err := s.Resolve(r, from, imp, symbol)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return resolved, resolved != nil
+}
+
+// Resolve calls the implementation function.  A nil symbol is returned if the
+// function returned None.
+func (s *ConflictResolver) Resolve(r *rule.Rule, from label.Label, imp *resolver.Import, symbol *resolver.Symbol) (*resolver.Symbol, error) {
+	candidates := append([]*resolver.Symbol{symbol}, symbol.Conflicts...)
+
+	value, err := s.script.call(s.impl, ruleValue(r, from), importValue(imp), symbolsValue(candidates))
+	if err != nil {
+		return nil, s.errorf("%w", err)
+	}
+	if value == starlark.None {
+		return nil, nil
+	}
+
+	want, err := parseLabel(value, from)
+	if err != nil {
+		return nil, s.errorf("resolving %q: %w", symbol.Name, err)
+	}
+	for _, sym := range candidates {
+		if labelString(sym.Label) == labelString(want) {
+			return sym, nil
+		}
+	}
+	return nil, s.errorf("resolving %q: %s is not one of the candidates", symbol.Name, value)
+}
+
+func (s *ConflictResolver) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: conflict_resolver %q: %w", s.script.filename, s.name, fmt.Errorf(format, args...))
+}
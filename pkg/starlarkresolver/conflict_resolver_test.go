@@ -0,0 +1,160 @@
+package starlarkresolver_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/google/go-cmp/cmp"
+
+	sppb "github.com/stackb/scala-gazelle/build/stack/gazelle/scala/parse"
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+	"github.com/stackb/scala-gazelle/pkg/starlarkresolver"
+)
+
+func TestConflictResolver(t *testing.T) {
+	symbol := &resolver.Symbol{
+		Type:     sppb.ImportType_PACKAGE,
+		Name:     "org.json4s",
+		Label:    label.Label{Repo: "maven", Name: "org_json4s_json4s_core_2_13"},
+		Provider: "maven",
+		Conflicts: []*resolver.Symbol{
+			{
+				Type:     sppb.ImportType_PACKAGE,
+				Name:     "org.json4s",
+				Label:    label.Label{Pkg: "a", Name: "json4s"},
+				Provider: "source",
+			},
+			{
+				Type:     sppb.ImportType_PACKAGE,
+				Name:     "org.json4s",
+				Label:    label.NoLabel,
+				Provider: "java",
+			},
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		src     string
+		want    label.Label
+		wantOk  bool
+		wantErr string
+	}{
+		"returns None": {
+			src: `
+def _resolve(rule, imp, symbols):
+    return None
+`,
+		},
+		"selects symbol": {
+			src: `
+def _resolve(rule, imp, symbols):
+    return symbols[0].label
+`,
+			want:   label.Label{Repo: "maven", Name: "org_json4s_json4s_core_2_13"},
+			wantOk: true,
+		},
+		"selects conflict by provider": {
+			src: `
+def _resolve(rule, imp, symbols):
+    for sym in symbols:
+        if sym.provider == "source":
+            return sym.label
+    return None
+`,
+			want:   label.Label{Pkg: "a", Name: "json4s"},
+			wantOk: true,
+		},
+		"selects relative label": {
+			src: `
+def _resolve(rule, imp, symbols):
+    return ":json4s"
+`,
+			want:   label.Label{Pkg: "a", Name: "json4s"},
+			wantOk: true,
+		},
+		"selects platform symbol": {
+			src: `
+def _resolve(rule, imp, symbols):
+    return ""
+`,
+			want:   label.NoLabel,
+			wantOk: true,
+		},
+		"receives rule and import": {
+			src: `
+def _resolve(rule, imp, symbols):
+    if rule.kind != "scala_library" or rule.name != "lib" or rule.label != "//a:lib":
+        fail("rule: %s %s %s" % (rule.kind, rule.name, rule.label))
+    if rule.attrs["deps"] != ["@maven//:org_json4s_json4s_core_2_13"]:
+        fail("deps: %s" % rule.attrs["deps"])
+    if imp.imp != "org.json4s" or imp.kind != "DIRECT" or imp.source != "a/Main.scala":
+        fail("imp: %s %s %s" % (imp.imp, imp.kind, imp.source))
+    if [sym.type for sym in symbols] != ["PACKAGE", "PACKAGE", "PACKAGE"]:
+        fail("symbols: %s" % symbols)
+    return rule.attrs["deps"][0]
+`,
+			want:   label.Label{Repo: "maven", Name: "org_json4s_json4s_core_2_13"},
+			wantOk: true,
+		},
+		"error on script failure": {
+			src: `
+def _resolve(rule, imp, symbols):
+    fail("no policy for " + imp.imp)
+`,
+			wantErr: `test.star: conflict_resolver "test": Traceback`,
+		},
+		"error on label not among candidates": {
+			src: `
+def _resolve(rule, imp, symbols):
+    return "//b:b"
+`,
+			wantErr: `test.star: conflict_resolver "test": resolving "org.json4s": "//b:b" is not one of the candidates`,
+		},
+		"error on wrong return type": {
+			src: `
+def _resolve(rule, imp, symbols):
+    return 1
+`,
+			wantErr: `test.star: conflict_resolver "test": resolving "org.json4s": want label string, got int`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			script, err := starlarkresolver.Load("test.star", strings.NewReader(tc.src+`
+conflict_resolver(name = "test", implementation = _resolve)
+`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(script.ConflictResolvers()) != 1 {
+				t.Fatalf("want 1 conflict resolver, got %d", len(script.ConflictResolvers()))
+			}
+			cr := script.ConflictResolvers()[0]
+
+			r := rule.NewRule("scala_library", "lib")
+			r.SetAttr("deps", []string{"@maven//:org_json4s_json4s_core_2_13"})
+			from := label.Label{Pkg: "a", Name: "lib"}
+			imp := resolver.NewDirectImport("org.json4s", &sppb.File{Filename: "a/Main.scala"})
+
+			got, err := cr.Resolve(r, from, imp, symbol)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("error: want %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.wantOk, got != nil); diff != "" {
+				t.Errorf("ok (-want +got):\n%s", diff)
+			}
+			if got != nil {
+				if diff := cmp.Diff(tc.want, got.Label); diff != "" {
+					t.Errorf("(-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
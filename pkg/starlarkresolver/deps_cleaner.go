@@ -0,0 +1,102 @@
+package starlarkresolver
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"go.starlark.net/starlark"
+)
+
+// DepsCleaner implements resolver.DepsCleaner by calling a starlark function
+// with the signature:
+//
+//	def implementation(rule, deps):
+//
+// The deps are the sorted labels of the resolved dependencies.  The function
+// returns the list of labels to keep; the others are removed.
+type DepsCleaner struct {
+	script *Script
+	name   string
+	impl   starlark.Callable
+}
+
+// Name implements part of the resolver.DepsCleaner interface.
+func (s *DepsCleaner) Name() string {
+	return s.name
+}
+
+// RegisterFlags implements part of the resolver.DepsCleaner interface.
+func (s *DepsCleaner) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {
+}
+
+// CheckFlags implements part of the resolver.DepsCleaner interface.
+func (s *DepsCleaner) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	return nil
+}
+
+// CleanDeps implements part of the resolver.DepsCleaner interface.  A failure
+// of the script is fatal.
+func (s *DepsCleaner) CleanDeps(deps map[label.Label]bool, r *rule.Rule, from label.Label) {
+	if err := s.Clean(deps, r, from); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Clean calls the implementation function and marks the deps that were not
+// returned as unwanted.  Deps may be relative to the package of from (":foo");
+// they are matched against the returned labels in their absolute form.
+func (s *DepsCleaner) Clean(deps map[label.Label]bool, r *rule.Rule, from label.Label) error {
+	wanted := make(map[string]label.Label, len(deps))
+	labels := make([]label.Label, 0, len(deps))
+	for dep, want := range deps {
+		if !want {
+			continue
+		}
+		wanted[labelString(dep.Abs(from.Repo, from.Pkg))] = dep
+		labels = append(labels, dep)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labelString(labels[i]) < labelString(labels[j])
+	})
+
+	value, err := s.script.call(s.impl, ruleValue(r, from), labelsValue(labels))
+	if err != nil {
+		return s.errorf("%w", err)
+	}
+	iterable, ok := value.(starlark.Iterable)
+	if !ok {
+		return s.errorf("want list of labels, got %s", value.Type())
+	}
+
+	keep := make(map[label.Label]bool)
+	iter := iterable.Iterate()
+	defer iter.Done()
+	var elem starlark.Value
+	for iter.Next(&elem) {
+		lbl, err := parseLabel(elem, from)
+		if err != nil {
+			return s.errorf("%w", err)
+		}
+		dep, ok := wanted[labelString(lbl.Abs(from.Repo, from.Pkg))]
+		if !ok {
+			return s.errorf("%s is not one of the deps", elem)
+		}
+		keep[dep] = true
+	}
+
+	for _, dep := range labels {
+		if !keep[dep] {
+			deps[dep] = false
+		}
+	}
+	return nil
+}
+
+func (s *DepsCleaner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: deps_cleaner %q: %w", s.script.filename, s.name, fmt.Errorf(format, args...))
+}
@@ -0,0 +1,174 @@
+package starlarkresolver_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/stackb/scala-gazelle/pkg/starlarkresolver"
+)
+
+func TestDepsCleaner(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src     string
+		deps    map[label.Label]bool
+		want    map[label.Label]bool
+		wantErr string
+	}{
+		"keeps all": {
+			src: `
+def _clean(rule, deps):
+    return deps
+`,
+			deps: map[label.Label]bool{
+				{Pkg: "a", Name: "a"}: true,
+				{Pkg: "b", Name: "b"}: true,
+			},
+			want: map[label.Label]bool{
+				{Pkg: "a", Name: "a"}: true,
+				{Pkg: "b", Name: "b"}: true,
+			},
+		},
+		"removes unreturned deps": {
+			src: `
+def _clean(rule, deps):
+    return [dep for dep in deps if not dep.startswith("@maven//:")]
+`,
+			deps: map[label.Label]bool{
+				{Pkg: "a", Name: "a"}:                     true,
+				{Repo: "maven", Name: "com_google_guava"}: true,
+			},
+			want: map[label.Label]bool{
+				{Pkg: "a", Name: "a"}:                     true,
+				{Repo: "maven", Name: "com_google_guava"}: false,
+			},
+		},
+		"unwanted deps are not passed": {
+			src: `
+def _clean(rule, deps):
+    if deps != ["//a"]:
+        fail("deps: %s" % deps)
+    return deps
+`,
+			deps: map[label.Label]bool{
+				{Pkg: "a", Name: "a"}: true,
+				{Pkg: "b", Name: "b"}: false,
+			},
+			want: map[label.Label]bool{
+				{Pkg: "a", Name: "a"}: true,
+				{Pkg: "b", Name: "b"}: false,
+			},
+		},
+		"accepts relative labels": {
+			src: `
+def _clean(rule, deps):
+    return [":lib_util"]
+`,
+			deps: map[label.Label]bool{
+				{Pkg: "lib", Name: "lib_util"}: true,
+				{Pkg: "b", Name: "b"}:          true,
+			},
+			want: map[label.Label]bool{
+				{Pkg: "lib", Name: "lib_util"}: true,
+				{Pkg: "b", Name: "b"}:          false,
+			},
+		},
+		"matches relative deps": {
+			src: `
+def _clean(rule, deps):
+    if deps != ["//b", ":lib_impl", ":lib_util"]:
+        fail("deps: %s" % deps)
+    return [dep for dep in deps if dep != "//b"] + ["//lib:lib_impl"]
+`,
+			deps: map[label.Label]bool{
+				{Name: "lib_util", Relative: true}: true,
+				{Name: "lib_impl", Relative: true}: true,
+				{Pkg: "b", Name: "b"}:              true,
+			},
+			want: map[label.Label]bool{
+				{Name: "lib_util", Relative: true}: true,
+				{Name: "lib_impl", Relative: true}: true,
+				{Pkg: "b", Name: "b"}:              false,
+			},
+		},
+		"receives rule": {
+			src: `
+def _clean(rule, deps):
+    if rule.attrs.get("testonly") != True:
+        return deps
+    return []
+`,
+			deps: map[label.Label]bool{
+				{Pkg: "a", Name: "a"}: true,
+			},
+			want: map[label.Label]bool{
+				{Pkg: "a", Name: "a"}: false,
+			},
+		},
+		"error on script failure": {
+			src: `
+def _clean(rule, deps):
+    return deps[10]
+`,
+			deps: map[label.Label]bool{
+				{Pkg: "a", Name: "a"}: true,
+			},
+			wantErr: `test.star: deps_cleaner "test": Traceback`,
+		},
+		"error on unknown dep": {
+			src: `
+def _clean(rule, deps):
+    return ["//c"]
+`,
+			deps: map[label.Label]bool{
+				{Pkg: "a", Name: "a"}: true,
+			},
+			wantErr: `test.star: deps_cleaner "test": "//c" is not one of the deps`,
+		},
+		"error on wrong return type": {
+			src: `
+def _clean(rule, deps):
+    return None
+`,
+			wantErr: `test.star: deps_cleaner "test": want list of labels, got NoneType`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			script, err := starlarkresolver.Load("test.star", strings.NewReader(tc.src+`
+deps_cleaner(name = "test", implementation = _clean)
+`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(script.DepsCleaners()) != 1 {
+				t.Fatalf("want 1 deps cleaner, got %d", len(script.DepsCleaners()))
+			}
+			dc := script.DepsCleaners()[0]
+
+			r := rule.NewRule("scala_library", "lib")
+			r.SetAttr("testonly", true)
+			from := label.Label{Pkg: "lib", Name: "lib"}
+
+			deps := tc.deps
+			if deps == nil {
+				deps = make(map[label.Label]bool)
+			}
+			err = dc.Clean(deps, r, from)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("error: want %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, deps); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
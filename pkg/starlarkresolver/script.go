@@ -0,0 +1,137 @@
+// Package starlarkresolver implements conflict resolvers and deps cleaners
+// whose policy is written in starlark.
+package starlarkresolver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"go.starlark.net/starlark"
+
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+	"github.com/stackb/scala-gazelle/pkg/starlarkeval"
+)
+
+// Script is a loaded .star file.  Executing the file registers the conflict
+// resolvers and deps cleaners declared by calls to the conflict_resolver() and
+// deps_cleaner() builtins:
+//
+//	def _resolve(rule, imp, symbols):
+//	    return symbols[0].label
+//
+//	conflict_resolver(name = "first", implementation = _resolve)
+type Script struct {
+	filename    string
+	interpreter *starlarkeval.Interpreter
+
+	conflictResolvers []*ConflictResolver
+	depsCleaners      []*DepsCleaner
+}
+
+// LoadFile reads and executes the given .star file.
+func LoadFile(filename string) (*Script, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(filename, f)
+}
+
+// Load executes the script read from src.  The filename is used for error
+// messages.
+func Load(filename string, src io.Reader) (*Script, error) {
+	s := &Script{filename: filename}
+	s.interpreter = starlarkeval.NewInterpreter(func(format string, args ...interface{}) {
+		// the interpreter passes print() messages as-is, not as a format
+		// string.
+		log.Printf("%s: %s", filename, format)
+	})
+	s.interpreter.SetGlobal("conflict_resolver", starlark.NewBuiltin("conflict_resolver", s.conflictResolverBuiltin))
+	s.interpreter.SetGlobal("deps_cleaner", starlark.NewBuiltin("deps_cleaner", s.depsCleanerBuiltin))
+
+	if err := s.interpreter.Exec(filename, src); err != nil {
+		return nil, fmt.Errorf("loading %s: %w", filename, scriptError(err))
+	}
+	return s, nil
+}
+
+// Filename returns the name of the script file.
+func (s *Script) Filename() string {
+	return s.filename
+}
+
+// ConflictResolvers returns the conflict resolvers declared by the script, in
+// declaration order.
+func (s *Script) ConflictResolvers() []*ConflictResolver {
+	return s.conflictResolvers
+}
+
+// DepsCleaners returns the deps cleaners declared by the script, in
+// declaration order.
+func (s *Script) DepsCleaners() []*DepsCleaner {
+	return s.depsCleaners
+}
+
+// Register puts the conflict resolvers and deps cleaners of the script into
+// the given registries.
+func (s *Script) Register(conflictResolvers resolver.ConflictResolverRegistry, depsCleaners resolver.DepsCleanerRegistry) error {
+	for _, cr := range s.conflictResolvers {
+		if err := conflictResolvers.PutConflictResolver(cr.Name(), cr); err != nil {
+			return fmt.Errorf("%s: %w", s.filename, err)
+		}
+	}
+	for _, dc := range s.depsCleaners {
+		if err := depsCleaners.PutDepsCleaner(dc.Name(), dc); err != nil {
+			return fmt.Errorf("%s: %w", s.filename, err)
+		}
+	}
+	return nil
+}
+
+func (s *Script) conflictResolverBuiltin(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var impl starlark.Callable
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "implementation", &impl); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%s: name must not be empty", b.Name())
+	}
+	s.conflictResolvers = append(s.conflictResolvers, &ConflictResolver{script: s, name: name, impl: impl})
+	return starlark.None, nil
+}
+
+func (s *Script) depsCleanerBuiltin(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var impl starlark.Callable
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "implementation", &impl); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%s: name must not be empty", b.Name())
+	}
+	s.depsCleaners = append(s.depsCleaners, &DepsCleaner{script: s, name: name, impl: impl})
+	return starlark.None, nil
+}
+
+// call invokes the implementation function of a resolver or cleaner.
+func (s *Script) call(impl starlark.Callable, args ...starlark.Value) (starlark.Value, error) {
+	value, err := s.interpreter.Call(impl, args...)
+	if err != nil {
+		return nil, scriptError(err)
+	}
+	return value, nil
+}
+
+// scriptError includes the starlark stack in evaluation errors.
+func scriptError(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(evalErr.Backtrace())
+	}
+	return err
+}
@@ -0,0 +1,156 @@
+package starlarkresolver_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+	"github.com/stackb/scala-gazelle/pkg/starlarkresolver"
+)
+
+func TestLoad(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src                   string
+		wantConflictResolvers []string
+		wantDepsCleaners      []string
+		wantErr               string
+	}{
+		"degenerate": {},
+		"registers resolvers and cleaners": {
+			src: `
+def _resolve(rule, imp, symbols):
+    return None
+
+def _clean(rule, deps):
+    return deps
+
+conflict_resolver(name = "b", implementation = _resolve)
+conflict_resolver(name = "a", implementation = _resolve)
+deps_cleaner(name = "c", implementation = _clean)
+`,
+			wantConflictResolvers: []string{"b", "a"},
+			wantDepsCleaners:      []string{"c"},
+		},
+		"syntax error": {
+			src:     `def`,
+			wantErr: "loading test.star: test.star:1:4: not an identifier",
+		},
+		"evaluation error": {
+			src:     `fail("boom")`,
+			wantErr: "loading test.star: Traceback",
+		},
+		"missing implementation": {
+			src:     `conflict_resolver(name = "a")`,
+			wantErr: "conflict_resolver: missing argument for implementation",
+		},
+		"implementation not callable": {
+			src:     `deps_cleaner(name = "a", implementation = "a")`,
+			wantErr: `deps_cleaner: for parameter "implementation": got string, want callable`,
+		},
+		"empty name": {
+			src: `
+def _clean(rule, deps):
+    return deps
+
+deps_cleaner(name = "", implementation = _clean)
+`,
+			wantErr: "deps_cleaner: name must not be empty",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			script, err := starlarkresolver.Load("test.star", strings.NewReader(tc.src))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("error: want %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotConflictResolvers, gotDepsCleaners []string
+			for _, cr := range script.ConflictResolvers() {
+				gotConflictResolvers = append(gotConflictResolvers, cr.Name())
+			}
+			for _, dc := range script.DepsCleaners() {
+				gotDepsCleaners = append(gotDepsCleaners, dc.Name())
+			}
+			if diff := cmp.Diff(tc.wantConflictResolvers, gotConflictResolvers); diff != "" {
+				t.Errorf("conflict resolvers (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantDepsCleaners, gotDepsCleaners); diff != "" {
+				t.Errorf("deps cleaners (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScriptRegister(t *testing.T) {
+	script, err := starlarkresolver.Load("test.star", strings.NewReader(`
+def _resolve(rule, imp, symbols):
+    return None
+
+def _clean(rule, deps):
+    return deps
+
+conflict_resolver(name = "a", implementation = _resolve)
+deps_cleaner(name = "b", implementation = _clean)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := make(testRegistry)
+	if err := script.Register(registry, registry); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := registry.GetConflictResolver("a"); !ok {
+		t.Error("conflict resolver was not registered")
+	}
+	if _, ok := registry.GetDepsCleaner("b"); !ok {
+		t.Error("deps cleaner was not registered")
+	}
+	err = script.Register(registry, registry)
+	if diff := cmp.Diff(`test.star: duplicate "a"`, errString(err)); diff != "" {
+		t.Errorf("duplicate registration (-want +got):\n%s", diff)
+	}
+}
+
+// testRegistry implements both the resolver.ConflictResolverRegistry and the
+// resolver.DepsCleanerRegistry interfaces.
+type testRegistry map[string]interface{}
+
+func (r testRegistry) GetConflictResolver(name string) (resolver.ConflictResolver, bool) {
+	cr, ok := r[name].(resolver.ConflictResolver)
+	return cr, ok
+}
+
+func (r testRegistry) PutConflictResolver(name string, cr resolver.ConflictResolver) error {
+	return r.put(name, cr)
+}
+
+func (r testRegistry) GetDepsCleaner(name string) (resolver.DepsCleaner, bool) {
+	dc, ok := r[name].(resolver.DepsCleaner)
+	return dc, ok
+}
+
+func (r testRegistry) PutDepsCleaner(name string, dc resolver.DepsCleaner) error {
+	return r.put(name, dc)
+}
+
+func (r testRegistry) put(name string, value interface{}) error {
+	if _, ok := r[name]; ok {
+		return fmt.Errorf("duplicate %q", name)
+	}
+	r[name] = value
+	return nil
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
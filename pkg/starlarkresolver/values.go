@@ -0,0 +1,153 @@
+package starlarkresolver
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/bazelbuild/buildtools/build"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/stackb/scala-gazelle/pkg/resolver"
+)
+
+// ruleValue represents the rule as a struct with fields kind, name, label and
+// attrs.
+func ruleValue(r *rule.Rule, from label.Label) starlark.Value {
+	attrs := starlark.NewDict(len(r.AttrKeys()))
+	for _, key := range r.AttrKeys() {
+		attrs.SetKey(starlark.String(key), exprValue(r.Attr(key)))
+	}
+	attrs.Freeze()
+
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"kind":  starlark.String(r.Kind()),
+		"name":  starlark.String(r.Name()),
+		"label": starlark.String(labelString(from)),
+		"attrs": attrs,
+	})
+}
+
+// importValue represents the import as a struct with fields imp, kind, source
+// and src.
+func importValue(imp *resolver.Import) starlark.Value {
+	var source string
+	if imp.Source != nil {
+		source = imp.Source.Filename
+	}
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"imp":    starlark.String(imp.Imp),
+		"kind":   starlark.String(imp.Kind.String()),
+		"source": starlark.String(source),
+		"src":    starlark.String(imp.Src),
+	})
+}
+
+// symbolsValue represents the symbols as a frozen list of structs with fields
+// name, type, label and provider.
+func symbolsValue(symbols []*resolver.Symbol) starlark.Value {
+	elems := make([]starlark.Value, len(symbols))
+	for i, sym := range symbols {
+		elems[i] = starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+			"name":     starlark.String(sym.Name),
+			"type":     starlark.String(sym.Type.String()),
+			"label":    starlark.String(labelString(sym.Label)),
+			"provider": starlark.String(sym.Provider),
+		})
+	}
+	list := starlark.NewList(elems)
+	list.Freeze()
+	return list
+}
+
+// labelsValue represents the labels as a frozen list of strings.
+func labelsValue(labels []label.Label) starlark.Value {
+	elems := make([]starlark.Value, len(labels))
+	for i, lbl := range labels {
+		elems[i] = starlark.String(labelString(lbl))
+	}
+	list := starlark.NewList(elems)
+	list.Freeze()
+	return list
+}
+
+// exprValue converts an attribute value to starlark.  Strings, numbers,
+// booleans, None, lists and dicts are converted; other expressions are given
+// as their source text.
+func exprValue(expr build.Expr) starlark.Value {
+	switch t := expr.(type) {
+	case *build.StringExpr:
+		return starlark.String(t.Value)
+	case *build.Ident:
+		if value, ok := constantValue(t.Name); ok {
+			return value
+		}
+	case *build.LiteralExpr:
+		if value, ok := constantValue(t.Token); ok {
+			return value
+		}
+		if i, err := strconv.ParseInt(t.Token, 0, 64); err == nil {
+			return starlark.MakeInt64(i)
+		}
+	case *build.ListExpr:
+		elems := make([]starlark.Value, len(t.List))
+		for i, elem := range t.List {
+			elems[i] = exprValue(elem)
+		}
+		list := starlark.NewList(elems)
+		list.Freeze()
+		return list
+	case *build.DictExpr:
+		dict := starlark.NewDict(len(t.List))
+		for _, kv := range t.List {
+			if err := dict.SetKey(exprValue(kv.Key), exprValue(kv.Value)); err != nil {
+				return starlark.String(build.FormatString(expr))
+			}
+		}
+		dict.Freeze()
+		return dict
+	}
+	return starlark.String(build.FormatString(expr))
+}
+
+// constantValue converts the predeclared constants.
+func constantValue(name string) (starlark.Value, bool) {
+	switch name {
+	case "True":
+		return starlark.True, true
+	case "False":
+		return starlark.False, true
+	case "None":
+		return starlark.None, true
+	}
+	return nil, false
+}
+
+// labelString formats the label; symbols provided by the platform (having no
+// label) are represented by the empty string.
+func labelString(lbl label.Label) string {
+	if lbl == label.NoLabel {
+		return ""
+	}
+	return lbl.String()
+}
+
+// parseLabel parses a label returned by a script.  Relative labels are taken
+// to be relative to the package of from, and the empty string is
+// label.NoLabel.
+func parseLabel(value starlark.Value, from label.Label) (label.Label, error) {
+	str, ok := value.(starlark.String)
+	if !ok {
+		return label.NoLabel, fmt.Errorf("want label string, got %s", value.Type())
+	}
+	if str == "" {
+		return label.NoLabel, nil
+	}
+	lbl, err := label.Parse(string(str))
+	if err != nil {
+		return label.NoLabel, err
+	}
+	return lbl.Abs(from.Repo, from.Pkg), nil
+}